@@ -0,0 +1,275 @@
+package database
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// AutoAssign configures automatic claiming of tickets opened from a panel, or from the panels of a support team. Only
+// members of the panel's teams who are on call are considered, and the ticket's opener is never assigned.
+type AutoAssign struct {
+	*pgxpool.Pool
+}
+
+type AutoAssignStrategy string
+
+const (
+	// AutoAssignRoundRobin assigns each candidate in turn
+	AutoAssignRoundRobin AutoAssignStrategy = "round_robin"
+	// AutoAssignLeastOpenClaims assigns the candidate with the fewest claimed tickets that are still open
+	AutoAssignLeastOpenClaims AutoAssignStrategy = "least_open_claims"
+	// AutoAssignLongestIdle assigns the candidate who was least recently auto-assigned a ticket
+	AutoAssignLongestIdle AutoAssignStrategy = "longest_idle"
+)
+
+// AutoAssignSettings apply to either a panel or a support team. Settings for a panel take priority over the settings of
+// its teams.
+type AutoAssignSettings struct {
+	Id       int                `json:"id"`
+	GuildId  uint64             `json:"guild_id,string"`
+	PanelId  *int               `json:"panel_id"`
+	TeamId   *int               `json:"team_id"`
+	Strategy AutoAssignStrategy `json:"strategy"`
+	Enabled  bool               `json:"enabled"`
+}
+
+type AutoAssignment struct {
+	UserId         uint64             `json:"user_id,string"`
+	SettingsId     int                `json:"settings_id"`
+	Strategy       AutoAssignStrategy `json:"strategy"`
+	CandidateCount int                `json:"candidate_count"`
+}
+
+// AutoAssignStats summarises the tickets auto-assigned to a user, for reporting on how evenly tickets are distributed
+type AutoAssignStats struct {
+	UserId         uint64    `json:"user_id,string"`
+	Assignments    int       `json:"assignments"`
+	LastAssignedAt time.Time `json:"last_assigned_at"`
+}
+
+// Ties are broken by round-robin order, so that candidates with equal load are still rotated through
+var autoAssignOrders = map[AutoAssignStrategy]string{
+	AutoAssignRoundRobin:      `candidates.user_id <= COALESCE(auto_assign_state.last_user_id, 0), candidates.user_id`,
+	AutoAssignLeastOpenClaims: `COALESCE(open_claims.count, 0) ASC, candidates.user_id <= COALESCE(auto_assign_state.last_user_id, 0), candidates.user_id`,
+	AutoAssignLongestIdle:     `last_assigned.assigned_at ASC NULLS FIRST, candidates.user_id <= COALESCE(auto_assign_state.last_user_id, 0), candidates.user_id`,
+}
+
+var (
+	//go:embed sql/auto_assign/schema.sql
+	autoAssignSchema string
+
+	//go:embed sql/auto_assign/get_by_guild.sql
+	autoAssignGetByGuild string
+
+	//go:embed sql/auto_assign/create.sql
+	autoAssignCreate string
+
+	//go:embed sql/auto_assign/update.sql
+	autoAssignUpdate string
+
+	//go:embed sql/auto_assign/delete.sql
+	autoAssignDelete string
+
+	//go:embed sql/auto_assign/resolve.sql
+	autoAssignResolve string
+
+	// Formatted with the rotation resolver query and the order from autoAssignOrders
+	//
+	//go:embed sql/auto_assign/pick.sql
+	autoAssignPick string
+
+	//go:embed sql/auto_assign/record.sql
+	autoAssignRecord string
+
+	//go:embed sql/auto_assign/get_stats.sql
+	autoAssignGetStats string
+)
+
+func newAutoAssign(db *pgxpool.Pool) *AutoAssign {
+	return &AutoAssign{
+		db,
+	}
+}
+
+func (AutoAssign) Schema() string {
+	return autoAssignSchema
+}
+
+func (a *AutoAssign) GetByGuild(ctx context.Context, guildId uint64) ([]AutoAssignSettings, error) {
+	rows, err := a.Query(ctx, autoAssignGetByGuild, guildId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var settings []AutoAssignSettings
+	for rows.Next() {
+		s := AutoAssignSettings{
+			GuildId: guildId,
+		}
+
+		if err := rows.Scan(&s.Id, &s.PanelId, &s.TeamId, &s.Strategy, &s.Enabled); err != nil {
+			return nil, err
+		}
+
+		settings = append(settings, s)
+	}
+
+	return settings, rows.Err()
+}
+
+// Create returns ErrPanelNotFound or ErrSupportTeamNotFound if the settings' panel or team does not belong to the guild.
+func (a *AutoAssign) Create(ctx context.Context, settings AutoAssignSettings) (int, error) {
+	var validPanel, validTeam bool
+	var id *int
+	if err := a.QueryRow(ctx, autoAssignCreate,
+		settings.GuildId,
+		settings.PanelId,
+		settings.TeamId,
+		settings.Strategy,
+		settings.Enabled,
+	).Scan(&validPanel, &validTeam, &id); err != nil {
+		return 0, err
+	}
+
+	if err := scopeError(validPanel, validTeam); err != nil {
+		return 0, err
+	}
+
+	return *id, nil
+}
+
+// Update returns ErrPanelNotFound or ErrSupportTeamNotFound if the settings' panel or team does not belong to the guild.
+func (a *AutoAssign) Update(ctx context.Context, settings AutoAssignSettings) error {
+	var validPanel, validTeam bool
+	if err := a.QueryRow(ctx, autoAssignUpdate,
+		settings.GuildId,
+		settings.Id,
+		settings.PanelId,
+		settings.TeamId,
+		settings.Strategy,
+		settings.Enabled,
+	).Scan(&validPanel, &validTeam); err != nil {
+		return err
+	}
+
+	return scopeError(validPanel, validTeam)
+}
+
+func (a *AutoAssign) Delete(ctx context.Context, guildId uint64, id int) (err error) {
+	_, err = a.Exec(ctx, autoAssignDelete, guildId, id)
+	return
+}
+
+// GetStats returns the number of tickets auto-assigned to each user since the given time, most assigned first
+func (a *AutoAssign) GetStats(ctx context.Context, guildId uint64, since time.Time) ([]AutoAssignStats, error) {
+	rows, err := a.Query(ctx, autoAssignGetStats, guildId, since)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var stats []AutoAssignStats
+	for rows.Next() {
+		var s AutoAssignStats
+		if err := rows.Scan(&s.UserId, &s.Assignments, &s.LastAssignedAt); err != nil {
+			return nil, err
+		}
+
+		stats = append(stats, s)
+	}
+
+	return stats, rows.Err()
+}
+
+// AutoAssignClaim picks a claimer for a ticket opened from the panel using the panel's auto-assign settings, and claims
+// the ticket for them in the same transaction. Returns nil if auto-assignment is not enabled for the panel, the ticket
+// is already claimed, or no candidates are on call with claim capacity to spare.
+func (d *Database) AutoAssignClaim(ctx context.Context, guildId uint64, ticketId, panelId int) (*AutoAssignment, error) {
+	var assignment *AutoAssignment
+	err := d.WithTx(ctx, func(tx pgx.Tx) (err error) {
+		assignment, err = d.AutoAssignClaimWithTx(ctx, tx, guildId, ticketId, panelId)
+		return
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return assignment, nil
+}
+
+func (d *Database) AutoAssignClaimWithTx(ctx context.Context, tx pgx.Tx, guildId uint64, ticketId, panelId int) (*AutoAssignment, error) {
+	var settings AutoAssignSettings
+	if err := tx.QueryRow(ctx, autoAssignResolve, guildId, panelId).Scan(
+		&settings.Id,
+		&settings.PanelId,
+		&settings.TeamId,
+		&settings.Strategy,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	order, ok := autoAssignOrders[settings.Strategy]
+	if !ok {
+		return nil, fmt.Errorf("invalid auto-assign strategy: %s", settings.Strategy)
+	}
+
+	assignment := AutoAssignment{
+		SettingsId: settings.Id,
+		Strategy:   settings.Strategy,
+	}
+
+	// Candidates at capacity are excluded by the pick, but another claim may have been made since. The claim is checked
+	// again while holding the member's lock, and the next candidate is picked if they are now at capacity.
+	query := fmt.Sprintf(autoAssignPick, onCallResolverQuery(), order)
+	excluded := make([]uint64, 0)
+	for {
+		if err := tx.QueryRow(ctx, query, guildId, time.Now(), ticketId, settings.PanelId, settings.TeamId, settings.Id, excluded).Scan(
+			&assignment.UserId,
+			&assignment.CandidateCount,
+		); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil, nil
+			}
+
+			return nil, err
+		}
+
+		err := d.TicketClaims.SetWithCapacityWithTx(ctx, tx, guildId, ticketId, assignment.UserId)
+		if err == nil {
+			break
+		}
+
+		if !errors.Is(err, ErrClaimCapacityReached) {
+			return nil, err
+		}
+
+		excluded = append(excluded, assignment.UserId)
+	}
+
+	if _, err := tx.Exec(ctx, autoAssignRecord,
+		settings.Id,
+		guildId,
+		ticketId,
+		assignment.UserId,
+		settings.Strategy,
+		assignment.CandidateCount,
+	); err != nil {
+		return nil, err
+	}
+
+	return &assignment, nil
+}
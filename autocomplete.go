@@ -0,0 +1,167 @@
+package database
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// AutocompleteIndex provides ranked fuzzy search over the names of a guild's entities, for slash command autocomplete.
+// It has no table of its own: its schema creates trigram indexes on the searched columns.
+type AutocompleteIndex struct {
+	*pgxpool.Pool
+}
+
+type AutocompleteEntity string
+
+const (
+	// AutocompleteEntityTag matches tag IDs and aliases, regardless of TagScopes
+	AutocompleteEntityTag         AutocompleteEntity = "tag"
+	AutocompleteEntityPanel       AutocompleteEntity = "panel"
+	AutocompleteEntitySupportTeam AutocompleteEntity = "support_team"
+	AutocompleteEntityForm        AutocompleteEntity = "form"
+)
+
+// AutocompleteResult is a single autocomplete choice. Value is the tag ID, or the panel, support team or form ID as a
+// string, and Name is the name that matched.
+type AutocompleteResult struct {
+	Value string  `json:"value"`
+	Name  string  `json:"name"`
+	Score float32 `json:"score"` // Word similarity between 0 and 1
+}
+
+// autocompleteSimilarityThreshold is lower than the pg_trgm default of 0.6, so that misspellings such as "refnd" still
+// match "refund-policy"
+const autocompleteSimilarityThreshold = "0.3"
+
+var (
+	//go:embed sql/autocomplete/schema.sql
+	autocompleteSchema string
+
+	//go:embed sql/autocomplete/set_threshold.sql
+	autocompleteSetThreshold string
+
+	// Formatted with the query for the candidates
+	//
+	//go:embed sql/autocomplete/search.sql
+	autocompleteSearch string
+
+	//go:embed sql/autocomplete/tags.sql
+	autocompleteTags string
+
+	//go:embed sql/autocomplete/visible_tags.sql
+	autocompleteVisibleTags string
+
+	//go:embed sql/autocomplete/panels.sql
+	autocompletePanels string
+
+	//go:embed sql/autocomplete/support_teams.sql
+	autocompleteSupportTeams string
+
+	//go:embed sql/autocomplete/forms.sql
+	autocompleteForms string
+)
+
+var autocompleteCandidates = map[AutocompleteEntity]string{
+	AutocompleteEntityTag:         autocompleteTags,
+	AutocompleteEntityPanel:       autocompletePanels,
+	AutocompleteEntitySupportTeam: autocompleteSupportTeams,
+	AutocompleteEntityForm:        autocompleteForms,
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func newAutocompleteIndex(db *pgxpool.Pool) *AutocompleteIndex {
+	return &AutocompleteIndex{
+		db,
+	}
+}
+
+func (AutocompleteIndex) Schema() string {
+	return autocompleteSchema
+}
+
+// Autocomplete returns up to limit of the guild's entities of the given type whose names match the input, best match
+// first. An empty input matches every entity.
+func (a *AutocompleteIndex) Autocomplete(
+	ctx context.Context,
+	guildId uint64,
+	entityType AutocompleteEntity,
+	input string,
+	limit int,
+) ([]AutocompleteResult, error) {
+	candidates, ok := autocompleteCandidates[entityType]
+	if !ok {
+		return nil, fmt.Errorf("invalid autocomplete entity type: %s", entityType)
+	}
+
+	return a.search(ctx, candidates, input, limit, guildId)
+}
+
+// AutocompleteTags is the same as Autocomplete for tags, but only returns tags that are visible to the viewer
+// according to TagScopes.
+func (a *AutocompleteIndex) AutocompleteTags(
+	ctx context.Context,
+	guildId uint64,
+	input string,
+	viewer TagViewer,
+	limit int,
+) ([]AutocompleteResult, error) {
+	roleIds := &pgtype.Int8Array{}
+	if err := roleIds.Set(viewer.RoleIds); err != nil {
+		return nil, err
+	}
+
+	return a.search(ctx, autocompleteVisibleTags, input, limit, guildId, viewer.PanelId, viewer.UserId, roleIds)
+}
+
+// search runs the candidates query, which takes the guild ID as $1, and any extra args from $5 onwards
+func (a *AutocompleteIndex) search(
+	ctx context.Context,
+	candidates string,
+	input string,
+	limit int,
+	guildId uint64,
+	extraArgs ...interface{},
+) ([]AutocompleteResult, error) {
+	tx, err := a.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, autocompleteSetThreshold, autocompleteSimilarityThreshold); err != nil {
+		return nil, err
+	}
+
+	input = strings.TrimSpace(input)
+	args := append([]interface{}{guildId, input, likeEscaper.Replace(input), limit}, extraArgs...)
+
+	rows, err := tx.Query(ctx, fmt.Sprintf(autocompleteSearch, candidates), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var results []AutocompleteResult
+	for rows.Next() {
+		var result AutocompleteResult
+		if err := rows.Scan(&result.Value, &result.Name, &result.Score); err != nil {
+			return nil, err
+		}
+
+		results = append(results, result)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, tx.Commit(ctx)
+}
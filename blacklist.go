@@ -17,11 +17,32 @@ func newBlacklist(db *pgxpool.Pool) *Blacklist {
 }
 
 func (b Blacklist) Schema() string {
-	return `CREATE TABLE IF NOT EXISTS blacklist("guild_id" int8 NOT NULL, "user_id" int8 NOT NULL, PRIMARY KEY("guild_id", "user_id"));`
+	return `
+CREATE TABLE IF NOT EXISTS blacklist(
+	"guild_id" int8 NOT NULL,
+	"user_id" int8 NOT NULL,
+	"reason" TEXT,
+	"created_by" int8,
+	"created_at" timestamptz NOT NULL DEFAULT NOW(),
+	"expires_at" timestamptz,
+	PRIMARY KEY("guild_id", "user_id")
+);
+ALTER TABLE blacklist ADD COLUMN IF NOT EXISTS "reason" TEXT;
+ALTER TABLE blacklist ADD COLUMN IF NOT EXISTS "created_by" int8;
+ALTER TABLE blacklist ADD COLUMN IF NOT EXISTS "created_at" timestamptz NOT NULL DEFAULT NOW();
+ALTER TABLE blacklist ADD COLUMN IF NOT EXISTS "expires_at" timestamptz;
+CREATE INDEX IF NOT EXISTS blacklist_expires_at ON blacklist("expires_at") WHERE "expires_at" IS NOT NULL;
+`
 }
 
+// IsBlacklisted returns false if the user's blacklist has expired, even if it has not been cleaned up yet.
 func (b *Blacklist) IsBlacklisted(ctx context.Context, guildId, userId uint64) (exists bool, e error) {
-	query := `SELECT EXISTS(SELECT 1 FROM blacklist WHERE "guild_id"=$1 AND "user_id"=$2);`
+	query := `
+SELECT EXISTS(
+	SELECT 1
+	FROM blacklist
+	WHERE "guild_id"=$1 AND "user_id"=$2 AND ("expires_at" IS NULL OR "expires_at" > NOW())
+);`
 	if err := b.QueryRow(ctx, query, guildId, userId).Scan(&exists); err != nil {
 		e = err
 	}
@@ -29,50 +50,130 @@ func (b *Blacklist) IsBlacklisted(ctx context.Context, guildId, userId uint64) (
 	return
 }
 
-func (b *Blacklist) GetBlacklistedUsers(ctx context.Context, guildId uint64, limit, offset int) (blacklisted []uint64, e error) {
+func (b *Blacklist) GetBlacklistedUsers(ctx context.Context, guildId uint64, limit, offset int) (blacklisted []BlacklistEntry, e error) {
 	query := `
-SELECT "user_id"
+SELECT "user_id", "reason", "created_by", "created_at", "expires_at"
 FROM blacklist
-WHERE "guild_id" = $1
+WHERE "guild_id" = $1 AND ("expires_at" IS NULL OR "expires_at" > NOW())
+ORDER BY "created_at" DESC, "user_id"
 LIMIT $2
 OFFSET $3;`
 
 	rows, err := b.Query(ctx, query, guildId, limit, offset)
-	defer rows.Close()
 	if err != nil {
 		e = err
 		return
 	}
 
+	defer rows.Close()
+
 	for rows.Next() {
-		var userId uint64
-		if err := rows.Scan(&userId); err != nil {
+		var entry BlacklistEntry
+		if err := rows.Scan(&entry.Id, &entry.Reason, &entry.CreatedBy, &entry.CreatedAt, &entry.ExpiresAt); err != nil {
 			e = err
 			continue
 		}
 
-		blacklisted = append(blacklisted, userId)
+		blacklisted = append(blacklisted, entry)
 	}
 
 	return
 }
 
 func (b *Blacklist) GetBlacklistedCount(ctx context.Context, guildId uint64) (count int, err error) {
-	query := `SELECT COUNT(*) FROM blacklist WHERE "guild_id" = $1;`
+	query := `SELECT COUNT(*) FROM blacklist WHERE "guild_id" = $1 AND ("expires_at" IS NULL OR "expires_at" > NOW());`
 
 	err = b.QueryRow(ctx, query, guildId).Scan(&count)
 	return
 }
 
+// Add permanently blacklists the user, without a reason. An existing blacklist is left unchanged, unless it has expired
+// and not yet been deleted by DeleteExpired, in which case it is replaced.
 func (b *Blacklist) Add(ctx context.Context, guildId, userId uint64) (err error) {
-	// on conflict, user is already blacklisted
-	query := `INSERT INTO blacklist("guild_id", "user_id") VALUES($1, $2) ON CONFLICT DO NOTHING;`
+	query := `
+WITH added AS (
+	INSERT INTO blacklist("guild_id", "user_id") VALUES($1, $2)
+	ON CONFLICT("guild_id", "user_id") DO UPDATE
+	SET "reason" = NULL,
+		"created_by" = NULL,
+		"created_at" = NOW(),
+		"expires_at" = NULL
+	WHERE blacklist."expires_at" <= NOW()
+	RETURNING "guild_id", "user_id"
+)
+INSERT INTO blacklist_history("type", "guild_id", "subject_id", "action")
+SELECT 'user', "guild_id", "user_id", 'added'
+FROM added;`
+
 	_, err = b.Exec(ctx, query, guildId, userId)
 	return
 }
 
+// AddWithMetadata blacklists the user, replacing any existing blacklist, and records it in the user's history.
+func (b *Blacklist) AddWithMetadata(ctx context.Context, guildId, userId uint64, metadata BlacklistMetadata) (err error) {
+	query := `
+WITH added AS (
+	INSERT INTO blacklist("guild_id", "user_id", "reason", "created_by", "created_at", "expires_at")
+	VALUES($1, $2, $3, $4, NOW(), $5)
+	ON CONFLICT("guild_id", "user_id") DO UPDATE
+	SET "reason" = EXCLUDED."reason",
+		"created_by" = EXCLUDED."created_by",
+		"created_at" = EXCLUDED."created_at",
+		"expires_at" = EXCLUDED."expires_at"
+	RETURNING "guild_id", "user_id", "reason", "created_by", "expires_at"
+)
+INSERT INTO blacklist_history("type", "guild_id", "subject_id", "action", "reason", "actor_id", "expires_at")
+SELECT 'user', "guild_id", "user_id", 'added', "reason", "created_by", "expires_at"
+FROM added;`
+
+	_, err = b.Exec(ctx, query, guildId, userId, metadata.Reason, metadata.CreatedBy, metadata.ExpiresAt)
+	return
+}
+
 func (b *Blacklist) Remove(ctx context.Context, guildId, userId uint64) (err error) {
-	query := `DELETE FROM blacklist WHERE "guild_id"=$1 AND "user_id"=$2;`
-	_, err = b.Exec(ctx, query, guildId, userId)
+	return b.remove(ctx, guildId, userId, nil)
+}
+
+// RemoveBy is the same as Remove, but records who removed the blacklist in the user's history.
+func (b *Blacklist) RemoveBy(ctx context.Context, guildId, userId, removedBy uint64) (err error) {
+	return b.remove(ctx, guildId, userId, &removedBy)
+}
+
+func (b *Blacklist) remove(ctx context.Context, guildId, userId uint64, removedBy *uint64) (err error) {
+	query := `
+WITH removed AS (
+	DELETE FROM blacklist WHERE "guild_id"=$1 AND "user_id"=$2
+	RETURNING "guild_id", "user_id", "expires_at"
+)
+INSERT INTO blacklist_history("type", "guild_id", "subject_id", "action", "actor_id", "expires_at")
+SELECT
+	'user',
+	"guild_id",
+	"user_id",
+	CASE WHEN "expires_at" <= NOW() THEN 'expired'::blacklist_action ELSE 'removed'::blacklist_action END,
+	CASE WHEN "expires_at" <= NOW() THEN NULL ELSE $3::int8 END,
+	"expires_at"
+FROM removed;`
+
+	_, err = b.Exec(ctx, query, guildId, userId, removedBy)
 	return
 }
+
+// DeleteExpired removes all expired blacklists, recording their expiry in history, and returns the number removed.
+func (b *Blacklist) DeleteExpired(ctx context.Context) (int64, error) {
+	query := `
+WITH removed AS (
+	DELETE FROM blacklist WHERE "expires_at" <= NOW()
+	RETURNING "guild_id", "user_id", "expires_at"
+)
+INSERT INTO blacklist_history("type", "guild_id", "subject_id", "action", "expires_at")
+SELECT 'user', "guild_id", "user_id", 'expired', "expires_at"
+FROM removed;`
+
+	res, err := b.Exec(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected(), nil
+}
@@ -0,0 +1,142 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// BlacklistHistory records each time a user, role or guild is added to or removed from a blacklist. It is written to by
+// Blacklist, RoleBlacklist, GlobalBlacklist, ServerBlacklist, PanelBlacklist and PanelRoleBlacklist, and should not need
+// to be written to directly.
+type BlacklistHistory struct {
+	*pgxpool.Pool
+}
+
+type BlacklistType string
+
+const (
+	BlacklistTypeUser      BlacklistType = "user"
+	BlacklistTypeRole      BlacklistType = "role"
+	BlacklistTypeGlobal    BlacklistType = "global"
+	BlacklistTypeServer    BlacklistType = "server"
+	BlacklistTypePanelUser BlacklistType = "panel_user"
+	BlacklistTypePanelRole BlacklistType = "panel_role"
+)
+
+type BlacklistAction string
+
+const (
+	BlacklistActionAdded   BlacklistAction = "added"
+	BlacklistActionRemoved BlacklistAction = "removed"
+	BlacklistActionExpired BlacklistAction = "expired"
+)
+
+// BlacklistMetadata is provided when blacklisting. A nil ExpiresAt blacklists permanently.
+type BlacklistMetadata struct {
+	Reason    *string    `json:"reason"`
+	CreatedBy *uint64    `json:"created_by,string"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type BlacklistEntry struct {
+	Id        uint64     `json:"id,string"` // User, role or guild ID
+	Reason    *string    `json:"reason"`
+	CreatedBy *uint64    `json:"created_by,string"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type BlacklistHistoryEntry struct {
+	Type      BlacklistType   `json:"type"`
+	GuildId   *uint64         `json:"guild_id,string"` // Nil for the global and server blacklists
+	PanelId   *int            `json:"panel_id"`        // Only set for the panel blacklists
+	SubjectId uint64          `json:"subject_id,string"`
+	Action    BlacklistAction `json:"action"`
+	Reason    *string         `json:"reason"`
+	ActorId   *uint64         `json:"actor_id,string"` // Who added or removed the entry, if known
+	ExpiresAt *time.Time      `json:"expires_at"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+func newBlacklistHistory(db *pgxpool.Pool) *BlacklistHistory {
+	return &BlacklistHistory{
+		db,
+	}
+}
+
+func (BlacklistHistory) Schema() string {
+	return `
+CREATE TYPE blacklist_type AS ENUM ('user', 'role', 'global', 'server', 'panel_user', 'panel_role');
+CREATE TYPE blacklist_action AS ENUM ('added', 'removed', 'expired');
+
+CREATE TABLE IF NOT EXISTS blacklist_history(
+	"id" BIGSERIAL NOT NULL,
+	"type" blacklist_type NOT NULL,
+	"guild_id" int8,
+	"panel_id" int4,
+	"subject_id" int8 NOT NULL,
+	"action" blacklist_action NOT NULL,
+	"reason" TEXT,
+	"actor_id" int8,
+	"expires_at" timestamptz,
+	"created_at" timestamptz NOT NULL DEFAULT NOW(),
+	PRIMARY KEY("id")
+);
+CREATE INDEX IF NOT EXISTS blacklist_history_subject ON blacklist_history("type", "guild_id", "subject_id");
+`
+}
+
+// GetByUser returns the guild and panel blacklist history of a user, most recent first.
+func (h *BlacklistHistory) GetByUser(ctx context.Context, guildId, userId uint64) ([]BlacklistHistoryEntry, error) {
+	query := `
+SELECT "type", "guild_id", "panel_id", "subject_id", "action", "reason", "actor_id", "expires_at", "created_at"
+FROM blacklist_history
+WHERE "type" IN ('user', 'panel_user') AND "guild_id" = $1 AND "subject_id" = $2
+ORDER BY "id" DESC;`
+
+	return h.query(ctx, query, guildId, userId)
+}
+
+// GetGlobalByUser returns the global blacklist history of a user, most recent first.
+func (h *BlacklistHistory) GetGlobalByUser(ctx context.Context, userId uint64) ([]BlacklistHistoryEntry, error) {
+	query := `
+SELECT "type", "guild_id", "panel_id", "subject_id", "action", "reason", "actor_id", "expires_at", "created_at"
+FROM blacklist_history
+WHERE "type" = 'global' AND "subject_id" = $1
+ORDER BY "id" DESC;`
+
+	return h.query(ctx, query, userId)
+}
+
+func (h *BlacklistHistory) query(ctx context.Context, query string, args ...interface{}) ([]BlacklistHistoryEntry, error) {
+	rows, err := h.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var entries []BlacklistHistoryEntry
+	for rows.Next() {
+		var entry BlacklistHistoryEntry
+		if err := rows.Scan(
+			&entry.Type,
+			&entry.GuildId,
+			&entry.PanelId,
+			&entry.SubjectId,
+			&entry.Action,
+			&entry.Reason,
+			&entry.ActorId,
+			&entry.ExpiresAt,
+			&entry.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
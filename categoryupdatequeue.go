@@ -5,10 +5,14 @@ import (
 	_ "embed"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/jadevelopmentgrp/Tickets-Utilities/model"
 )
 
+// CategoryUpdateQueue is a work queue of tickets that need moving to a different category after a status change.
+// Items are leased to a worker with Lease, and must then be acknowledged with Ack once processed, or released with
+// Nack on failure. Items that are not acknowledged before their lease expires become available to other workers.
 type CategoryUpdateQueue struct {
 	*pgxpool.Pool
 }
@@ -19,6 +23,26 @@ type CategoryUpdateQueueItem struct {
 	NewStatus model.TicketStatus
 	ChannelId *uint64
 	PanelId   *int
+	LeaseId   uuid.UUID
+	Attempts  int
+}
+
+type DeadCategoryUpdateQueueItem struct {
+	GuildId         uint64             `json:"guild_id,string"`
+	TicketId        int                `json:"ticket_id"`
+	NewStatus       model.TicketStatus `json:"new_status"`
+	StatusChangedAt time.Time          `json:"status_changed_at"`
+	Attempts        int                `json:"attempts"`
+	LastError       *string            `json:"last_error"`
+	DeadAt          time.Time          `json:"dead_at"`
+}
+
+type CategoryUpdateQueueStats struct {
+	Pending   int            `json:"pending"` // Not currently leased, including items waiting for their delay or a retry
+	Ready     int            `json:"ready"`   // Pending items that can be leased now
+	Leased    int            `json:"leased"`
+	Dead      int            `json:"dead"`
+	OldestAge *time.Duration `json:"oldest_age"` // Age of the oldest item that is not dead
 }
 
 var (
@@ -30,6 +54,27 @@ var (
 
 	//go:embed sql/category_update_queue/get_ready_for_update.sql
 	categoryUpdateQueueGetReadyForUpdate string
+
+	//go:embed sql/category_update_queue/lease.sql
+	categoryUpdateQueueLease string
+
+	//go:embed sql/category_update_queue/dead_letter_expired.sql
+	categoryUpdateQueueDeadLetterExpired string
+
+	//go:embed sql/category_update_queue/ack.sql
+	categoryUpdateQueueAck string
+
+	//go:embed sql/category_update_queue/nack.sql
+	categoryUpdateQueueNack string
+
+	//go:embed sql/category_update_queue/list_dead.sql
+	categoryUpdateQueueListDead string
+
+	//go:embed sql/category_update_queue/requeue_dead.sql
+	categoryUpdateQueueRequeueDead string
+
+	//go:embed sql/category_update_queue/get_stats.sql
+	categoryUpdateQueueGetStats string
 )
 
 func newCategoryUpdateQueueTable(db *pgxpool.Pool) *CategoryUpdateQueue {
@@ -42,17 +87,24 @@ func (CategoryUpdateQueue) Schema() string {
 	return categoryUpdateQueueSchema
 }
 
+// Add enqueues a ticket, replacing any existing item for the same ticket. If the existing item is currently leased,
+// the lease is invalidated so that the stale worker cannot acknowledge the new status.
 func (q *CategoryUpdateQueue) Add(ctx context.Context, guildId uint64, ticketId int, newStatus model.TicketStatus) error {
 	_, err := q.Exec(ctx, categoryUpdateQueueAdd, guildId, ticketId, newStatus)
 	return err
 }
 
+// Deprecated: GetReadyForUpdate deletes the items as they are returned, so they are lost if the consumer fails to
+// process them. Use Lease, Ack and Nack instead. Items that are currently leased, waiting for a retry or dead-lettered
+// are not returned, so that this can be used alongside Lease during a migration.
 func (q *CategoryUpdateQueue) GetReadyForUpdate(ctx context.Context, delayInterval time.Duration) ([]CategoryUpdateQueueItem, error) {
 	rows, err := q.Query(ctx, categoryUpdateQueueGetReadyForUpdate, delayInterval)
 	if err != nil {
 		return nil, err
 	}
 
+	defer rows.Close()
+
 	var items []CategoryUpdateQueueItem
 	for rows.Next() {
 		var item CategoryUpdateQueueItem
@@ -65,3 +117,121 @@ func (q *CategoryUpdateQueue) GetReadyForUpdate(ctx context.Context, delayInterv
 
 	return items, nil
 }
+
+// Lease claims up to limit items whose status changed more than delayInterval ago, hiding them from other workers for
+// the visibility timeout. Items which have already been attempted maxAttempts times are moved to the dead-letter state
+// instead of being leased again.
+func (q *CategoryUpdateQueue) Lease(
+	ctx context.Context,
+	delayInterval time.Duration,
+	limit int,
+	visibilityTimeout time.Duration,
+	maxAttempts int,
+) ([]CategoryUpdateQueueItem, error) {
+	if _, err := q.Exec(ctx, categoryUpdateQueueDeadLetterExpired, maxAttempts); err != nil {
+		return nil, err
+	}
+
+	rows, err := q.Query(ctx, categoryUpdateQueueLease, delayInterval, limit, visibilityTimeout, maxAttempts)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var items []CategoryUpdateQueueItem
+	for rows.Next() {
+		var item CategoryUpdateQueueItem
+		if err := rows.Scan(
+			&item.GuildId,
+			&item.TicketId,
+			&item.NewStatus,
+			&item.ChannelId,
+			&item.PanelId,
+			&item.LeaseId,
+			&item.Attempts,
+		); err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// Ack removes a processed item from the queue. Returns false if the lease had already expired and been taken by
+// another worker, or the ticket was re-queued with a new status in the meantime.
+func (q *CategoryUpdateQueue) Ack(ctx context.Context, item CategoryUpdateQueueItem) (bool, error) {
+	res, err := q.Exec(ctx, categoryUpdateQueueAck, item.GuildId, item.TicketId, item.LeaseId)
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() > 0, nil
+}
+
+// Nack releases the lease on an item after a failed attempt, making it available again after retryDelay. If the item
+// has reached maxAttempts, it is moved to the dead-letter state instead.
+func (q *CategoryUpdateQueue) Nack(
+	ctx context.Context,
+	item CategoryUpdateQueueItem,
+	retryDelay time.Duration,
+	maxAttempts int,
+	errorMessage string,
+) error {
+	_, err := q.Exec(ctx, categoryUpdateQueueNack, item.GuildId, item.TicketId, item.LeaseId, retryDelay, maxAttempts, errorMessage)
+	return err
+}
+
+func (q *CategoryUpdateQueue) ListDead(ctx context.Context, limit int) ([]DeadCategoryUpdateQueueItem, error) {
+	rows, err := q.Query(ctx, categoryUpdateQueueListDead, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var items []DeadCategoryUpdateQueueItem
+	for rows.Next() {
+		var item DeadCategoryUpdateQueueItem
+		if err := rows.Scan(
+			&item.GuildId,
+			&item.TicketId,
+			&item.NewStatus,
+			&item.StatusChangedAt,
+			&item.Attempts,
+			&item.LastError,
+			&item.DeadAt,
+		); err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// RequeueDead moves a dead-lettered item back into the queue with its attempt count reset.
+func (q *CategoryUpdateQueue) RequeueDead(ctx context.Context, guildId uint64, ticketId int) (bool, error) {
+	res, err := q.Exec(ctx, categoryUpdateQueueRequeueDead, guildId, ticketId)
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() > 0, nil
+}
+
+// GetStats returns queue depth and age metrics. delayInterval should match the value passed to Lease.
+func (q *CategoryUpdateQueue) GetStats(ctx context.Context, delayInterval time.Duration) (stats CategoryUpdateQueueStats, err error) {
+	err = q.QueryRow(ctx, categoryUpdateQueueGetStats, delayInterval).Scan(
+		&stats.Pending,
+		&stats.Leased,
+		&stats.Dead,
+		&stats.Ready,
+		&stats.OldestAge,
+	)
+
+	return
+}
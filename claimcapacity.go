@@ -0,0 +1,198 @@
+package database
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// ClaimCapacity caps the number of open tickets that a member can have claimed at once. Caps are only enforced by
+// TicketClaims.SetWithCapacity and by auto-assignment.
+type ClaimCapacity struct {
+	*pgxpool.Pool
+}
+
+// ClaimCapacityLimit applies to a single member if UserId is set, to each member of a support team if TeamId is set, or
+// to every member of the guild if neither are set. A team limit caps each member's own open claims, not the total across
+// the team.
+type ClaimCapacityLimit struct {
+	Id            int     `json:"id"`
+	GuildId       uint64  `json:"guild_id,string"`
+	TeamId        *int    `json:"team_id"`
+	UserId        *uint64 `json:"user_id,string"`
+	MaxOpenClaims int     `json:"max_open_claims"`
+}
+
+type StaffLoad struct {
+	UserId        uint64 `json:"user_id,string"`
+	TeamIds       []int  `json:"team_ids"`
+	OpenClaims    int    `json:"open_claims"`
+	MaxOpenClaims *int   `json:"max_open_claims"` // Nil if the member has no cap
+	OnCall        bool   `json:"on_call"`
+}
+
+var ErrClaimCapacityReached = errors.New("claim capacity reached")
+
+var (
+	//go:embed sql/claim_capacity/schema.sql
+	claimCapacitySchema string
+
+	//go:embed sql/claim_capacity/get_by_guild.sql
+	claimCapacityGetByGuild string
+
+	//go:embed sql/claim_capacity/create.sql
+	claimCapacityCreate string
+
+	//go:embed sql/claim_capacity/update.sql
+	claimCapacityUpdate string
+
+	//go:embed sql/claim_capacity/delete.sql
+	claimCapacityDelete string
+
+	//go:embed sql/claim_capacity/lock.sql
+	claimCapacityLock string
+
+	//go:embed sql/claim_capacity/check.sql
+	claimCapacityCheck string
+
+	// Formatted with the query for the users on call
+	//
+	//go:embed sql/claim_capacity/get_staff_load.sql
+	claimCapacityGetStaffLoad string
+)
+
+func newClaimCapacity(db *pgxpool.Pool) *ClaimCapacity {
+	return &ClaimCapacity{
+		db,
+	}
+}
+
+func (ClaimCapacity) Schema() string {
+	return claimCapacitySchema
+}
+
+func (c *ClaimCapacity) GetByGuild(ctx context.Context, guildId uint64) ([]ClaimCapacityLimit, error) {
+	rows, err := c.Query(ctx, claimCapacityGetByGuild, guildId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var limits []ClaimCapacityLimit
+	for rows.Next() {
+		limit := ClaimCapacityLimit{
+			GuildId: guildId,
+		}
+
+		if err := rows.Scan(&limit.Id, &limit.TeamId, &limit.UserId, &limit.MaxOpenClaims); err != nil {
+			return nil, err
+		}
+
+		limits = append(limits, limit)
+	}
+
+	return limits, rows.Err()
+}
+
+// Create returns ErrSupportTeamNotFound if the limit's team does not belong to the guild.
+func (c *ClaimCapacity) Create(ctx context.Context, limit ClaimCapacityLimit) (id int, err error) {
+	if err := c.QueryRow(ctx, claimCapacityCreate, limit.GuildId, limit.TeamId, limit.UserId, limit.MaxOpenClaims).Scan(&id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrSupportTeamNotFound
+		}
+
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// Update returns ErrSupportTeamNotFound if the limit's team does not belong to the guild.
+func (c *ClaimCapacity) Update(ctx context.Context, limit ClaimCapacityLimit) error {
+	var validTeam bool
+	if err := c.QueryRow(ctx, claimCapacityUpdate, limit.GuildId, limit.Id, limit.TeamId, limit.UserId, limit.MaxOpenClaims).Scan(&validTeam); err != nil {
+		return err
+	}
+
+	if !validTeam {
+		return ErrSupportTeamNotFound
+	}
+
+	return nil
+}
+
+func (c *ClaimCapacity) Delete(ctx context.Context, guildId uint64, id int) (err error) {
+	_, err = c.Exec(ctx, claimCapacityDelete, guildId, id)
+	return
+}
+
+// GetStaffLoad returns the number of open tickets claimed by each support team member, support or admin user, or other
+// user with open claims, most loaded first.
+func (c *ClaimCapacity) GetStaffLoad(ctx context.Context, guildId uint64) ([]StaffLoad, error) {
+	onCallQuery := strings.TrimSuffix(strings.TrimSpace(fmt.Sprintf(onCallGetUsersOnCall, onCallResolverQuery())), ";")
+	query := fmt.Sprintf(claimCapacityGetStaffLoad, onCallQuery)
+
+	// Members are on call if any of the guild's rotations schedule them, regardless of team
+	rows, err := c.Query(ctx, query, guildId, time.Now(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var load []StaffLoad
+	for rows.Next() {
+		var l StaffLoad
+		if err := rows.Scan(&l.UserId, &l.TeamIds, &l.OpenClaims, &l.MaxOpenClaims, &l.OnCall); err != nil {
+			return nil, err
+		}
+
+		load = append(load, l)
+	}
+
+	return load, rows.Err()
+}
+
+// SetWithCapacity is the same as Set, but returns ErrClaimCapacityReached instead if the user already has as many open
+// tickets claimed as their cap allows.
+func (c *TicketClaims) SetWithCapacity(ctx context.Context, guildId uint64, ticketId int, userId uint64) error {
+	tx, err := c.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	if err := c.SetWithCapacityWithTx(ctx, tx, guildId, ticketId, userId); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// SetWithCapacityWithTx holds a lock on the user's claims until the transaction ends, so that concurrent claims cannot
+// exceed the cap.
+func (c *TicketClaims) SetWithCapacityWithTx(ctx context.Context, tx pgx.Tx, guildId uint64, ticketId int, userId uint64) error {
+	if _, err := tx.Exec(ctx, claimCapacityLock, guildId, userId); err != nil {
+		return err
+	}
+
+	var maxOpenClaims *int
+	var openClaims int
+	if err := tx.QueryRow(ctx, claimCapacityCheck, guildId, userId, ticketId).Scan(&maxOpenClaims, &openClaims); err != nil {
+		return err
+	}
+
+	if maxOpenClaims != nil && openClaims >= *maxOpenClaims {
+		return ErrClaimCapacityReached
+	}
+
+	return c.SetWithTx(ctx, tx, guildId, ticketId, userId)
+}
@@ -9,8 +9,9 @@ import (
 )
 
 type CloseMetadata struct {
-	Reason   *string `json:"reason"`
-	ClosedBy *uint64 `json:"closed_by,string"` // Null if auto-closed
+	Reason     *string `json:"reason"`
+	ClosedBy   *uint64 `json:"closed_by,string"` // Null if auto-closed
+	AutoClosed bool    `json:"auto_closed"`
 }
 
 type CloseMetadataTable struct {
@@ -30,21 +31,25 @@ CREATE TABLE IF NOT EXISTS close_reason(
 	"ticket_id" int4 NOT NULL,
 	"close_reason" TEXT,
 	"closed_by" int8,
+	"auto_closed" bool NOT NULL DEFAULT false,
 	FOREIGN KEY("guild_id", "ticket_id") REFERENCES tickets("guild_id", "id"),
 	PRIMARY KEY("guild_id", "ticket_id")
 );
+ALTER TABLE close_reason ADD COLUMN IF NOT EXISTS "auto_closed" bool NOT NULL DEFAULT false;
+-- Tickets closed before auto_closed was recorded have no closer if they were auto-closed
+UPDATE close_reason SET "auto_closed" = true WHERE "closed_by" IS NULL AND NOT "auto_closed";
 `
 }
 
 func (c *CloseMetadataTable) Get(ctx context.Context, guildId uint64, ticketId int) (CloseMetadata, bool, error) {
 	query := `
-SELECT "close_reason", "closed_by"
+SELECT "close_reason", "closed_by", "auto_closed"
 FROM close_reason
 WHERE "guild_id" = $1 AND "ticket_id" = $2;
 `
 
 	var data CloseMetadata
-	if err := c.QueryRow(ctx, query, guildId, ticketId).Scan(&data.Reason, &data.ClosedBy); err != nil {
+	if err := c.QueryRow(ctx, query, guildId, ticketId).Scan(&data.Reason, &data.ClosedBy, &data.AutoClosed); err != nil {
 		if err == pgx.ErrNoRows {
 			return CloseMetadata{}, false, nil
 		} else {
@@ -57,7 +62,7 @@ WHERE "guild_id" = $1 AND "ticket_id" = $2;
 
 func (c *CloseMetadataTable) GetMulti(ctx context.Context, guildId uint64, ticketIds []int) (map[int]CloseMetadata, error) {
 	query := `
-SELECT "ticket_id", "close_reason", "closed_by"
+SELECT "ticket_id", "close_reason", "closed_by", "auto_closed"
 FROM close_reason
 WHERE "guild_id" = $1 AND "ticket_id" = ANY($2);
 `
@@ -76,7 +81,7 @@ WHERE "guild_id" = $1 AND "ticket_id" = ANY($2);
 	for rows.Next() {
 		var ticketId int
 		var data CloseMetadata
-		if err := rows.Scan(&ticketId, &data.Reason, &data.ClosedBy); err != nil {
+		if err := rows.Scan(&ticketId, &data.Reason, &data.ClosedBy, &data.AutoClosed); err != nil {
 			return nil, err
 		}
 
@@ -88,12 +93,12 @@ WHERE "guild_id" = $1 AND "ticket_id" = ANY($2);
 
 func (c *CloseMetadataTable) Set(ctx context.Context, guildId uint64, ticketId int, data CloseMetadata) (err error) {
 	query := `
-INSERT INTO close_reason("guild_id", "ticket_id", "close_reason", "closed_by")
-VALUES($1, $2, $3, $4)
-ON CONFLICT("guild_id", "ticket_id") DO UPDATE SET "close_reason" = $3, "closed_by" = $4;
+INSERT INTO close_reason("guild_id", "ticket_id", "close_reason", "closed_by", "auto_closed")
+VALUES($1, $2, $3, $4, $5)
+ON CONFLICT("guild_id", "ticket_id") DO UPDATE SET "close_reason" = $3, "closed_by" = $4, "auto_closed" = $5;
 `
 
-	_, err = c.Exec(ctx, query, guildId, ticketId, data.Reason, data.ClosedBy)
+	_, err = c.Exec(ctx, query, guildId, ticketId, data.Reason, data.ClosedBy, data.AutoClosed)
 	return
 }
 
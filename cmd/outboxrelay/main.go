@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+	database "github.com/jadevelopmentgrp/Tickets-Database"
+	"github.com/jadevelopmentgrp/Tickets-Database/outbox"
+	"github.com/sirupsen/logrus"
+)
+
+func main() {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	logrus.Info("Connecting to database...")
+	pool := must(pgxpool.Connect(ctx, os.Getenv("DATABASE_URI")))
+	db := database.NewDatabase(pool)
+	logrus.Info("Connected!")
+
+	config := outbox.DefaultConfig()
+	if v := os.Getenv("BATCH_SIZE"); v != "" {
+		config.BatchSize = must(strconv.Atoi(v))
+	}
+
+	if v := os.Getenv("MAX_ATTEMPTS"); v != "" {
+		config.MaxAttempts = must(strconv.Atoi(v))
+	}
+
+	purgeAfter := time.Hour * 24 * 7
+	if v := os.Getenv("PURGE_AFTER"); v != "" {
+		purgeAfter = must(time.ParseDuration(v))
+	}
+
+	relay := outbox.NewRelay(db.Outbox, config, buildSink())
+
+	go purgeLoop(ctx, db, purgeAfter)
+
+	logrus.Info("Starting relay...")
+	if err := relay.Run(ctx); err != nil {
+		logrus.Errorf("Relay stopped: %s", err.Error())
+	}
+
+	logrus.Info("Relay stopped")
+}
+
+func buildSink() outbox.Sink {
+	switch os.Getenv("SINK") {
+	case "http":
+		headers := make(map[string]string)
+		if v := os.Getenv("WEBHOOK_AUTHORIZATION"); v != "" {
+			headers["Authorization"] = v
+		}
+
+		return outbox.NewHttpSink(os.Getenv("WEBHOOK_URL"), headers, time.Second*15)
+	case "file":
+		return must(outbox.NewFileSink(os.Getenv("FILE_PATH")))
+	case "stdout", "":
+		return outbox.NewStdoutSink()
+	default:
+		logrus.Fatalf("Unknown sink type: %s", os.Getenv("SINK"))
+		return nil
+	}
+}
+
+func purgeLoop(ctx context.Context, db *database.Database, purgeAfter time.Duration) {
+	for {
+		purged, err := db.Outbox.PurgeDelivered(ctx, purgeAfter)
+		if err != nil {
+			logrus.Errorf("Error purging delivered outbox events: %s", err.Error())
+		} else if purged > 0 {
+			logrus.Infof("Purged %d delivered outbox events", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Hour):
+		}
+	}
+}
+
+func must[T any](v T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+
+	return v
+}
@@ -92,3 +92,18 @@ DO UPDATE SET "colour_code" = $3;`
 	_, err = res.Exec()
 	return
 }
+
+func (c *CustomColours) BatchSetWithTx(ctx context.Context, tx pgx.Tx, guildId uint64, colours map[int16]int) (err error) {
+	query := `
+INSERT INTO custom_colours("guild_id", "colour_id", "colour_code")
+VALUES($1, $2, $3) ON CONFLICT("guild_id", "colour_id")
+DO UPDATE SET "colour_code" = $3;`
+
+	for colourId, colourCode := range colours {
+		if _, err := tx.Exec(ctx, query, guildId, colourId, colourCode); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -15,12 +15,16 @@ type Database struct {
 	ActiveLanguage                 *ActiveLanguage
 	ArchiveChannel                 *ArchiveChannel
 	ArchiveMessages                *ArchiveMessages
+	AutoAssign                     *AutoAssign
 	AutoClose                      *AutoCloseTable
 	AutoCloseExclude               *AutoCloseExclude
+	AutocompleteIndex              *AutocompleteIndex
 	Blacklist                      *Blacklist
+	BlacklistHistory               *BlacklistHistory
 	BotStaff                       *BotStaff
 	CategoryUpdateQueue            *CategoryUpdateQueue
 	ChannelCategory                *ChannelCategory
+	ClaimCapacity                  *ClaimCapacity
 	ClaimSettings                  *ClaimSettingsTable
 	CloseConfirmation              *CloseConfirmation
 	CloseReason                    *CloseMetadataTable
@@ -39,6 +43,7 @@ type Database struct {
 	EmbedFields                    *EmbedFieldsTable
 	Embeds                         *EmbedsTable
 	Entitlements                   *Entitlements
+	EscalationPolicies             *EscalationPolicies
 	ExitSurveyResponses            *ExitSurveyResponses
 	FeedbackEnabled                *FeedbackEnabled
 	FirstResponseTime              *FirstResponseTime
@@ -47,8 +52,10 @@ type Database struct {
 	GlobalBlacklist                *GlobalBlacklist
 	GuildLeaveTime                 *GuildLeaveTime
 	GuildMetadata                  *GuildMetadataTable
+	ImportCheckpoints              *ImportCheckpointsTable
 	ImportLogs                     *ImportLogsTable
 	ImportMappingTable             *ImportMappingTable
+	IntegrationExecutions          *IntegrationExecutions
 	LegacyPremiumEntitlementGuilds *LegacyPremiumEntitlementGuilds
 	LegacyPremiumEntitlements      *LegacyPremiumEntitlements
 	MultiPanels                    *MultiPanelTable
@@ -56,8 +63,12 @@ type Database struct {
 	MultiServerSkus                *MultiServerSkus
 	NamingScheme                   *TicketNamingScheme
 	OnCall                         *OnCall
+	OnCallRotations                *OnCallRotations
+	Outbox                         *OutboxTable
 	Panel                          *PanelTable
 	PanelAccessControlRules        *PanelAccessControlRules
+	PanelBlacklist                 *PanelBlacklist
+	PanelRoleBlacklist             *PanelRoleBlacklist
 	PanelRoleMentions              *PanelRoleMentions
 	PanelTeams                     *PanelTeamsTable
 	PanelUserMention               *PanelUserMention
@@ -68,20 +79,32 @@ type Database struct {
 	PremiumKeys                    *PremiumKeys
 	RoleBlacklist                  *RoleBlacklist
 	RolePermissions                *RolePermissions
+	ScheduledJobs                  *ScheduledJobs
 	ServerBlacklist                *ServerBlacklist
 	ServiceRatings                 *ServiceRatings
 	Settings                       *SettingsTable
+	StaffLeaderboard               *StaffLeaderboard
 	StaffOverride                  *StaffOverride
 	SubscriptionSkus               *SubscriptionSkus
 	SupportTeam                    *SupportTeamTable
 	SupportTeamMembers             *SupportTeamMembersTable
 	SupportTeamRoles               *SupportTeamRolesTable
 	Tag                            *TagsTable
+	TagAliases                     *TagAliases
+	TagCategories                  *TagCategories
+	TagHistory                     *TagHistory
+	TagScopes                      *TagScopes
+	TagUsages                      *TagUsages
+	TemplatePlaceholderRefs        *TemplatePlaceholderRefs
 	TicketClaims                   *TicketClaims
 	TicketLastMessage              *TicketLastMessageTable
 	TicketLimit                    *TicketLimit
 	TicketMembers                  *TicketMembers
+	TicketOpenLimits               *TicketOpenLimits
 	TicketPermissions              *TicketPermissionsTable
+	TicketPriority                 *TicketPriority
+	TicketStatusHistory            *TicketStatusHistory
+	TicketSubscriptions            *TicketSubscriptions
 	Tickets                        *TicketTable
 	UsedKeys                       *UsedKeys
 	UsersCanClose                  *UsersCanClose
@@ -103,12 +126,16 @@ func NewDatabase(pool *pgxpool.Pool) *Database {
 		ActiveLanguage:                 newActiveLanguage(pool),
 		ArchiveChannel:                 newArchiveChannel(pool),
 		ArchiveMessages:                newArchiveMessages(pool),
+		AutoAssign:                     newAutoAssign(pool),
 		AutoClose:                      newAutoCloseTable(pool),
 		AutoCloseExclude:               newAutoCloseExclude(pool),
+		AutocompleteIndex:              newAutocompleteIndex(pool),
 		Blacklist:                      newBlacklist(pool),
+		BlacklistHistory:               newBlacklistHistory(pool),
 		BotStaff:                       newBotStaff(pool),
 		CategoryUpdateQueue:            newCategoryUpdateQueueTable(pool),
 		ChannelCategory:                newChannelCategory(pool),
+		ClaimCapacity:                  newClaimCapacity(pool),
 		ClaimSettings:                  newClaimSettingsTable(pool),
 		CloseConfirmation:              newCloseConfirmation(pool),
 		CloseReason:                    newCloseReasonTable(pool),
@@ -127,6 +154,7 @@ func NewDatabase(pool *pgxpool.Pool) *Database {
 		EmbedFields:                    newEmbedFieldsTable(pool),
 		Embeds:                         newEmbedsTable(pool),
 		Entitlements:                   newEntitlementsTable(pool),
+		EscalationPolicies:             newEscalationPolicies(pool),
 		ExitSurveyResponses:            newExitSurveyResponses(pool),
 		FeedbackEnabled:                newFeedbackEnabled(pool),
 		FirstResponseTime:              newFirstResponseTime(pool),
@@ -135,8 +163,10 @@ func NewDatabase(pool *pgxpool.Pool) *Database {
 		GlobalBlacklist:                newGlobalBlacklist(pool),
 		GuildLeaveTime:                 newGuildLeaveTime(pool),
 		GuildMetadata:                  newGuildMetadataTable(pool),
+		ImportCheckpoints:              newImportCheckpoints(pool),
 		ImportLogs:                     newImportLogs(pool),
 		ImportMappingTable:             newImportMapping(pool),
+		IntegrationExecutions:          newIntegrationExecutions(pool),
 		LegacyPremiumEntitlementGuilds: newLegacyPremiumEntitlementGuildsTable(pool),
 		LegacyPremiumEntitlements:      newLegacyPremiumEntitlement(pool),
 		MultiPanels:                    newMultiMultiPanelTable(pool),
@@ -144,8 +174,12 @@ func NewDatabase(pool *pgxpool.Pool) *Database {
 		MultiServerSkus:                newMultiServerSkusTable(pool),
 		NamingScheme:                   newTicketNamingScheme(pool),
 		OnCall:                         newOnCall(pool),
+		OnCallRotations:                newOnCallRotations(pool),
+		Outbox:                         newOutboxTable(pool),
 		Panel:                          newPanelTable(pool),
 		PanelAccessControlRules:        newPanelAccessControlRules(pool),
+		PanelBlacklist:                 newPanelBlacklist(pool),
+		PanelRoleBlacklist:             newPanelRoleBlacklist(pool),
 		PanelRoleMentions:              newPanelRoleMentions(pool),
 		PanelTeams:                     newPanelTeamsTable(pool),
 		PanelUserMention:               newPanelUserMention(pool),
@@ -156,20 +190,32 @@ func NewDatabase(pool *pgxpool.Pool) *Database {
 		PremiumKeys:                    newPremiumKeys(pool),
 		RoleBlacklist:                  newRoleBlacklist(pool),
 		RolePermissions:                newRolePermissions(pool),
+		ScheduledJobs:                  newScheduledJobs(pool),
 		ServerBlacklist:                newServerBlacklist(pool),
 		ServiceRatings:                 newServiceRatings(pool),
 		Settings:                       newSettingsTable(pool),
+		StaffLeaderboard:               newStaffLeaderboard(pool),
 		StaffOverride:                  newStaffOverride(pool),
 		SubscriptionSkus:               newSubscriptionSkusTable(pool),
 		SupportTeam:                    newSupportTeamTable(pool),
 		SupportTeamMembers:             newSupportTeamMembersTable(pool),
 		SupportTeamRoles:               newSupportTeamRolesTable(pool),
 		Tag:                            newTag(pool),
+		TagAliases:                     newTagAliases(pool),
+		TagCategories:                  newTagCategories(pool),
+		TagHistory:                     newTagHistory(pool),
+		TagScopes:                      newTagScopes(pool),
+		TagUsages:                      newTagUsages(pool),
+		TemplatePlaceholderRefs:        newTemplatePlaceholderRefs(pool),
 		TicketClaims:                   newTicketClaims(pool),
 		TicketLastMessage:              newTicketLastMessageTable(pool),
 		TicketLimit:                    newTicketLimit(pool),
 		TicketMembers:                  newTicketMembers(pool),
+		TicketOpenLimits:               newTicketOpenLimits(pool),
 		TicketPermissions:              newTicketPermissionsTable(pool),
+		TicketPriority:                 newTicketPriority(pool),
+		TicketStatusHistory:            newTicketStatusHistory(pool),
+		TicketSubscriptions:            newTicketSubscriptions(pool),
 		Tickets:                        newTicketTable(pool),
 		UsedKeys:                       newUsedKeys(pool),
 		UsersCanClose:                  newUsersCanClose(pool),
@@ -218,6 +264,7 @@ func (d *Database) CreateTables(ctx context.Context, pool *pgxpool.Pool) {
 		d.ArchiveChannel,
 		d.AutoClose,
 		d.Blacklist,
+		d.BlacklistHistory,
 		d.BotStaff,
 		d.ChannelCategory,
 		d.ClaimSettings,
@@ -229,6 +276,7 @@ func (d *Database) CreateTables(ctx context.Context, pool *pgxpool.Pool) {
 		d.CustomIntegrationPlaceholders,
 		d.CustomIntegrationSecrets,
 		d.CustomIntegrationSecretValues,
+		d.IntegrationExecutions, // Must be created after custom integrations table
 		d.CustomColours,
 		d.DashboardUsers,
 		d.Embeds,
@@ -243,6 +291,7 @@ func (d *Database) CreateTables(ctx context.Context, pool *pgxpool.Pool) {
 		d.GlobalBlacklist,
 		d.GuildLeaveTime,
 		d.GuildMetadata,
+		d.ImportCheckpoints,
 		d.ImportLogs,
 		d.ImportMappingTable,
 		d.LegacyPremiumEntitlements,
@@ -251,9 +300,12 @@ func (d *Database) CreateTables(ctx context.Context, pool *pgxpool.Pool) {
 		d.MultiServerSkus,
 		d.NamingScheme,
 		d.OnCall,
+		d.Outbox,
 		d.Panel,
 		d.PanelAccessControlRules, // must be created after panels table
 		d.MultiPanelTargets,       // must be created after panels table
+		d.PanelBlacklist,          // must be created after panels table
+		d.PanelRoleBlacklist,      // must be created after panels table
 		d.PanelRoleMentions,
 		d.PanelUserMention,
 		d.PatreonEntitlements,
@@ -268,8 +320,16 @@ func (d *Database) CreateTables(ctx context.Context, pool *pgxpool.Pool) {
 		d.SupportTeam,
 		d.SupportTeamMembers,
 		d.SupportTeamRoles,
-		d.PanelTeams, // Must be created after panels & support teams tables
+		d.PanelTeams,      // Must be created after panels & support teams tables
+		d.OnCallRotations, // Must be created after support teams table
+		d.ClaimCapacity,   // Must be created after support teams table
 		d.Tag,
+		d.TagHistory,
+		d.TagUsages,
+		d.TagAliases,        // Must be created after tags table
+		d.TagCategories,     // Must be created after tags table
+		d.TagScopes,         // Must be created after tags, panels & support teams tables
+		d.AutocompleteIndex, // Must be created after tags, tag aliases, panels, support teams & forms tables
 		d.TicketLimit,
 		d.TicketPermissions,
 		d.Tickets,             // Must be created before members table
@@ -282,9 +342,16 @@ func (d *Database) CreateTables(ctx context.Context, pool *pgxpool.Pool) {
 		d.ExitSurveyResponses, // Must be created after Tickets table
 		d.ArchiveMessages,     // Must be created after Tickets table
 		d.CategoryUpdateQueue, // Must be created after Tickets table
+		d.ScheduledJobs,       // Must be created after Tickets table
+		d.TicketStatusHistory, // Must be created after Tickets table
+		d.TicketOpenLimits,    // Must be created after Tickets, panels & support teams tables
+		d.TicketPriority,      // Must be created after Tickets table
+		d.EscalationPolicies,  // Must be created after Tickets, panels & support teams tables
+		d.TicketSubscriptions, // Must be created after Tickets, panels & support teams tables
 		d.FirstResponseTime,
 		d.TicketMembers,
 		d.TicketClaims,
+		d.AutoAssign, // Must be created after Tickets, panels & support teams tables
 		d.UsedKeys,
 		d.UsersCanClose,
 		d.UserGuilds,
@@ -292,6 +359,7 @@ func (d *Database) CreateTables(ctx context.Context, pool *pgxpool.Pool) {
 		d.Votes,
 		d.Webhooks,
 		d.WelcomeMessages,
+		d.TemplatePlaceholderRefs, // Must be created after tags, panels, embeds & welcome messages tables
 		d.Whitelabel,
 		d.WhitelabelErrors,
 		d.WhitelabelGuilds,
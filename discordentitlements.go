@@ -39,8 +39,11 @@ func (DiscordEntitlements) Schema() string {
 }
 
 func (e *DiscordEntitlements) Create(ctx context.Context, tx pgx.Tx, discordId uint64, entitlementId uuid.UUID) error {
-	_, err := tx.Exec(ctx, discordEntitlementsCreate, discordId, entitlementId)
-	return err
+	if _, err := tx.Exec(ctx, discordEntitlementsCreate, discordId, entitlementId); err != nil {
+		return err
+	}
+
+	return publishEntitlementEventById(ctx, tx, OutboxEventEntitlementUpdated, entitlementId)
 }
 
 func (e *DiscordEntitlements) GetEntitlementId(ctx context.Context, tx pgx.Tx, discordId uint64) (*uuid.UUID, error) {
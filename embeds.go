@@ -2,6 +2,7 @@ package database
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/jackc/pgx/v4"
@@ -105,44 +106,7 @@ WHERE "id" = $1;
 }
 
 func (s *EmbedsTable) Create(ctx context.Context, embed *CustomEmbed) (id int, err error) {
-	query := `
-INSERT INTO embeds(
-	"guild_id",
-	"title",
-	"description",
-	"url",
-	"colour",
-	"author_name",
-	"author_icon_url",
-	"author_url",
-	"image_url",
-	"thumbnail_url",
-	"footer_text", 
-	"footer_icon_url",
-	"timestamp"
-)
-VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
-RETURNING "id";
-`
-
-	err = s.QueryRow(
-		ctx,
-		query,
-		embed.GuildId,
-		embed.Title,
-		embed.Description,
-		embed.Url,
-		embed.Colour,
-		embed.AuthorName,
-		embed.AuthorIconUrl,
-		embed.AuthorUrl,
-		embed.ImageUrl,
-		embed.ThumbnailUrl,
-		embed.FooterText,
-		embed.FooterIconUrl,
-		embed.Timestamp,
-	).Scan(&id)
-	return
+	return s.CreateWithFields(ctx, embed, nil)
 }
 
 func (s *EmbedsTable) CreateWithFields(ctx context.Context, embed *CustomEmbed, fields []EmbedField) (int, error) {
@@ -226,47 +190,55 @@ VALUES($1, $2, $3, $4);
 		}
 	}
 
+	templates := EmbedTemplates(&CustomEmbedWithFields{CustomEmbed: embed, Fields: fields})
+	if err := recordTemplatePlaceholders(ctx, tx, embed.GuildId, EmbedTemplateSource(embedId), templates...); err != nil {
+		return 0, err
+	}
+
 	return embedId, nil
 }
 
+// Update leaves the embed's fields unchanged. Use UpdateWithFields to replace them as well.
 func (s *EmbedsTable) Update(ctx context.Context, embed *CustomEmbed) error {
-	query := `
-UPDATE embeds
-SET
-	"title" = $2,
-	"description" = $3,
-	"url" = $4,
-	"colour" = $5,
-	"author_name" = $6,
-	"author_icon_url" = $7,
-	"author_url" = $8,
-	"image_url" = $9,
-	"thumbnail_url" = $10,
-	"footer_text" = $11, 
-	"footer_icon_url" = $12,
-	"timestamp" = $13
-WHERE "id" = $1;
-`
+	tx, err := s.Begin(ctx)
+	if err != nil {
+		return err
+	}
 
-	_, err := s.Exec(
-		ctx,
-		query,
-		embed.Id,
-		embed.Title,
-		embed.Description,
-		embed.Url,
-		embed.Colour,
-		embed.AuthorName,
-		embed.AuthorIconUrl,
-		embed.AuthorUrl,
-		embed.ImageUrl,
-		embed.ThumbnailUrl,
-		embed.FooterText,
-		embed.FooterIconUrl,
-		embed.Timestamp,
-	)
+	defer tx.Rollback(ctx)
 
-	return err
+	guildId, ok, err := updateEmbed(ctx, tx, embed)
+	if err != nil || !ok {
+		return err
+	}
+
+	rows, err := tx.Query(ctx, `SELECT "name", "value" FROM embed_fields WHERE "embed_id" = $1;`, embed.Id)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	var fields []EmbedField
+	for rows.Next() {
+		var field EmbedField
+		if err := rows.Scan(&field.Name, &field.Value); err != nil {
+			return err
+		}
+
+		fields = append(fields, field)
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	templates := EmbedTemplates(&CustomEmbedWithFields{CustomEmbed: embed, Fields: fields})
+	if err := recordTemplatePlaceholders(ctx, tx, guildId, EmbedTemplateSource(embed.Id), templates...); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
 }
 
 func (s *EmbedsTable) UpdateWithFields(ctx context.Context, embed *CustomEmbed, fields []EmbedField) error {
@@ -285,6 +257,43 @@ func (s *EmbedsTable) UpdateWithFields(ctx context.Context, embed *CustomEmbed,
 }
 
 func (s *EmbedsTable) UpdateWithFieldsTx(ctx context.Context, tx pgx.Tx, embed *CustomEmbed, fields []EmbedField) error {
+	// Update actual embed
+	guildId, ok, err := updateEmbed(ctx, tx, embed)
+	if err != nil || !ok {
+		return err
+	}
+
+	// Delete and recreate fields
+	if _, err := tx.Exec(ctx, `DELETE FROM embed_fields WHERE embed_id = $1;`, embed.Id); err != nil {
+		return err
+	}
+
+	for _, field := range fields {
+		query := `
+INSERT INTO embed_fields(
+	"embed_id",
+	"name",
+	"value",
+	"inline"
+)
+VALUES($1, $2, $3, $4);
+`
+		_, err = tx.Exec(ctx, query, embed.Id, field.Name, field.Value, field.Inline)
+		if err != nil {
+			return err
+		}
+	}
+
+	templates := EmbedTemplates(&CustomEmbedWithFields{CustomEmbed: embed, Fields: fields})
+	if err := recordTemplatePlaceholders(ctx, tx, guildId, EmbedTemplateSource(embed.Id), templates...); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// updateEmbed returns the guild that the embed belongs to, or false if it does not exist
+func updateEmbed(ctx context.Context, tx pgx.Tx, embed *CustomEmbed) (uint64, bool, error) {
 	query := `
 UPDATE embeds
 SET
@@ -297,14 +306,15 @@ SET
 	"author_url" = $8,
 	"image_url" = $9,
 	"thumbnail_url" = $10,
-	"footer_text" = $11, 
+	"footer_text" = $11,
 	"footer_icon_url" = $12,
 	"timestamp" = $13
-WHERE "id" = $1;
+WHERE "id" = $1
+RETURNING "guild_id";
 `
 
-	// Update actual embed
-	_, err := tx.Exec(
+	var guildId uint64
+	if err := tx.QueryRow(
 		ctx,
 		query,
 		embed.Id,
@@ -320,30 +330,15 @@ WHERE "id" = $1;
 		embed.FooterText,
 		embed.FooterIconUrl,
 		embed.Timestamp,
-	)
-
-	// Delete and recreate fields
-	if _, err := tx.Exec(ctx, `DELETE FROM embed_fields WHERE embed_id = $1;`, embed.Id); err != nil {
-		return err
-	}
-
-	for _, field := range fields {
-		query := `
-INSERT INTO embed_fields(
-	"embed_id",
-	"name",
-	"value",
-	"inline"
-)
-VALUES($1, $2, $3, $4);
-`
-		_, err = tx.Exec(ctx, query, embed.Id, field.Name, field.Value, field.Inline)
-		if err != nil {
-			return err
+	).Scan(&guildId); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, false, nil
 		}
+
+		return 0, false, err
 	}
 
-	return nil
+	return guildId, true, nil
 }
 
 func (s *EmbedsTable) Delete(ctx context.Context, id int) (err error) {
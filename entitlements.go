@@ -98,14 +98,20 @@ func (e *Entitlements) Create(
 		return model.Entitlement{}, err
 	}
 
-	return model.Entitlement{
+	entitlement := model.Entitlement{
 		Id:        id,
 		GuildId:   guildId,
 		UserId:    userId,
 		SkuId:     skuId,
 		Source:    source,
 		ExpiresAt: expiresAt,
-	}, nil
+	}
+
+	if err := publishEntitlementEvent(ctx, tx, OutboxEventEntitlementCreated, entitlement); err != nil {
+		return model.Entitlement{}, err
+	}
+
+	return entitlement, nil
 }
 
 func (e *Entitlements) GetById(ctx context.Context, tx pgx.Tx, id uuid.UUID) (*model.Entitlement, error) {
@@ -129,8 +135,23 @@ func (e *Entitlements) GetById(ctx context.Context, tx pgx.Tx, id uuid.UUID) (*m
 }
 
 func (e *Entitlements) DeleteById(ctx context.Context, tx pgx.Tx, id uuid.UUID) error {
-	_, err := tx.Exec(ctx, entitlementsDeleteById, id)
-	return err
+	var entitlement model.Entitlement
+	if err := tx.QueryRow(ctx, entitlementsDeleteById, id).Scan(
+		&entitlement.Id,
+		&entitlement.GuildId,
+		&entitlement.UserId,
+		&entitlement.SkuId,
+		&entitlement.Source,
+		&entitlement.ExpiresAt,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+
+		return err
+	}
+
+	return publishEntitlementEvent(ctx, tx, OutboxEventEntitlementDeleted, entitlement)
 }
 
 func (e *Entitlements) GetGuildTiers(ctx context.Context, guildId, ownerId uint64, gracePeriod time.Duration, includeVoting bool) ([]model.EntitlementTier, error) {
@@ -252,6 +273,52 @@ func (e *Entitlements) ListAllUserSubscriptions(ctx context.Context, gracePeriod
 }
 
 func (e *Entitlements) IncreaseExpiry(ctx context.Context, tx pgx.Tx, guildId, userId *uint64, skuId uuid.UUID, source model.EntitlementSource, duration time.Duration) error {
-	_, err := tx.Exec(ctx, entitlementsIncreaseExpiry, guildId, userId, skuId, source, duration)
-	return err
+	entitlement := model.Entitlement{
+		GuildId: guildId,
+		UserId:  userId,
+		SkuId:   skuId,
+		Source:  source,
+	}
+
+	if err := tx.QueryRow(ctx, entitlementsIncreaseExpiry, guildId, userId, skuId, source, duration).
+		Scan(&entitlement.Id, &entitlement.ExpiresAt); err != nil {
+		return err
+	}
+
+	return publishEntitlementEvent(ctx, tx, OutboxEventEntitlementUpdated, entitlement)
+}
+
+// publishEntitlementEventById is used when a table linked to an entitlement changes. Nothing is published if the
+// entitlement does not exist.
+func publishEntitlementEventById(ctx context.Context, tx pgx.Tx, eventType OutboxEventType, id uuid.UUID) error {
+	var entitlement model.Entitlement
+	if err := tx.QueryRow(ctx, entitlementsGetById, id).Scan(
+		&entitlement.Id,
+		&entitlement.GuildId,
+		&entitlement.UserId,
+		&entitlement.SkuId,
+		&entitlement.Source,
+		&entitlement.ExpiresAt,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+
+		return err
+	}
+
+	return publishEntitlementEvent(ctx, tx, eventType, entitlement)
+}
+
+func publishEntitlementEvent(ctx context.Context, tx pgx.Tx, eventType OutboxEventType, entitlement model.Entitlement) error {
+	payload := EntitlementEventPayload{
+		Id:        entitlement.Id.String(),
+		GuildId:   entitlement.GuildId,
+		UserId:    entitlement.UserId,
+		SkuId:     entitlement.SkuId.String(),
+		Source:    string(entitlement.Source),
+		ExpiresAt: entitlement.ExpiresAt,
+	}
+
+	return publishOutboxEvent(ctx, tx, OutboxAggregateEntitlement, payload.Id, entitlement.GuildId, eventType, payload)
 }
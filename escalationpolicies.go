@@ -0,0 +1,359 @@
+package database
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// EscalationPolicies escalate tickets opened from a panel that have been waiting for a staff reply, in steps after
+// increasing delays. Each step fires at most once per ticket: workers poll GetDue, and only perform a step's action if
+// MarkFired reports that they were the first to fire it.
+type EscalationPolicies struct {
+	*pgxpool.Pool
+}
+
+type EscalationAction string
+
+const (
+	// EscalationActionNotifyTeam notifies the step's support team
+	EscalationActionNotifyTeam EscalationAction = "notify_team"
+	// EscalationActionMentionOnCall mentions the guild's on call role, from GuildMetadata.OnCallRole
+	EscalationActionMentionOnCall EscalationAction = "mention_on_call"
+	// EscalationActionBumpPriority increases the ticket's priority. It is applied by MarkFired.
+	EscalationActionBumpPriority EscalationAction = "bump_priority"
+)
+
+type EscalationPolicy struct {
+	Id      int              `json:"id"`
+	GuildId uint64           `json:"guild_id,string"`
+	PanelId int              `json:"panel_id"`
+	Enabled bool             `json:"enabled"`
+	Steps   []EscalationStep `json:"steps"` // In order of position
+}
+
+type EscalationStep struct {
+	Id               int              `json:"id"` // Zero for a new step
+	Delay            time.Duration    `json:"delay"`
+	Action           EscalationAction `json:"action"`
+	TeamId           *int             `json:"team_id"`           // Only set for notify_team
+	PriorityIncrease *int             `json:"priority_increase"` // Only set for bump_priority
+}
+
+type DueEscalation struct {
+	GuildId      uint64         `json:"guild_id,string"`
+	TicketId     int            `json:"ticket_id"`
+	PanelId      int            `json:"panel_id"`
+	ChannelId    *uint64        `json:"channel_id"`
+	PolicyId     int            `json:"policy_id"`
+	Step         EscalationStep `json:"step"`
+	WaitingSince time.Time      `json:"waiting_since"`
+}
+
+type FiredEscalation struct {
+	StepId  int       `json:"step_id"`
+	FiredAt time.Time `json:"fired_at"`
+}
+
+var (
+	//go:embed sql/escalation_policies/schema.sql
+	escalationPoliciesSchema string
+
+	//go:embed sql/escalation_policies/get_by_guild.sql
+	escalationPoliciesGetByGuild string
+
+	//go:embed sql/escalation_policies/set_policy.sql
+	escalationPoliciesSetPolicy string
+
+	//go:embed sql/escalation_policies/insert_step.sql
+	escalationPoliciesInsertStep string
+
+	//go:embed sql/escalation_policies/update_step.sql
+	escalationPoliciesUpdateStep string
+
+	//go:embed sql/escalation_policies/delete_steps_except.sql
+	escalationPoliciesDeleteStepsExcept string
+
+	//go:embed sql/escalation_policies/delete.sql
+	escalationPoliciesDelete string
+
+	//go:embed sql/escalation_policies/get_due.sql
+	escalationPoliciesGetDue string
+
+	//go:embed sql/escalation_policies/mark_fired.sql
+	escalationPoliciesMarkFired string
+
+	//go:embed sql/escalation_policies/get_fired.sql
+	escalationPoliciesGetFired string
+)
+
+var ErrEscalationStepNotFound = errors.New("escalation step not found")
+
+func newEscalationPolicies(db *pgxpool.Pool) *EscalationPolicies {
+	return &EscalationPolicies{
+		db,
+	}
+}
+
+func (EscalationPolicies) Schema() string {
+	return escalationPoliciesSchema
+}
+
+// GetByGuild returns a map[panel_id]policy
+func (e *EscalationPolicies) GetByGuild(ctx context.Context, guildId uint64) (map[int]EscalationPolicy, error) {
+	rows, err := e.Query(ctx, escalationPoliciesGetByGuild, guildId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	policies := make(map[int]EscalationPolicy)
+	for rows.Next() {
+		policy := EscalationPolicy{
+			GuildId: guildId,
+		}
+
+		// Step columns are null for policies without any steps
+		var stepId *int
+		var delay *time.Duration
+		var action *EscalationAction
+		var step EscalationStep
+		if err := rows.Scan(
+			&policy.Id,
+			&policy.PanelId,
+			&policy.Enabled,
+			&stepId,
+			&delay,
+			&action,
+			&step.TeamId,
+			&step.PriorityIncrease,
+		); err != nil {
+			return nil, err
+		}
+
+		if existing, ok := policies[policy.PanelId]; ok {
+			policy = existing
+		}
+
+		if stepId != nil {
+			step.Id = *stepId
+			step.Delay = *delay
+			step.Action = *action
+			policy.Steps = append(policy.Steps, step)
+		}
+
+		policies[policy.PanelId] = policy
+	}
+
+	return policies, rows.Err()
+}
+
+func (e *EscalationPolicies) Set(ctx context.Context, policy EscalationPolicy) (int, error) {
+	tx, err := e.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	id, err := e.SetWithTx(ctx, tx, policy)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// SetWithTx creates or replaces the panel's policy, and returns its ID. Steps with an ID update the existing step, so a
+// step that has already fired for a ticket will not fire again, even if it is moved or its delay or action is changed.
+// Steps without an ID are created, and existing steps that are not included are removed. Returns ErrPanelNotFound if
+// the panel does not belong to the guild, ErrSupportTeamNotFound if a step's team does not belong to the guild, or
+// ErrEscalationStepNotFound if a step ID does not belong to the policy.
+func (e *EscalationPolicies) SetWithTx(ctx context.Context, tx pgx.Tx, policy EscalationPolicy) (id int, err error) {
+	if err := tx.QueryRow(ctx, escalationPoliciesSetPolicy, policy.GuildId, policy.PanelId, policy.Enabled).Scan(&id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrPanelNotFound
+		}
+
+		return 0, err
+	}
+
+	// Remove steps that are no longer part of the policy first, so that they do not hold positions
+	keep := make([]int, 0, len(policy.Steps)) // Not nil, as NOT (id = ANY(NULL)) would keep every step
+	for _, step := range policy.Steps {
+		if step.Id != 0 {
+			keep = append(keep, step.Id)
+		}
+	}
+
+	keepArray := &pgtype.Int4Array{}
+	if err := keepArray.Set(keep); err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.Exec(ctx, escalationPoliciesDeleteStepsExcept, id, keepArray); err != nil {
+		return 0, err
+	}
+
+	for position, step := range policy.Steps {
+		if step.Action == EscalationActionNotifyTeam && step.TeamId == nil {
+			return 0, fmt.Errorf("escalation step %d: notify_team requires a team", position)
+		}
+
+		if step.Action == EscalationActionBumpPriority && step.PriorityIncrease == nil {
+			return 0, fmt.Errorf("escalation step %d: bump_priority requires a priority increase", position)
+		}
+
+		delay, err := toInterval(step.Delay)
+		if err != nil {
+			return 0, err
+		}
+
+		if step.Id == 0 {
+			res, err := tx.Exec(ctx, escalationPoliciesInsertStep,
+				id,
+				position,
+				delay,
+				step.Action,
+				step.TeamId,
+				step.PriorityIncrease,
+			)
+			if err != nil {
+				return 0, err
+			}
+
+			if res.RowsAffected() == 0 {
+				return 0, ErrSupportTeamNotFound
+			}
+
+			continue
+		}
+
+		var validTeam, updated bool
+		if err := tx.QueryRow(ctx, escalationPoliciesUpdateStep,
+			id,
+			step.Id,
+			position,
+			delay,
+			step.Action,
+			step.TeamId,
+			step.PriorityIncrease,
+		).Scan(&validTeam, &updated); err != nil {
+			return 0, err
+		}
+
+		if !validTeam {
+			return 0, ErrSupportTeamNotFound
+		}
+
+		if !updated {
+			return 0, ErrEscalationStepNotFound
+		}
+	}
+
+	return id, nil
+}
+
+func (e *EscalationPolicies) Delete(ctx context.Context, guildId uint64, panelId int) (err error) {
+	_, err = e.Exec(ctx, escalationPoliciesDelete, guildId, panelId)
+	return
+}
+
+// GetDue returns escalation steps that should be fired, across all guilds, longest overdue first.
+func (e *EscalationPolicies) GetDue(ctx context.Context, limit int) ([]DueEscalation, error) {
+	rows, err := e.Query(ctx, escalationPoliciesGetDue, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var due []DueEscalation
+	for rows.Next() {
+		var escalation DueEscalation
+		if err := rows.Scan(
+			&escalation.GuildId,
+			&escalation.TicketId,
+			&escalation.PanelId,
+			&escalation.ChannelId,
+			&escalation.PolicyId,
+			&escalation.Step.Id,
+			&escalation.Step.Delay,
+			&escalation.Step.Action,
+			&escalation.Step.TeamId,
+			&escalation.Step.PriorityIncrease,
+			&escalation.WaitingSince,
+		); err != nil {
+			return nil, err
+		}
+
+		due = append(due, escalation)
+	}
+
+	return due, rows.Err()
+}
+
+// MarkFired records that the step has fired for the ticket, and applies the step if it is a bump_priority step. Returns
+// false if the step had already fired, in which case the caller should not perform the step's action.
+func (e *EscalationPolicies) MarkFired(ctx context.Context, escalation DueEscalation) (bool, error) {
+	tx, err := e.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	res, err := tx.Exec(ctx, escalationPoliciesMarkFired, escalation.GuildId, escalation.TicketId, escalation.Step.Id)
+	if err != nil {
+		return false, err
+	}
+
+	if res.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	if escalation.Step.Action == EscalationActionBumpPriority && escalation.Step.PriorityIncrease != nil {
+		if _, err := tx.Exec(ctx, ticketPriorityIncrease, escalation.GuildId, escalation.TicketId, *escalation.Step.PriorityIncrease); err != nil {
+			return false, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// GetFired returns the steps that have fired for the ticket
+func (e *EscalationPolicies) GetFired(ctx context.Context, guildId uint64, ticketId int) ([]FiredEscalation, error) {
+	rows, err := e.Query(ctx, escalationPoliciesGetFired, guildId, ticketId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var fired []FiredEscalation
+	for rows.Next() {
+		var f FiredEscalation
+		if err := rows.Scan(&f.StepId, &f.FiredAt); err != nil {
+			return nil, err
+		}
+
+		fired = append(fired, f)
+	}
+
+	return fired, rows.Err()
+}
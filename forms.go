@@ -73,6 +73,26 @@ func (f *FormsTable) GetForms(ctx context.Context, guildId uint64) (forms []Form
 }
 
 func (f *FormsTable) Create(ctx context.Context, guildId uint64, title, customId string) (int, error) {
+	tx, err := f.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	id, err := f.CreateWithTx(ctx, tx, guildId, title, customId)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (f *FormsTable) CreateWithTx(ctx context.Context, tx pgx.Tx, guildId uint64, title, customId string) (int, error) {
 	query := `
 INSERT INTO forms("guild_id", "title", "custom_id")
 VALUES($1, $2, $3)
@@ -80,7 +100,7 @@ RETURNING "form_id";
 `
 
 	var id int
-	if err := f.QueryRow(ctx, query, guildId, title, customId).Scan(&id); err != nil {
+	if err := tx.QueryRow(ctx, query, guildId, title, customId).Scan(&id); err != nil {
 		return 0, err
 	}
 
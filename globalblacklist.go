@@ -20,15 +20,23 @@ func (b GlobalBlacklist) Schema() string {
 	return `
 CREATE TABLE IF NOT EXISTS global_blacklist(
 	"user_id" int8 NOT NULL UNIQUE,
+	"reason" TEXT,
+	"created_by" int8,
+	"created_at" timestamptz NOT NULL DEFAULT NOW(),
+	"expires_at" timestamptz,
 	PRIMARY KEY("user_id")
 );
+ALTER TABLE global_blacklist ADD COLUMN IF NOT EXISTS "reason" TEXT;
+ALTER TABLE global_blacklist ADD COLUMN IF NOT EXISTS "created_by" int8;
+ALTER TABLE global_blacklist ADD COLUMN IF NOT EXISTS "created_at" timestamptz NOT NULL DEFAULT NOW();
+ALTER TABLE global_blacklist ADD COLUMN IF NOT EXISTS "expires_at" timestamptz;
 `
 }
 
 func (b *GlobalBlacklist) IsBlacklisted(ctx context.Context, userId uint64) (blacklisted bool, err error) {
 	query := `
 SELECT EXISTS(
-	SELECT 1 FROM global_blacklist WHERE "user_id" = $1
+	SELECT 1 FROM global_blacklist WHERE "user_id" = $1 AND ("expires_at" IS NULL OR "expires_at" > NOW())
 );
 `
 
@@ -37,13 +45,15 @@ SELECT EXISTS(
 }
 
 func (b *GlobalBlacklist) ListAll(ctx context.Context) (users []uint64, err error) {
-	query := `SELECT "user_id" FROM global_blacklist;`
+	query := `SELECT "user_id" FROM global_blacklist WHERE "expires_at" IS NULL OR "expires_at" > NOW();`
 
 	rows, err := b.Query(ctx, query)
 	if err != nil {
 		return
 	}
 
+	defer rows.Close()
+
 	for rows.Next() {
 		var userId uint64
 		if err = rows.Scan(&userId); err != nil {
@@ -56,12 +66,82 @@ func (b *GlobalBlacklist) ListAll(ctx context.Context) (users []uint64, err erro
 	return
 }
 
+// Add permanently blacklists the user, without a reason. An existing blacklist is left unchanged, unless it has expired
+// and not yet been deleted by DeleteExpired, in which case it is replaced.
 func (b *GlobalBlacklist) Add(ctx context.Context, userId uint64) (err error) {
-	_, err = b.Exec(ctx, `INSERT INTO global_blacklist("user_id") VALUES($1) ON CONFLICT("user_id") DO NOTHING;`, userId)
+	query := `
+WITH added AS (
+	INSERT INTO global_blacklist("user_id") VALUES($1)
+	ON CONFLICT("user_id") DO UPDATE
+	SET "reason" = NULL,
+		"created_by" = NULL,
+		"created_at" = NOW(),
+		"expires_at" = NULL
+	WHERE global_blacklist."expires_at" <= NOW()
+	RETURNING "user_id"
+)
+INSERT INTO blacklist_history("type", "subject_id", "action")
+SELECT 'global', "user_id", 'added'
+FROM added;`
+
+	_, err = b.Exec(ctx, query, userId)
+	return
+}
+
+// AddWithMetadata blacklists the user, replacing any existing blacklist, and records it in the user's history.
+func (b *GlobalBlacklist) AddWithMetadata(ctx context.Context, userId uint64, metadata BlacklistMetadata) (err error) {
+	query := `
+WITH added AS (
+	INSERT INTO global_blacklist("user_id", "reason", "created_by", "created_at", "expires_at")
+	VALUES($1, $2, $3, NOW(), $4)
+	ON CONFLICT("user_id") DO UPDATE
+	SET "reason" = EXCLUDED."reason",
+		"created_by" = EXCLUDED."created_by",
+		"created_at" = EXCLUDED."created_at",
+		"expires_at" = EXCLUDED."expires_at"
+	RETURNING "user_id", "reason", "created_by", "expires_at"
+)
+INSERT INTO blacklist_history("type", "subject_id", "action", "reason", "actor_id", "expires_at")
+SELECT 'global', "user_id", 'added', "reason", "created_by", "expires_at"
+FROM added;`
+
+	_, err = b.Exec(ctx, query, userId, metadata.Reason, metadata.CreatedBy, metadata.ExpiresAt)
 	return
 }
 
 func (b *GlobalBlacklist) Delete(ctx context.Context, userId uint64) (err error) {
-	_, err = b.Exec(ctx, `DELETE FROM global_blacklist WHERE "user_id" = $1;`, userId)
+	query := `
+WITH removed AS (
+	DELETE FROM global_blacklist WHERE "user_id" = $1
+	RETURNING "user_id", "expires_at"
+)
+INSERT INTO blacklist_history("type", "subject_id", "action", "expires_at")
+SELECT
+	'global',
+	"user_id",
+	CASE WHEN "expires_at" <= NOW() THEN 'expired'::blacklist_action ELSE 'removed'::blacklist_action END,
+	"expires_at"
+FROM removed;`
+
+	_, err = b.Exec(ctx, query, userId)
 	return
 }
+
+// DeleteExpired removes all expired blacklists, recording their expiry in history, and returns the number removed.
+func (b *GlobalBlacklist) DeleteExpired(ctx context.Context) (int64, error) {
+	query := `
+WITH removed AS (
+	DELETE FROM global_blacklist WHERE "expires_at" <= NOW()
+	RETURNING "user_id", "expires_at"
+)
+INSERT INTO blacklist_history("type", "subject_id", "action", "expires_at")
+SELECT 'global', "user_id", 'expired', "expires_at"
+FROM removed;`
+
+	res, err := b.Exec(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected(), nil
+}
@@ -0,0 +1,601 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+// GuildConfigVersion is incremented whenever the format of GuildConfig changes in a way that older versions of
+// ImportGuildConfig would not understand. Version 2 added access condition rules, and tag categories, aliases and scopes.
+const GuildConfigVersion = 2
+
+var ErrUnsupportedGuildConfigVersion = errors.New("unsupported guild config version")
+
+// GuildConfig is a snapshot of a guild's setup, used to copy it to another guild. IDs within the snapshot are those of
+// the source guild, and are remapped by ImportGuildConfig.
+type GuildConfig struct {
+	Version       int                      `json:"version"`
+	GuildId       uint64                   `json:"guild_id,string"`
+	ExportedAt    time.Time                `json:"exported_at"`
+	Panels        []GuildConfigPanel       `json:"panels"`
+	MultiPanels   []GuildConfigMultiPanel  `json:"multi_panels"`
+	Forms         []GuildConfigForm        `json:"forms"`
+	SupportTeams  []GuildConfigSupportTeam `json:"support_teams"`
+	Tags          []GuildConfigTag         `json:"tags"`
+	TagCategories []TagCategory            `json:"tag_categories"`
+	CustomColours map[int16]int            `json:"custom_colours"`
+	Settings      Settings                 `json:"settings"`
+}
+
+type GuildConfigPanel struct {
+	Panel              Panel                      `json:"panel"`
+	WelcomeMessage     *CustomEmbedWithFields     `json:"welcome_message"`
+	TeamIds            []int                      `json:"team_ids"`
+	AccessControlRules []PanelAccessControlRule   `json:"access_control_rules"`
+	AccessConditions   []PanelAccessConditionRule `json:"access_conditions"`
+	RoleMentions       []uint64                   `json:"role_mentions"`
+	MentionUser        bool                       `json:"mention_user"`
+}
+
+type GuildConfigMultiPanel struct {
+	MultiPanel MultiPanel `json:"multi_panel"`
+	PanelIds   []int      `json:"panel_ids"`
+}
+
+type GuildConfigForm struct {
+	Form   Form        `json:"form"`
+	Inputs []FormInput `json:"inputs"`
+}
+
+type GuildConfigSupportTeam struct {
+	Team    SupportTeam `json:"team"`
+	Members []uint64    `json:"members"`
+	Roles   []uint64    `json:"roles"`
+}
+
+type GuildConfigTag struct {
+	Id         string                 `json:"id"`
+	Content    *string                `json:"content"`
+	Embed      *CustomEmbedWithFields `json:"embed"`
+	Aliases    []string               `json:"aliases"`
+	CategoryId *int                   `json:"category_id"`
+	Scope      *TagScope              `json:"scope"`
+}
+
+func (d *Database) ExportGuildConfig(ctx context.Context, guildId uint64) (GuildConfig, error) {
+	config := GuildConfig{
+		Version:    GuildConfigVersion,
+		GuildId:    guildId,
+		ExportedAt: time.Now(),
+	}
+
+	// Panels
+	panels, err := d.Panel.GetByGuildWithWelcomeMessage(ctx, guildId)
+	if err != nil {
+		return GuildConfig{}, err
+	}
+
+	welcomeMessageFields, err := d.EmbedFields.GetAllFieldsForPanels(ctx, guildId)
+	if err != nil {
+		return GuildConfig{}, err
+	}
+
+	accessControlRules, err := d.PanelAccessControlRules.GetAllForGuild(ctx, guildId)
+	if err != nil {
+		return GuildConfig{}, err
+	}
+
+	for _, panel := range panels {
+		exported := GuildConfigPanel{
+			Panel:              panel.Panel,
+			AccessControlRules: accessControlRules[panel.PanelId],
+		}
+
+		if panel.WelcomeMessage != nil {
+			exported.WelcomeMessage = &CustomEmbedWithFields{
+				CustomEmbed: panel.WelcomeMessage,
+				Fields:      welcomeMessageFields[panel.WelcomeMessage.Id],
+			}
+		}
+
+		if exported.AccessConditions, err = d.PanelAccessControlRules.GetConditionRules(ctx, panel.PanelId); err != nil {
+			return GuildConfig{}, err
+		}
+
+		if exported.TeamIds, err = d.PanelTeams.GetTeamIds(ctx, panel.PanelId); err != nil {
+			return GuildConfig{}, err
+		}
+
+		if exported.RoleMentions, err = d.PanelRoleMentions.GetRoles(ctx, panel.PanelId); err != nil {
+			return GuildConfig{}, err
+		}
+
+		if exported.MentionUser, err = d.PanelUserMention.ShouldMentionUser(ctx, panel.PanelId); err != nil {
+			return GuildConfig{}, err
+		}
+
+		config.Panels = append(config.Panels, exported)
+	}
+
+	// Multi-panels
+	multiPanels, err := d.MultiPanels.GetByGuild(ctx, guildId)
+	if err != nil {
+		return GuildConfig{}, err
+	}
+
+	for _, multiPanel := range multiPanels {
+		targets, err := d.MultiPanelTargets.GetPanels(ctx, multiPanel.Id)
+		if err != nil {
+			return GuildConfig{}, err
+		}
+
+		panelIds := make([]int, len(targets))
+		for i, target := range targets {
+			panelIds[i] = target.PanelId
+		}
+
+		config.MultiPanels = append(config.MultiPanels, GuildConfigMultiPanel{
+			MultiPanel: multiPanel,
+			PanelIds:   panelIds,
+		})
+	}
+
+	// Forms
+	forms, err := d.Forms.GetForms(ctx, guildId)
+	if err != nil {
+		return GuildConfig{}, err
+	}
+
+	inputs, err := d.FormInput.GetInputsForGuild(ctx, guildId)
+	if err != nil {
+		return GuildConfig{}, err
+	}
+
+	for _, form := range forms {
+		config.Forms = append(config.Forms, GuildConfigForm{
+			Form:   form,
+			Inputs: inputs[form.Id],
+		})
+	}
+
+	// Support teams
+	teams, err := d.SupportTeam.Get(ctx, guildId)
+	if err != nil {
+		return GuildConfig{}, err
+	}
+
+	for _, team := range teams {
+		exported := GuildConfigSupportTeam{
+			Team: team,
+		}
+
+		if exported.Members, err = d.SupportTeamMembers.Get(ctx, team.Id); err != nil {
+			return GuildConfig{}, err
+		}
+
+		if exported.Roles, err = d.SupportTeamRoles.Get(ctx, team.Id); err != nil {
+			return GuildConfig{}, err
+		}
+
+		config.SupportTeams = append(config.SupportTeams, exported)
+	}
+
+	// Tags
+	tags, err := d.Tag.GetByGuild(ctx, guildId)
+	if err != nil {
+		return GuildConfig{}, err
+	}
+
+	if config.TagCategories, err = d.TagCategories.GetByGuild(ctx, guildId); err != nil {
+		return GuildConfig{}, err
+	}
+
+	tagCategories, err := d.TagCategories.GetTagCategories(ctx, guildId)
+	if err != nil {
+		return GuildConfig{}, err
+	}
+
+	aliases, err := d.TagAliases.GetByGuild(ctx, guildId)
+	if err != nil {
+		return GuildConfig{}, err
+	}
+
+	scopes, err := d.TagScopes.GetByGuild(ctx, guildId)
+	if err != nil {
+		return GuildConfig{}, err
+	}
+
+	for _, tag := range tags {
+		exported := GuildConfigTag{
+			Id:      tag.Id,
+			Content: tag.Content,
+			Embed:   tag.Embed,
+		}
+
+		if categoryId, ok := tagCategories[tag.Id]; ok {
+			exported.CategoryId = &categoryId
+		}
+
+		if scope, ok := scopes[tag.Id]; ok {
+			exported.Scope = &scope
+		}
+
+		config.Tags = append(config.Tags, exported)
+	}
+
+	sort.Slice(config.Tags, func(i, j int) bool {
+		return config.Tags[i].Id < config.Tags[j].Id
+	})
+
+	for alias, tagId := range aliases {
+		for i := range config.Tags {
+			if config.Tags[i].Id == tagId {
+				config.Tags[i].Aliases = append(config.Tags[i].Aliases, alias)
+				break
+			}
+		}
+	}
+
+	for _, tag := range config.Tags {
+		sort.Strings(tag.Aliases)
+	}
+
+	// Custom colours & settings
+	if config.CustomColours, err = d.CustomColours.GetAll(ctx, guildId); err != nil {
+		return GuildConfig{}, err
+	}
+
+	if config.Settings, err = d.Settings.Get(ctx, guildId); err != nil {
+		return GuildConfig{}, err
+	}
+
+	return config, nil
+}
+
+// ImportGuildConfig recreates the configuration in the snapshot in the target guild, in a single transaction. The
+// target guild is expected not to have been set up yet: conflicts, such as a support team with the same name, cause the
+// whole import to be rolled back.
+//
+// Channel, category and role IDs are translated through the snowflakes map, and are kept unchanged if they are not
+// present in it. The source guild ID (i.e. the @everyone role) is always translated to the target guild ID. Support
+// team on-call roles and tag application commands are not imported, as they are created by the bot.
+//
+// Panels and multi-panels are created without a message ID, and should be sent and have their message ID updated
+// afterwards. The mapping from source to target IDs is recorded in ImportMappingTable, and is also returned,
+// keyed by mapping area.
+func (d *Database) ImportGuildConfig(
+	ctx context.Context,
+	guildId uint64,
+	config GuildConfig,
+	snowflakes map[uint64]uint64,
+) (map[string]map[int]int, error) {
+	if config.Version < 1 || config.Version > GuildConfigVersion {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedGuildConfigVersion, config.Version)
+	}
+
+	mapSnowflake := func(id uint64) uint64 {
+		if id == config.GuildId {
+			return guildId
+		}
+
+		if mapped, ok := snowflakes[id]; ok {
+			return mapped
+		}
+
+		return id
+	}
+
+	mapSnowflakePtr := func(id *uint64) *uint64 {
+		if id == nil {
+			return nil
+		}
+
+		return ptr(mapSnowflake(*id))
+	}
+
+	mapSnowflakes := func(ids []uint64) []uint64 {
+		mapped := make([]uint64, len(ids))
+		for i, id := range ids {
+			mapped[i] = mapSnowflake(id)
+		}
+
+		return mapped
+	}
+
+	mapping := map[string]map[int]int{
+		ImportMappingAreaForm:        make(map[int]int),
+		ImportMappingAreaFormInput:   make(map[int]int),
+		ImportMappingAreaSupportTeam: make(map[int]int),
+		ImportMappingAreaEmbed:       make(map[int]int),
+		ImportMappingAreaPanel:       make(map[int]int),
+		ImportMappingAreaMultiPanel:  make(map[int]int),
+		ImportMappingAreaTagCategory: make(map[int]int),
+	}
+
+	// Returns nil if the source ID was not imported, e.g. a form that has since been deleted
+	mapId := func(area string, id *int) *int {
+		if id == nil {
+			return nil
+		}
+
+		if mapped, ok := mapping[area][*id]; ok {
+			return &mapped
+		}
+
+		return nil
+	}
+
+	err := d.WithTx(ctx, func(tx pgx.Tx) error {
+		// Forms must be created before panels
+		for _, form := range config.Forms {
+			formId, err := d.Forms.CreateWithTx(ctx, tx, guildId, form.Form.Title, newCustomId())
+			if err != nil {
+				return err
+			}
+
+			mapping[ImportMappingAreaForm][form.Form.Id] = formId
+
+			for _, input := range form.Inputs {
+				inputId, err := d.FormInput.CreateTx(
+					ctx,
+					tx,
+					formId,
+					newCustomId(),
+					input.Position,
+					input.Style,
+					input.Label,
+					input.Placeholder,
+					input.Required,
+					input.MinLength,
+					input.MaxLength,
+				)
+				if err != nil {
+					return err
+				}
+
+				mapping[ImportMappingAreaFormInput][input.Id] = inputId
+			}
+		}
+
+		// Support teams must be created before panel teams
+		for _, team := range config.SupportTeams {
+			teamId, err := d.SupportTeam.CreateWithTx(ctx, tx, guildId, team.Team.Name)
+			if err != nil {
+				return err
+			}
+
+			mapping[ImportMappingAreaSupportTeam][team.Team.Id] = teamId
+
+			for _, userId := range team.Members {
+				if err := d.SupportTeamMembers.AddWithTx(ctx, tx, teamId, userId); err != nil {
+					return err
+				}
+			}
+
+			for _, roleId := range team.Roles {
+				if err := d.SupportTeamRoles.AddWithTx(ctx, tx, teamId, mapSnowflake(roleId)); err != nil {
+					return err
+				}
+			}
+		}
+
+		// Panels
+		for _, exported := range config.Panels {
+			panel := exported.Panel
+			panel.GuildId = guildId
+			panel.ChannelId = mapSnowflake(panel.ChannelId)
+			panel.TargetCategory = mapSnowflake(panel.TargetCategory)
+			panel.PendingCategory = mapSnowflakePtr(panel.PendingCategory)
+			panel.CustomId = newCustomId()
+			panel.FormId = mapId(ImportMappingAreaForm, panel.FormId)
+			panel.ExitSurveyFormId = mapId(ImportMappingAreaForm, panel.ExitSurveyFormId)
+			panel.MessageId = 0
+
+			panel.WelcomeMessageEmbed = nil
+			if exported.WelcomeMessage != nil && exported.WelcomeMessage.CustomEmbed != nil {
+				embed := *exported.WelcomeMessage.CustomEmbed
+				embed.GuildId = guildId
+
+				embedId, err := d.Embeds.CreateWithFieldsTx(ctx, tx, &embed, exported.WelcomeMessage.Fields)
+				if err != nil {
+					return err
+				}
+
+				if exported.Panel.WelcomeMessageEmbed != nil {
+					mapping[ImportMappingAreaEmbed][*exported.Panel.WelcomeMessageEmbed] = embedId
+				}
+
+				panel.WelcomeMessageEmbed = &embedId
+			}
+
+			panelId, err := d.Panel.CreateWithTx(ctx, tx, panel)
+			if err != nil {
+				return err
+			}
+
+			mapping[ImportMappingAreaPanel][exported.Panel.PanelId] = panelId
+
+			teamIds := make([]int, 0, len(exported.TeamIds))
+			for _, teamId := range exported.TeamIds {
+				if mapped := mapId(ImportMappingAreaSupportTeam, &teamId); mapped != nil {
+					teamIds = append(teamIds, *mapped)
+				}
+			}
+
+			if err := d.PanelTeams.ReplaceWithTx(ctx, tx, panelId, teamIds); err != nil {
+				return err
+			}
+
+			rules := make([]PanelAccessControlRule, len(exported.AccessControlRules))
+			for i, rule := range exported.AccessControlRules {
+				rules[i] = PanelAccessControlRule{
+					RoleId: mapSnowflake(rule.RoleId),
+					Action: rule.Action,
+				}
+			}
+
+			if err := d.PanelAccessControlRules.ReplaceWithTx(ctx, tx, panelId, rules); err != nil {
+				return err
+			}
+
+			conditionRules := make([]PanelAccessConditionRule, len(exported.AccessConditions))
+			for i, rule := range exported.AccessConditions {
+				conditions := make([]AccessCondition, len(rule.Conditions))
+				for j, condition := range rule.Conditions {
+					if condition.RoleIds != nil {
+						condition.RoleIds = mapSnowflakes(condition.RoleIds)
+					}
+
+					conditions[j] = condition
+				}
+
+				conditionRules[i] = PanelAccessConditionRule{
+					Action:     rule.Action,
+					Conditions: conditions,
+				}
+			}
+
+			if err := d.PanelAccessControlRules.ReplaceConditionRulesWithTx(ctx, tx, panelId, conditionRules); err != nil {
+				return err
+			}
+
+			if err := d.PanelRoleMentions.ReplaceWithTx(ctx, tx, panelId, mapSnowflakes(exported.RoleMentions)); err != nil {
+				return err
+			}
+
+			if err := d.PanelUserMention.SetWithTx(ctx, tx, panelId, exported.MentionUser); err != nil {
+				return err
+			}
+		}
+
+		// Multi-panels must be created after panels
+		for _, exported := range config.MultiPanels {
+			multiPanel := exported.MultiPanel
+			multiPanel.GuildId = guildId
+			multiPanel.ChannelId = mapSnowflake(multiPanel.ChannelId)
+			multiPanel.MessageId = 0
+
+			multiPanelId, err := d.MultiPanels.CreateWithTx(ctx, tx, multiPanel)
+			if err != nil {
+				return err
+			}
+
+			mapping[ImportMappingAreaMultiPanel][exported.MultiPanel.Id] = multiPanelId
+
+			for _, panelId := range exported.PanelIds {
+				if mapped := mapId(ImportMappingAreaPanel, &panelId); mapped != nil {
+					if err := d.MultiPanelTargets.InsertWithTx(ctx, tx, multiPanelId, *mapped); err != nil {
+						return err
+					}
+				}
+			}
+		}
+
+		// Tag categories must be created before tags
+		for _, category := range config.TagCategories {
+			categoryId, err := d.TagCategories.CreateWithTx(ctx, tx, guildId, category.Name)
+			if err != nil {
+				return err
+			}
+
+			mapping[ImportMappingAreaTagCategory][category.Id] = categoryId
+		}
+
+		// Tags
+		for _, tag := range config.Tags {
+			if err := d.Tag.SetWithTx(ctx, tx, Tag{
+				Id:      tag.Id,
+				GuildId: guildId,
+				Content: tag.Content,
+				Embed:   tag.Embed,
+			}); err != nil {
+				return err
+			}
+
+			if categoryId := mapId(ImportMappingAreaTagCategory, tag.CategoryId); categoryId != nil {
+				if err := d.TagCategories.SetTagCategoryWithTx(ctx, tx, guildId, tag.Id, categoryId); err != nil {
+					return err
+				}
+			}
+
+			if tag.Scope != nil {
+				var scope TagScope
+				for _, panelId := range tag.Scope.PanelIds {
+					if mapped := mapId(ImportMappingAreaPanel, &panelId); mapped != nil {
+						scope.PanelIds = append(scope.PanelIds, *mapped)
+					}
+				}
+
+				for _, teamId := range tag.Scope.TeamIds {
+					if mapped := mapId(ImportMappingAreaSupportTeam, &teamId); mapped != nil {
+						scope.TeamIds = append(scope.TeamIds, *mapped)
+					}
+				}
+
+				if err := d.TagScopes.SetWithTx(ctx, tx, guildId, tag.Id, scope); err != nil {
+					return err
+				}
+			}
+		}
+
+		// Aliases must be added after all tags, as an alias must not clash with a tag ID
+		for _, tag := range config.Tags {
+			for _, alias := range tag.Aliases {
+				if _, err := d.TagAliases.AddWithTx(ctx, tx, guildId, alias, tag.Id); err != nil {
+					return err
+				}
+			}
+		}
+
+		// Custom colours
+		if err := d.CustomColours.BatchSetWithTx(ctx, tx, guildId, config.CustomColours); err != nil {
+			return err
+		}
+
+		// Settings reference panels and forms, so must be set last
+		settings := config.Settings
+		settings.ContextMenuPanel = mapId(ImportMappingAreaPanel, settings.ContextMenuPanel)
+		settings.TicketNotificationChannel = mapSnowflakePtr(settings.TicketNotificationChannel)
+		settings.OverflowCategoryId = mapSnowflakePtr(settings.OverflowCategoryId)
+
+		if settings.ExitSurveyFormId != nil {
+			settings.ExitSurveyFormId = nil
+
+			sourceId := int(*config.Settings.ExitSurveyFormId)
+			if mapped := mapId(ImportMappingAreaForm, &sourceId); mapped != nil {
+				settings.ExitSurveyFormId = ptr(uint64(*mapped))
+			}
+		}
+
+		if err := d.Settings.SetWithTx(ctx, tx, guildId, settings); err != nil {
+			return err
+		}
+
+		for area, ids := range mapping {
+			for sourceId, targetId := range ids {
+				if err := d.ImportMappingTable.SetWithTx(ctx, tx, guildId, area, sourceId, targetId); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return mapping, nil
+}
+
+func newCustomId() string {
+	return strings.ReplaceAll(uuid.NewString(), "-", "")
+}
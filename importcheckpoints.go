@@ -0,0 +1,80 @@
+package database
+
+import (
+	"context"
+	_ "embed"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// ImportCheckpointsTable records which entities of an import run have been committed, so that a failed run can be
+// resumed without re-importing entities that already completed.
+type ImportCheckpointsTable struct {
+	*pgxpool.Pool
+}
+
+type ImportCheckpoint struct {
+	EntityType  string    `json:"entity_type"`
+	RowCount    int       `json:"row_count"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+var (
+	//go:embed sql/import_checkpoints/schema.sql
+	importCheckpointsSchema string
+
+	//go:embed sql/import_checkpoints/set.sql
+	importCheckpointsSet string
+
+	//go:embed sql/import_checkpoints/get_run.sql
+	importCheckpointsGetRun string
+
+	//go:embed sql/import_checkpoints/delete_run.sql
+	importCheckpointsDeleteRun string
+)
+
+func newImportCheckpoints(db *pgxpool.Pool) *ImportCheckpointsTable {
+	return &ImportCheckpointsTable{
+		db,
+	}
+}
+
+func (ImportCheckpointsTable) Schema() string {
+	return importCheckpointsSchema
+}
+
+// GetRun returns the checkpoints of a run, keyed by entity type.
+func (c *ImportCheckpointsTable) GetRun(ctx context.Context, guildId uint64, runId int) (map[string]ImportCheckpoint, error) {
+	rows, err := c.Query(ctx, importCheckpointsGetRun, guildId, runId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	checkpoints := make(map[string]ImportCheckpoint)
+	for rows.Next() {
+		var checkpoint ImportCheckpoint
+		if err := rows.Scan(&checkpoint.EntityType, &checkpoint.RowCount, &checkpoint.CompletedAt); err != nil {
+			return nil, err
+		}
+
+		checkpoints[checkpoint.EntityType] = checkpoint
+	}
+
+	return checkpoints, rows.Err()
+}
+
+// SetWithTx should be called in the same transaction that imports the entity, so that the checkpoint is only recorded
+// if the import is committed.
+func (c *ImportCheckpointsTable) SetWithTx(ctx context.Context, tx pgx.Tx, guildId uint64, runId int, entityType string, rowCount int) error {
+	_, err := tx.Exec(ctx, importCheckpointsSet, guildId, runId, entityType, rowCount)
+	return err
+}
+
+func (c *ImportCheckpointsTable) DeleteRunWithTx(ctx context.Context, tx pgx.Tx, guildId uint64, runId int) error {
+	_, err := tx.Exec(ctx, importCheckpointsDeleteRun, guildId, runId)
+	return err
+}
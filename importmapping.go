@@ -19,12 +19,26 @@ type ImportMapping struct {
 	TargetId int    `json:"target_id"`
 }
 
+const (
+	ImportMappingAreaTicket      = "ticket"
+	ImportMappingAreaForm        = "form"
+	ImportMappingAreaFormInput   = "form_input"
+	ImportMappingAreaPanel       = "panel"
+	ImportMappingAreaEmbed       = "embed"
+	ImportMappingAreaMultiPanel  = "multi_panel"
+	ImportMappingAreaSupportTeam = "support_team"
+	ImportMappingAreaTagCategory = "tag_category"
+)
+
 var (
 	//go:embed sql/import_mapping/schema.sql
 	importMappingSchema string
 
 	//go:embed sql/import_mapping/set.sql
 	importMappingSet string
+
+	//go:embed sql/import_mapping/set_source.sql
+	importMappingSetSource string
 )
 
 func newImportMapping(db *pgxpool.Pool) *ImportMappingTable {
@@ -37,10 +51,26 @@ func (s ImportMappingTable) Schema() string {
 	return importMappingSchema
 }
 
+// GetMapping returns the mappings recorded by guild config and Tickets imports. If a source ID has been imported more
+// than once, it is mapped to the most recently created target.
 func (s *ImportMappingTable) GetMapping(ctx context.Context, guildId uint64) (map[string]map[int]int, error) {
-	query := `SELECT * FROM import_mapping WHERE "guild_id" = $1;`
+	return s.getMapping(ctx, guildId, nil)
+}
+
+// GetSourceMapping returns the mappings recorded by imports from the given ImportSource, which are kept separate from
+// other imports as the source bot's IDs may collide with them.
+func (s *ImportMappingTable) GetSourceMapping(ctx context.Context, guildId uint64, source string) (map[string]map[int]int, error) {
+	return s.getMapping(ctx, guildId, &source)
+}
+
+func (s *ImportMappingTable) getMapping(ctx context.Context, guildId uint64, source *string) (map[string]map[int]int, error) {
+	query := `
+SELECT "guild_id", "area", "source_id", "target_id"
+FROM import_mapping
+WHERE "guild_id" = $1 AND "source" IS NOT DISTINCT FROM $2
+ORDER BY "target_id";`
 
-	rows, err := s.Query(ctx, query, guildId)
+	rows, err := s.Query(ctx, query, guildId, source)
 	if err != nil {
 		return nil, err
 	}
@@ -68,6 +98,16 @@ func (s *ImportMappingTable) Set(ctx context.Context, guildId uint64, area strin
 	return err
 }
 
+func (s *ImportMappingTable) SetWithTx(ctx context.Context, tx pgx.Tx, guildId uint64, area string, sourceId, targetId int) error {
+	_, err := tx.Exec(ctx, importMappingSet, guildId, area, sourceId, targetId)
+	return err
+}
+
+func (s *ImportMappingTable) SetSourceWithTx(ctx context.Context, tx pgx.Tx, guildId uint64, source, area string, sourceId, targetId int) error {
+	_, err := tx.Exec(ctx, importMappingSetSource, guildId, area, sourceId, targetId, source)
+	return err
+}
+
 func (s *ImportMappingTable) SetBulk(ctx context.Context, guildId uint64, area string, mappings map[int]int) error {
 	rows := make([][]interface{}, len(mappings))
 
@@ -0,0 +1,381 @@
+package database
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+)
+
+// Entity types used for import checkpoints and logs. Entities are imported in this order, as all other entities
+// reference tickets.
+const (
+	ImportEntityTickets           = "tickets"
+	ImportEntityParticipants      = "participants"
+	ImportEntityTicketMembers     = "ticket_members"
+	ImportEntityServiceRatings    = "service_ratings"
+	ImportEntityTicketClaims      = "ticket_claims"
+	ImportEntityTicketLastMessage = "ticket_last_message"
+)
+
+// TicketImportData holds the data exported from the source bot. All ticket IDs are source IDs, and are remapped to
+// target IDs when imported.
+type TicketImportData struct {
+	Source       string // The name of the ImportSource the data was parsed by, empty for a Tickets export
+	Tickets      []Ticket
+	Participants map[int][]uint64
+	Members      map[int][]uint64
+	Ratings      map[int]uint8
+	Claims       map[int]uint64
+	LastMessages map[int]TicketLastMessage
+}
+
+type ImportEntityResult struct {
+	Entity   string `json:"entity"`
+	Staged   int    `json:"staged"`
+	Inserted int    `json:"inserted"`
+	Skipped  int    `json:"skipped"` // Rows that already existed, or that reference a ticket that was not imported
+	Resumed  bool   `json:"resumed"` // The entity had already been imported by a previous attempt at the run
+}
+
+type importStep struct {
+	entity  string
+	stage   string
+	insert  string
+	table   string
+	columns []string
+	rows    [][]interface{}
+	args    []interface{} // Passed to insert after the guild and run IDs
+}
+
+var (
+	//go:embed sql/import_pipeline/stage_tickets.sql
+	importPipelineStageTickets string
+
+	//go:embed sql/import_pipeline/lock_ticket_ids.sql
+	importPipelineLockTicketIds string
+
+	//go:embed sql/import_pipeline/map_tickets.sql
+	importPipelineMapTickets string
+
+	//go:embed sql/import_pipeline/insert_tickets.sql
+	importPipelineInsertTickets string
+
+	//go:embed sql/import_pipeline/stage_participant.sql
+	importPipelineStageParticipant string
+
+	//go:embed sql/import_pipeline/insert_participant.sql
+	importPipelineInsertParticipant string
+
+	//go:embed sql/import_pipeline/stage_ticket_members.sql
+	importPipelineStageTicketMembers string
+
+	//go:embed sql/import_pipeline/insert_ticket_members.sql
+	importPipelineInsertTicketMembers string
+
+	//go:embed sql/import_pipeline/stage_service_ratings.sql
+	importPipelineStageServiceRatings string
+
+	//go:embed sql/import_pipeline/insert_service_ratings.sql
+	importPipelineInsertServiceRatings string
+
+	//go:embed sql/import_pipeline/stage_ticket_claims.sql
+	importPipelineStageTicketClaims string
+
+	//go:embed sql/import_pipeline/insert_ticket_claims.sql
+	importPipelineInsertTicketClaims string
+
+	//go:embed sql/import_pipeline/stage_ticket_last_message.sql
+	importPipelineStageTicketLastMessage string
+
+	//go:embed sql/import_pipeline/insert_ticket_last_message.sql
+	importPipelineInsertTicketLastMessage string
+
+	//go:embed sql/import_pipeline/get_run_tickets.sql
+	importPipelineGetRunTickets string
+
+	//go:embed sql/import_pipeline/delete_run_mappings.sql
+	importPipelineDeleteRunMappings string
+)
+
+// ImportTicketData imports tickets and their associated data as part of the given run, which should be created with
+// ImportLogsTable.CreateRun. Each entity is staged into a temporary table and merged in its own transaction, with a
+// checkpoint and the run's progress counts recorded on commit, so if the import fails it can be resumed by calling
+// ImportTicketData again with the same run ID. The run's state is left to the caller to finish.
+//
+// Ticket IDs are kept where they are free in the target guild, otherwise new IDs are allocated. The mappings are
+// recorded in import_mapping against the run, and only that run's mappings are used to attach participants, ratings
+// and so on, so importing data again as part of a new run creates new tickets. Panel IDs are translated using the panel
+// mappings from a previous guild config import, or from ImportFromSource if the data came from an ImportSource. Imported tickets are always closed.
+func (d *Database) ImportTicketData(ctx context.Context, guildId uint64, runId int, data TicketImportData) ([]ImportEntityResult, error) {
+	checkpoints, err := d.ImportCheckpoints.GetRun(ctx, guildId, runId)
+	if err != nil {
+		return nil, err
+	}
+
+	steps := buildImportSteps(data)
+
+	results := make([]ImportEntityResult, 0, len(steps))
+	for _, step := range steps {
+		if checkpoint, ok := checkpoints[step.entity]; ok {
+			results = append(results, ImportEntityResult{
+				Entity:   step.entity,
+				Staged:   len(step.rows),
+				Inserted: checkpoint.RowCount,
+				Resumed:  true,
+			})
+
+			continue
+		}
+
+		var inserted int
+		err := d.WithTx(ctx, func(tx pgx.Tx) error {
+			var err error
+			inserted, err = d.runImportStep(ctx, tx, guildId, runId, step)
+			if err != nil {
+				return err
+			}
+
+			if err := d.ImportLogs.AddRunCountsWithTx(ctx, tx, guildId, runId, inserted, len(step.rows)-inserted, 0); err != nil {
+				return err
+			}
+
+			return d.ImportCheckpoints.SetWithTx(ctx, tx, guildId, runId, step.entity, inserted)
+		})
+
+		if err != nil {
+			_ = d.ImportLogs.AddLog(ctx, guildId, runId, "DATA", "ENTITY_FAILED", step.entity, truncateLogMessage(err.Error()))
+			_ = d.ImportLogs.AddRunCounts(ctx, guildId, runId, 0, 0, 1)
+			return results, fmt.Errorf("failed to import %s: %w", step.entity, err)
+		}
+
+		result := ImportEntityResult{
+			Entity:   step.entity,
+			Staged:   len(step.rows),
+			Inserted: inserted,
+			Skipped:  len(step.rows) - inserted,
+		}
+
+		message := fmt.Sprintf("Imported %d of %d rows", result.Inserted, result.Staged)
+		if err := d.ImportLogs.AddLog(ctx, guildId, runId, "DATA", "ENTITY_COMPLETE", step.entity, message); err != nil {
+			return results, err
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+func (d *Database) runImportStep(ctx context.Context, tx pgx.Tx, guildId uint64, runId int, step importStep) (int, error) {
+	if _, err := tx.Exec(ctx, step.stage); err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{step.table}, step.columns, pgx.CopyFromRows(step.rows)); err != nil {
+		return 0, err
+	}
+
+	if step.entity == ImportEntityTickets {
+		if _, err := tx.Exec(ctx, importPipelineLockTicketIds, guildId); err != nil {
+			return 0, err
+		}
+
+		if _, err := tx.Exec(ctx, importPipelineMapTickets, guildId, runId); err != nil {
+			return 0, err
+		}
+	}
+
+	res, err := tx.Exec(ctx, step.insert, append([]interface{}{guildId, runId}, step.args...)...)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(res.RowsAffected()), nil
+}
+
+// Tables whose foreign key to tickets does not cascade, and so must be deleted from before the tickets themselves
+var importRollbackTables = []string{
+	"participant",
+	"ticket_members",
+	"service_ratings",
+	"ticket_claims",
+	"ticket_last_message",
+	"first_response_time",
+	"close_reason",
+	"close_request",
+	"auto_close_exclude",
+	"webhooks",
+	"exit_survey_responses",
+}
+
+// RollbackImportRun deletes the tickets imported by a run, along with any rows that reference them, and removes the
+// run's ID mappings and checkpoints. Only tickets whose mapping was created by the run are deleted. Tickets imported by
+// the legacy bulk import functions are not associated with a run, and so are not affected.
+func (d *Database) RollbackImportRun(ctx context.Context, guildId uint64, runId int) (int, error) {
+	var deleted int
+	err := d.WithTx(ctx, func(tx pgx.Tx) error {
+		var ticketIds []int
+		rows, err := tx.Query(ctx, importPipelineGetRunTickets, guildId, runId)
+		if err != nil {
+			return err
+		}
+
+		for rows.Next() {
+			var ticketId int
+			if err := rows.Scan(&ticketId); err != nil {
+				rows.Close()
+				return err
+			}
+
+			ticketIds = append(ticketIds, ticketId)
+		}
+
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		ticketIdArray := &pgtype.Int4Array{}
+		if err := ticketIdArray.Set(ticketIds); err != nil {
+			return err
+		}
+
+		for _, table := range importRollbackTables {
+			query := fmt.Sprintf(`DELETE FROM %s WHERE "guild_id" = $1 AND "ticket_id" = ANY($2);`, table)
+			if _, err := tx.Exec(ctx, query, guildId, ticketIdArray); err != nil {
+				return err
+			}
+		}
+
+		res, err := tx.Exec(ctx, `DELETE FROM tickets WHERE "guild_id" = $1 AND "id" = ANY($2);`, guildId, ticketIdArray)
+		if err != nil {
+			return err
+		}
+
+		deleted = int(res.RowsAffected())
+
+		if _, err := tx.Exec(ctx, importPipelineDeleteRunMappings, guildId, runId); err != nil {
+			return err
+		}
+
+		return d.ImportCheckpoints.DeleteRunWithTx(ctx, tx, guildId, runId)
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	message := fmt.Sprintf("Rolled back %d tickets", deleted)
+	if err := d.ImportLogs.AddLog(ctx, guildId, runId, "DATA", "RUN_ROLLBACK", ImportEntityTickets, message); err != nil {
+		return deleted, err
+	}
+
+	return deleted, nil
+}
+
+func buildImportSteps(data TicketImportData) []importStep {
+	tickets := make([][]interface{}, len(data.Tickets))
+	for i, ticket := range data.Tickets {
+		tickets[i] = []interface{}{
+			ticket.Id,
+			ticket.ChannelId,
+			ticket.UserId,
+			ticket.Open,
+			ticket.OpenTime,
+			ticket.WelcomeMessageId,
+			ticket.PanelId,
+			ticket.HasTranscript,
+			ticket.CloseTime,
+			ticket.IsThread,
+			ticket.JoinMessageId,
+			ticket.NotesThreadId,
+		}
+	}
+
+	userRows := func(users map[int][]uint64) [][]interface{} {
+		rows := make([][]interface{}, 0)
+		for ticketId, userIds := range users {
+			for _, userId := range userIds {
+				rows = append(rows, []interface{}{ticketId, userId})
+			}
+		}
+
+		return rows
+	}
+
+	ratings := make([][]interface{}, 0, len(data.Ratings))
+	for ticketId, rating := range data.Ratings {
+		ratings = append(ratings, []interface{}{ticketId, int16(rating)})
+	}
+
+	claims := make([][]interface{}, 0, len(data.Claims))
+	for ticketId, userId := range data.Claims {
+		claims = append(claims, []interface{}{ticketId, userId})
+	}
+
+	lastMessages := make([][]interface{}, 0, len(data.LastMessages))
+	for ticketId, msg := range data.LastMessages {
+		lastMessages = append(lastMessages, []interface{}{
+			ticketId,
+			msg.LastMessageId,
+			msg.LastMessageTime,
+			msg.UserId,
+			msg.UserIsStaff,
+		})
+	}
+
+	return []importStep{
+		{
+			entity:  ImportEntityTickets,
+			stage:   importPipelineStageTickets,
+			insert:  importPipelineInsertTickets,
+			table:   "import_staging_tickets",
+			columns: []string{"id", "channel_id", "user_id", "open", "open_time", "welcome_message_id", "panel_id", "has_transcript", "close_time", "is_thread", "join_message_id", "notes_thread_id"},
+			rows:    tickets,
+			args:    []interface{}{data.Source},
+		},
+		{
+			entity:  ImportEntityParticipants,
+			stage:   importPipelineStageParticipant,
+			insert:  importPipelineInsertParticipant,
+			table:   "import_staging_participant",
+			columns: []string{"ticket_id", "user_id"},
+			rows:    userRows(data.Participants),
+		},
+		{
+			entity:  ImportEntityTicketMembers,
+			stage:   importPipelineStageTicketMembers,
+			insert:  importPipelineInsertTicketMembers,
+			table:   "import_staging_ticket_members",
+			columns: []string{"ticket_id", "user_id"},
+			rows:    userRows(data.Members),
+		},
+		{
+			entity:  ImportEntityServiceRatings,
+			stage:   importPipelineStageServiceRatings,
+			insert:  importPipelineInsertServiceRatings,
+			table:   "import_staging_service_ratings",
+			columns: []string{"ticket_id", "rating"},
+			rows:    ratings,
+		},
+		{
+			entity:  ImportEntityTicketClaims,
+			stage:   importPipelineStageTicketClaims,
+			insert:  importPipelineInsertTicketClaims,
+			table:   "import_staging_ticket_claims",
+			columns: []string{"ticket_id", "user_id"},
+			rows:    claims,
+		},
+		{
+			entity:  ImportEntityTicketLastMessage,
+			stage:   importPipelineStageTicketLastMessage,
+			insert:  importPipelineInsertTicketLastMessage,
+			table:   "import_staging_ticket_last_message",
+			columns: []string{"ticket_id", "last_message_id", "last_message_time", "user_id", "user_is_staff"},
+			rows:    lastMessages,
+		},
+	}
+}
@@ -0,0 +1,264 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// ImportSource parses an export from another ticket bot. Rows that fail validation are returned as ImportRowErrors and
+// left out of the data, so that the rest of the export can still be imported. An error is only returned if the export
+// cannot be read at all.
+type ImportSource interface {
+	// Name identifies the source in import logs and import mappings, and must not be empty
+	Name() string
+	Parse(r io.Reader) (ImportSourceData, []ImportRowError, error)
+}
+
+// ImportSourceData holds entities with the IDs used by the source bot. Panels reference forms, and form inputs
+// reference forms, by source ID.
+type ImportSourceData struct {
+	Forms      []Form
+	FormInputs []FormInput
+	Panels     []Panel
+	Tickets    TicketImportData
+}
+
+type ImportRowError struct {
+	Entity  string `json:"entity"`
+	Row     int    `json:"row"` // Starts at 1, and excludes any header row
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+func (e ImportRowError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("row %d: %s", e.Row, e.Message)
+	}
+
+	return fmt.Sprintf("row %d: %s: %s", e.Row, e.Field, e.Message)
+}
+
+type ImportPreview struct {
+	Source        string           `json:"source"`
+	Forms         int              `json:"forms"`
+	FormInputs    int              `json:"form_inputs"`
+	Panels        int              `json:"panels"`
+	Tickets       int              `json:"tickets"`
+	Errors        []ImportRowError `json:"errors"`
+	SampleTickets []Ticket         `json:"sample_tickets"`
+}
+
+const importPreviewSampleSize = 10
+
+// PreviewImport parses an export without writing anything, so that the user can check the source has been interpreted
+// correctly before importing.
+func PreviewImport(source ImportSource, r io.Reader) (ImportPreview, error) {
+	data, rowErrors, err := source.Parse(r)
+	if err != nil {
+		return ImportPreview{}, err
+	}
+
+	return newImportPreview(source, data, rowErrors), nil
+}
+
+// ImportFromSource parses an export and imports it as part of the given run. Validation errors are written to the run's
+// logs, unless a previous attempt at the run has already started importing tickets, in which case they were logged by
+// that attempt. Forms and panels which have already been imported, according to import_mapping, are not created again,
+// and tickets are imported with ImportTicketData, so the import can be safely retried.
+func (d *Database) ImportFromSource(
+	ctx context.Context,
+	guildId uint64,
+	runId int,
+	source ImportSource,
+	r io.Reader,
+) (ImportPreview, []ImportEntityResult, error) {
+	data, rowErrors, err := source.Parse(r)
+	if err != nil {
+		return ImportPreview{}, nil, err
+	}
+
+	preview := newImportPreview(source, data, rowErrors)
+
+	checkpoints, err := d.ImportCheckpoints.GetRun(ctx, guildId, runId)
+	if err != nil {
+		return preview, nil, err
+	}
+
+	if _, retrying := checkpoints[ImportEntityTickets]; !retrying {
+		for _, rowError := range rowErrors {
+			if err := d.ImportLogs.AddLog(ctx, guildId, runId, "DATA", "VALIDATION_ERROR", rowError.Entity, truncateLogMessage(rowError.Error())); err != nil {
+				return preview, nil, err
+			}
+		}
+
+		if len(rowErrors) > 0 {
+			if err := d.ImportLogs.AddRunCounts(ctx, guildId, runId, 0, 0, len(rowErrors)); err != nil {
+				return preview, nil, err
+			}
+		}
+	}
+
+	if err := d.importSourceConfig(ctx, guildId, source.Name(), data); err != nil {
+		return preview, nil, err
+	}
+
+	message := fmt.Sprintf("Imported %d forms and %d panels from %s", len(data.Forms), len(data.Panels), source.Name())
+	if err := d.ImportLogs.AddLog(ctx, guildId, runId, "DATA", "ENTITY_COMPLETE", ImportMappingAreaPanel, message); err != nil {
+		return preview, nil, err
+	}
+
+	data.Tickets.Source = source.Name()
+	results, err := d.ImportTicketData(ctx, guildId, runId, data.Tickets)
+	return preview, results, err
+}
+
+// importSourceConfig creates the forms and panels of an export. Mappings are recorded against the source, as its IDs are
+// unrelated to those of a guild config import or of another source.
+func (d *Database) importSourceConfig(ctx context.Context, guildId uint64, sourceName string, data ImportSourceData) error {
+	existing, err := d.ImportMappingTable.GetSourceMapping(ctx, guildId, sourceName)
+	if err != nil {
+		return err
+	}
+
+	mapping := map[string]map[int]int{
+		ImportMappingAreaForm:      make(map[int]int),
+		ImportMappingAreaFormInput: make(map[int]int),
+		ImportMappingAreaPanel:     make(map[int]int),
+	}
+
+	for area := range mapping {
+		for sourceId, targetId := range existing[area] {
+			mapping[area][sourceId] = targetId
+		}
+	}
+
+	return d.WithTx(ctx, func(tx pgx.Tx) error {
+		newMappings := make(map[string]map[int]int)
+		setMapping := func(area string, sourceId, targetId int) {
+			mapping[area][sourceId] = targetId
+
+			if _, ok := newMappings[area]; !ok {
+				newMappings[area] = make(map[int]int)
+			}
+
+			newMappings[area][sourceId] = targetId
+		}
+
+		// Forms must be created before inputs and panels
+		for _, form := range data.Forms {
+			if _, ok := mapping[ImportMappingAreaForm][form.Id]; ok {
+				continue
+			}
+
+			formId, err := d.Forms.CreateWithTx(ctx, tx, guildId, form.Title, newCustomId())
+			if err != nil {
+				return err
+			}
+
+			setMapping(ImportMappingAreaForm, form.Id, formId)
+		}
+
+		for _, input := range data.FormInputs {
+			// Inputs of forms that were imported by a previous run are not added again, as the form may have been
+			// edited since
+			if _, ok := newMappings[ImportMappingAreaForm][input.FormId]; !ok {
+				continue
+			}
+
+			inputId, err := d.FormInput.CreateTx(
+				ctx,
+				tx,
+				mapping[ImportMappingAreaForm][input.FormId],
+				newCustomId(),
+				input.Position,
+				input.Style,
+				input.Label,
+				input.Placeholder,
+				input.Required,
+				input.MinLength,
+				input.MaxLength,
+			)
+			if err != nil {
+				return err
+			}
+
+			if input.Id != 0 {
+				setMapping(ImportMappingAreaFormInput, input.Id, inputId)
+			}
+		}
+
+		mapFormId := func(id *int) *int {
+			if id == nil {
+				return nil
+			}
+
+			if mapped, ok := mapping[ImportMappingAreaForm][*id]; ok {
+				return &mapped
+			}
+
+			return nil
+		}
+
+		for _, panel := range data.Panels {
+			if _, ok := mapping[ImportMappingAreaPanel][panel.PanelId]; ok {
+				continue
+			}
+
+			sourceId := panel.PanelId
+
+			panel.GuildId = guildId
+			panel.CustomId = newCustomId()
+			panel.FormId = mapFormId(panel.FormId)
+			panel.ExitSurveyFormId = mapFormId(panel.ExitSurveyFormId)
+			panel.WelcomeMessageEmbed = nil
+			panel.MessageId = 0 // The panel has not been sent in this guild yet
+
+			panelId, err := d.Panel.CreateWithTx(ctx, tx, panel)
+			if err != nil {
+				return err
+			}
+
+			setMapping(ImportMappingAreaPanel, sourceId, panelId)
+		}
+
+		for area, ids := range newMappings {
+			for sourceId, targetId := range ids {
+				if err := d.ImportMappingTable.SetSourceWithTx(ctx, tx, guildId, sourceName, area, sourceId, targetId); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+}
+
+func newImportPreview(source ImportSource, data ImportSourceData, rowErrors []ImportRowError) ImportPreview {
+	sample := data.Tickets.Tickets
+	if len(sample) > importPreviewSampleSize {
+		sample = sample[:importPreviewSampleSize]
+	}
+
+	return ImportPreview{
+		Source:        source.Name(),
+		Forms:         len(data.Forms),
+		FormInputs:    len(data.FormInputs),
+		Panels:        len(data.Panels),
+		Tickets:       len(data.Tickets.Tickets),
+		Errors:        rowErrors,
+		SampleTickets: sample,
+	}
+}
+
+// import_logs messages are limited to 255 characters
+func truncateLogMessage(message string) string {
+	runes := []rune(message)
+	if len(runes) > 255 {
+		return string(runes[:255])
+	}
+
+	return message
+}
@@ -0,0 +1,662 @@
+package database
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// JSONImportSource reads a JSON export of the form {"forms": [...], "panels": [...], "tickets": [...]}, where any of the
+// arrays may be omitted. Objects are read using the following keys, and exports which use other keys, including for the
+// top level arrays, can be read by mapping them with FieldAliases.
+//
+//	forms:   id, title, inputs
+//	inputs:  id, label, placeholder, style, required, min_length, max_length, position
+//	panels:  id, channel_id, category_id, title, content, colour, button_label, button_style, emoji_name, emoji_id,
+//	         form_id, naming_scheme
+//	tickets: id, user_id, channel_id, open_time, close_time, panel_id, has_transcript, is_thread, claimed_by,
+//	         participants, members, rating, last_message_id, last_message_time, last_message_user_id
+//
+// IDs and snowflakes may be numbers or strings. Lists of users may be arrays, or strings separated by commas,
+// semicolons or spaces.
+type JSONImportSource struct {
+	SourceName   string
+	FieldAliases map[string]string // Source key -> key listed above
+	TimeLayout   string            // Defaults to RFC 3339. Unix timestamps in seconds or milliseconds are always accepted.
+}
+
+// CSVTicketSource reads tickets from a CSV export with a header row. Columns are read using the same names as ticket
+// objects in JSONImportSource, and columns with other names can be mapped with FieldAliases.
+type CSVTicketSource struct {
+	SourceName   string
+	FieldAliases map[string]string
+	TimeLayout   string
+	Comma        rune // Defaults to ','
+}
+
+const (
+	importEntityForm      = "form"
+	importEntityFormInput = "form_input"
+	importEntityPanel     = "panel"
+	importEntityTicket    = "ticket"
+)
+
+var errImportFieldMissing = errors.New("field is required")
+
+func (s JSONImportSource) Name() string {
+	return s.SourceName
+}
+
+func (s JSONImportSource) Parse(r io.Reader) (ImportSourceData, []ImportRowError, error) {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+
+	var document map[string]interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return ImportSourceData{}, nil, err
+	}
+
+	document = applyImportFieldAliases(document, s.FieldAliases)
+
+	data := ImportSourceData{
+		Tickets: newTicketImportData(),
+	}
+
+	var rowErrors []ImportRowError
+
+	rows := func(key, entity string) ([]*importRow, error) {
+		value, ok := document[key]
+		if !ok || value == nil {
+			return nil, nil
+		}
+
+		array, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s must be an array", key)
+		}
+
+		parsed := make([]*importRow, 0, len(array))
+		for i, element := range array {
+			object, ok := element.(map[string]interface{})
+			if !ok {
+				rowErrors = append(rowErrors, ImportRowError{Entity: entity, Row: i + 1, Message: "expected an object"})
+				continue
+			}
+
+			parsed = append(parsed, newImportRow(entity, i+1, applyImportFieldAliases(object, s.FieldAliases), s.TimeLayout, &rowErrors))
+		}
+
+		return parsed, nil
+	}
+
+	forms, err := rows("forms", importEntityForm)
+	if err != nil {
+		return ImportSourceData{}, nil, err
+	}
+
+	formIds := make(map[int]struct{})
+	for _, row := range forms {
+		form, inputs := parseImportForm(row, s.FieldAliases, &rowErrors)
+		if row.failed {
+			continue
+		}
+
+		if _, ok := formIds[form.Id]; ok {
+			row.fail("id", "duplicate form ID")
+			continue
+		}
+
+		formIds[form.Id] = struct{}{}
+		data.Forms = append(data.Forms, form)
+		data.FormInputs = append(data.FormInputs, inputs...)
+	}
+
+	panels, err := rows("panels", importEntityPanel)
+	if err != nil {
+		return ImportSourceData{}, nil, err
+	}
+
+	panelIds := make(map[int]struct{})
+	for _, row := range panels {
+		panel := parseImportPanel(row)
+		if row.failed {
+			continue
+		}
+
+		if _, ok := panelIds[panel.PanelId]; ok {
+			row.fail("id", "duplicate panel ID")
+			continue
+		}
+
+		if panel.FormId != nil {
+			if _, ok := formIds[*panel.FormId]; !ok {
+				row.fail("form_id", "form is not in the export")
+				continue
+			}
+		}
+
+		panelIds[panel.PanelId] = struct{}{}
+		data.Panels = append(data.Panels, panel)
+	}
+
+	tickets, err := rows("tickets", importEntityTicket)
+	if err != nil {
+		return ImportSourceData{}, nil, err
+	}
+
+	ticketIds := make(map[int]struct{})
+	for _, row := range tickets {
+		parseImportTicket(row, &data.Tickets, ticketIds)
+	}
+
+	return data, rowErrors, nil
+}
+
+func (s CSVTicketSource) Name() string {
+	return s.SourceName
+}
+
+func (s CSVTicketSource) Parse(r io.Reader) (ImportSourceData, []ImportRowError, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	if s.Comma != 0 {
+		reader.Comma = s.Comma
+	}
+
+	header, err := reader.Read()
+	if err != nil {
+		return ImportSourceData{}, nil, err
+	}
+
+	for i, column := range header {
+		column = strings.TrimSpace(column)
+		if alias, ok := s.FieldAliases[column]; ok {
+			column = alias
+		}
+
+		header[i] = column
+	}
+
+	data := ImportSourceData{
+		Tickets: newTicketImportData(),
+	}
+
+	var rowErrors []ImportRowError
+
+	ticketIds := make(map[int]struct{})
+	for rowNumber := 1; ; rowNumber++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				rowErrors = append(rowErrors, ImportRowError{Entity: importEntityTicket, Row: rowNumber, Message: parseErr.Err.Error()})
+				continue
+			}
+
+			return ImportSourceData{}, nil, err
+		}
+
+		values := make(map[string]interface{}, len(header))
+		for i, column := range header {
+			if i < len(record) {
+				values[column] = record[i]
+			}
+		}
+
+		parseImportTicket(newImportRow(importEntityTicket, rowNumber, values, s.TimeLayout, &rowErrors), &data.Tickets, ticketIds)
+	}
+
+	return data, rowErrors, nil
+}
+
+func parseImportForm(row *importRow, aliases map[string]string, rowErrors *[]ImportRowError) (Form, []FormInput) {
+	form := Form{
+		Id:    row.requiredId("id"),
+		Title: row.requiredString("title"),
+	}
+
+	if len(form.Title) > 45 {
+		row.fail("title", "must be at most 45 characters")
+	}
+
+	value, ok := row.values["inputs"]
+	if !ok || value == nil {
+		return form, nil
+	}
+
+	array, ok := value.([]interface{})
+	if !ok {
+		row.fail("inputs", "must be an array")
+		return form, nil
+	}
+
+	if len(array) > 5 {
+		row.fail("inputs", "forms can have at most 5 inputs")
+		return form, nil
+	}
+
+	// Inputs are reported against the form's row, as they have no row of their own
+	inputs := make([]FormInput, 0, len(array))
+	for i, element := range array {
+		object, ok := element.(map[string]interface{})
+		if !ok {
+			row.fail("inputs", "expected an object")
+			continue
+		}
+
+		inputRow := newImportRow(importEntityFormInput, row.row, applyImportFieldAliases(object, aliases), row.timeLayout, rowErrors)
+
+		input := FormInput{
+			FormId:      form.Id,
+			Position:    i + 1,
+			Style:       1,
+			Label:       inputRow.requiredString("label"),
+			Placeholder: inputRow.string("placeholder"),
+			Required:    inputRow.bool("required"),
+			MinLength:   inputRow.uint16("min_length"),
+			MaxLength:   inputRow.uint16("max_length"),
+		}
+
+		// Input IDs are optional, as they are only used to record the mapping
+		if inputRow.has("id") {
+			input.Id = inputRow.requiredId("id")
+		}
+
+		if position := inputRow.int("position"); position != nil {
+			input.Position = *position
+		}
+
+		if style := inputRow.int("style"); style != nil {
+			if *style != 1 && *style != 2 {
+				inputRow.fail("style", "must be 1 (short) or 2 (paragraph)")
+			}
+
+			input.Style = uint8(*style)
+		}
+
+		if len(input.Label) > 45 {
+			inputRow.fail("label", "must be at most 45 characters")
+		}
+
+		if input.MinLength != nil && input.MaxLength != nil && *input.MinLength > *input.MaxLength {
+			inputRow.fail("min_length", "must not be greater than max_length")
+		}
+
+		if inputRow.failed {
+			row.failed = true
+			continue
+		}
+
+		inputs = append(inputs, input)
+	}
+
+	return form, inputs
+}
+
+func parseImportPanel(row *importRow) Panel {
+	panel := Panel{
+		PanelId:        row.requiredId("id"),
+		ChannelId:      row.requiredSnowflake("channel_id"),
+		TargetCategory: row.requiredSnowflake("category_id"),
+		Title:          row.requiredString("title"),
+		ButtonStyle:    1,
+		EmojiName:      row.string("emoji_name"),
+		EmojiId:        row.snowflake("emoji_id"),
+		FormId:         row.int("form_id"),
+		NamingScheme:   row.string("naming_scheme"),
+	}
+
+	if content := row.string("content"); content != nil {
+		panel.Content = *content
+	}
+
+	if colour := row.int("colour"); colour != nil {
+		if *colour < 0 || *colour > 0xFFFFFF {
+			row.fail("colour", "must be between 0 and 0xFFFFFF")
+		}
+
+		panel.Colour = int32(*colour)
+	}
+
+	panel.ButtonLabel = panel.Title
+	if label := row.string("button_label"); label != nil {
+		panel.ButtonLabel = *label
+	}
+
+	if style := row.int("button_style"); style != nil {
+		if *style < 1 || *style > 4 {
+			row.fail("button_style", "must be between 1 and 4")
+		}
+
+		panel.ButtonStyle = *style
+	}
+
+	if len(panel.Title) > 255 {
+		row.fail("title", "must be at most 255 characters")
+	}
+
+	if len(panel.ButtonLabel) > 80 {
+		row.fail("button_label", "must be at most 80 characters")
+	}
+
+	return panel
+}
+
+func parseImportTicket(row *importRow, data *TicketImportData, seen map[int]struct{}) {
+	ticket := Ticket{
+		Id:            row.requiredId("id"),
+		UserId:        row.requiredSnowflake("user_id"),
+		ChannelId:     row.snowflake("channel_id"),
+		PanelId:       row.int("panel_id"),
+		HasTranscript: row.bool("has_transcript"),
+		IsThread:      row.bool("is_thread"),
+		CloseTime:     row.time("close_time"),
+	}
+
+	if openTime := row.time("open_time"); openTime != nil {
+		ticket.OpenTime = *openTime
+	} else if !row.has("open_time") {
+		row.fail("open_time", errImportFieldMissing.Error())
+	}
+
+	if _, ok := seen[ticket.Id]; ok {
+		row.fail("id", "duplicate ticket ID")
+	}
+
+	if ticket.CloseTime != nil && ticket.CloseTime.Before(ticket.OpenTime) {
+		row.fail("close_time", "must not be before open_time")
+	}
+
+	claimedBy := row.snowflake("claimed_by")
+	participants := row.snowflakes("participants")
+	members := row.snowflakes("members")
+
+	rating := row.int("rating")
+	if rating != nil && (*rating < 1 || *rating > 5) {
+		row.fail("rating", "must be between 1 and 5")
+	}
+
+	lastMessage := TicketLastMessage{
+		LastMessageId:   row.snowflake("last_message_id"),
+		LastMessageTime: row.time("last_message_time"),
+		UserId:          row.snowflake("last_message_user_id"),
+	}
+
+	if row.failed {
+		return
+	}
+
+	seen[ticket.Id] = struct{}{}
+	data.Tickets = append(data.Tickets, ticket)
+
+	if claimedBy != nil {
+		data.Claims[ticket.Id] = *claimedBy
+	}
+
+	if len(participants) > 0 {
+		data.Participants[ticket.Id] = participants
+	}
+
+	if len(members) > 0 {
+		data.Members[ticket.Id] = members
+	}
+
+	if rating != nil {
+		data.Ratings[ticket.Id] = uint8(*rating)
+	}
+
+	if lastMessage.LastMessageId != nil || lastMessage.LastMessageTime != nil {
+		data.LastMessages[ticket.Id] = lastMessage
+	}
+}
+
+func newTicketImportData() TicketImportData {
+	return TicketImportData{
+		Participants: make(map[int][]uint64),
+		Members:      make(map[int][]uint64),
+		Ratings:      make(map[int]uint8),
+		Claims:       make(map[int]uint64),
+		LastMessages: make(map[int]TicketLastMessage),
+	}
+}
+
+func applyImportFieldAliases(values map[string]interface{}, aliases map[string]string) map[string]interface{} {
+	if len(aliases) == 0 {
+		return values
+	}
+
+	aliased := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		if alias, ok := aliases[key]; ok {
+			key = alias
+		}
+
+		aliased[key] = value
+	}
+
+	return aliased
+}
+
+// importRow reads fields from a row of an export, recording an ImportRowError for each invalid field.
+type importRow struct {
+	entity     string
+	row        int
+	values     map[string]interface{}
+	timeLayout string
+	errors     *[]ImportRowError
+	failed     bool
+}
+
+func newImportRow(entity string, row int, values map[string]interface{}, timeLayout string, rowErrors *[]ImportRowError) *importRow {
+	if timeLayout == "" {
+		timeLayout = time.RFC3339
+	}
+
+	return &importRow{
+		entity:     entity,
+		row:        row,
+		values:     values,
+		timeLayout: timeLayout,
+		errors:     rowErrors,
+	}
+}
+
+func (r *importRow) fail(field, message string) {
+	r.failed = true
+	*r.errors = append(*r.errors, ImportRowError{
+		Entity:  r.entity,
+		Row:     r.row,
+		Field:   field,
+		Message: message,
+	})
+}
+
+// has returns false if the field is missing, null or an empty string
+func (r *importRow) has(field string) bool {
+	_, ok := r.raw(field)
+	return ok
+}
+
+func (r *importRow) raw(field string) (string, bool) {
+	switch value := r.values[field].(type) {
+	case nil:
+		return "", false
+	case string:
+		value = strings.TrimSpace(value)
+		return value, value != ""
+	case bool:
+		return strconv.FormatBool(value), true
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), true
+	case fmt.Stringer: // json.Number
+		return value.String(), true
+	default:
+		return "", false
+	}
+}
+
+func (r *importRow) string(field string) *string {
+	if value, ok := r.raw(field); ok {
+		return &value
+	}
+
+	return nil
+}
+
+func (r *importRow) requiredString(field string) string {
+	value, ok := r.raw(field)
+	if !ok {
+		r.fail(field, errImportFieldMissing.Error())
+	}
+
+	return value
+}
+
+func (r *importRow) int(field string) *int {
+	raw, ok := r.raw(field)
+	if !ok {
+		return nil
+	}
+
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		r.fail(field, "must be an integer")
+		return nil
+	}
+
+	return &value
+}
+
+func (r *importRow) requiredId(field string) int {
+	value := r.int(field)
+	if value == nil {
+		if !r.has(field) {
+			r.fail(field, errImportFieldMissing.Error())
+		}
+
+		return 0
+	}
+
+	if *value <= 0 {
+		r.fail(field, "must be positive")
+		return 0
+	}
+
+	return *value
+}
+
+func (r *importRow) uint16(field string) *uint16 {
+	value := r.int(field)
+	if value == nil {
+		return nil
+	}
+
+	if *value < 0 || *value > 4000 {
+		r.fail(field, "must be between 0 and 4000")
+		return nil
+	}
+
+	return ptr(uint16(*value))
+}
+
+func (r *importRow) bool(field string) bool {
+	raw, ok := r.raw(field)
+	if !ok {
+		return false
+	}
+
+	value, err := strconv.ParseBool(strings.ToLower(raw))
+	if err != nil {
+		r.fail(field, "must be true or false")
+	}
+
+	return value
+}
+
+func (r *importRow) snowflake(field string) *uint64 {
+	raw, ok := r.raw(field)
+	if !ok {
+		return nil
+	}
+
+	value, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		r.fail(field, "must be a Discord ID")
+		return nil
+	}
+
+	return &value
+}
+
+func (r *importRow) requiredSnowflake(field string) uint64 {
+	value := r.snowflake(field)
+	if value == nil {
+		if !r.has(field) {
+			r.fail(field, errImportFieldMissing.Error())
+		}
+
+		return 0
+	}
+
+	return *value
+}
+
+func (r *importRow) snowflakes(field string) []uint64 {
+	var raw []string
+	switch value := r.values[field].(type) {
+	case []interface{}:
+		for _, element := range value {
+			raw = append(raw, fmt.Sprint(element))
+		}
+	default:
+		if joined, ok := r.raw(field); ok {
+			raw = strings.FieldsFunc(joined, func(c rune) bool {
+				return c == ',' || c == ';' || c == ' '
+			})
+		}
+	}
+
+	snowflakes := make([]uint64, 0, len(raw))
+	for _, id := range raw {
+		value, err := strconv.ParseUint(strings.TrimSpace(id), 10, 64)
+		if err != nil {
+			r.fail(field, "must only contain Discord IDs")
+			return nil
+		}
+
+		snowflakes = append(snowflakes, value)
+	}
+
+	return snowflakes
+}
+
+// time accepts timestamps in the row's layout, or Unix timestamps in seconds or milliseconds
+func (r *importRow) time(field string) *time.Time {
+	raw, ok := r.raw(field)
+	if !ok {
+		return nil
+	}
+
+	if unix, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		if unix > 1e12 {
+			return ptr(time.UnixMilli(unix).UTC())
+		}
+
+		return ptr(time.Unix(unix, 0).UTC())
+	}
+
+	value, err := time.Parse(r.timeLayout, raw)
+	if err != nil {
+		r.fail(field, fmt.Sprintf("must be a timestamp in the format %s", r.timeLayout))
+		return nil
+	}
+
+	return &value
+}
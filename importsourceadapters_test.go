@@ -0,0 +1,130 @@
+package database
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCSVTicketSourceParse(t *testing.T) {
+	source := CSVTicketSource{
+		SourceName:   "test",
+		FieldAliases: map[string]string{"ticket": "id"},
+	}
+
+	input := `ticket,user_id,open_time,close_time,claimed_by,participants,rating
+1,123,2024-01-01T00:00:00Z,2024-01-02T00:00:00Z,456,"789;790",5
+2,123,1704067200,,,,
+3,abc,2024-01-01T00:00:00Z,,,,
+1,123,2024-01-01T00:00:00Z,,,,
+4,123,2024-01-02T00:00:00Z,2024-01-01T00:00:00Z,,,
+5,123,2024-01-01T00:00:00Z,,,,6
+`
+
+	data, rowErrors, err := source.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	if len(data.Tickets.Tickets) != 2 {
+		t.Fatalf("expected 2 valid tickets, got %d", len(data.Tickets.Tickets))
+	}
+
+	first := data.Tickets.Tickets[0]
+	if first.Id != 1 || first.UserId != 123 || first.CloseTime == nil {
+		t.Errorf("first ticket parsed incorrectly: %+v", first)
+	}
+
+	if claimer := data.Tickets.Claims[1]; claimer != 456 {
+		t.Errorf("expected ticket 1 to be claimed by 456, got %d", claimer)
+	}
+
+	if participants := data.Tickets.Participants[1]; len(participants) != 2 || participants[0] != 789 || participants[1] != 790 {
+		t.Errorf("unexpected participants: %v", participants)
+	}
+
+	if rating := data.Tickets.Ratings[1]; rating != 5 {
+		t.Errorf("expected rating 5, got %d", rating)
+	}
+
+	if openTime := data.Tickets.Tickets[1].OpenTime; !openTime.Equal(time.Unix(1704067200, 0)) {
+		t.Errorf("expected unix open time to be parsed, got %s", openTime)
+	}
+
+	expected := []ImportRowError{
+		{Entity: importEntityTicket, Row: 3, Field: "user_id", Message: "must be a Discord ID"},
+		{Entity: importEntityTicket, Row: 4, Field: "id", Message: "duplicate ticket ID"},
+		{Entity: importEntityTicket, Row: 5, Field: "close_time", Message: "must not be before open_time"},
+		{Entity: importEntityTicket, Row: 6, Field: "rating", Message: "must be between 1 and 5"},
+	}
+
+	if len(rowErrors) != len(expected) {
+		t.Fatalf("expected %d row errors, got %d: %v", len(expected), len(rowErrors), rowErrors)
+	}
+
+	for i, rowError := range rowErrors {
+		if rowError != expected[i] {
+			t.Errorf("row error %d: expected %+v, got %+v", i, expected[i], rowError)
+		}
+	}
+}
+
+func TestJSONImportSourceParse(t *testing.T) {
+	source := JSONImportSource{
+		SourceName:   "test",
+		FieldAliases: map[string]string{"ticket_list": "tickets"},
+	}
+
+	input := `{
+	"forms": [{"id": 1, "title": "Form"}],
+	"panels": [
+		{"id": 1, "channel_id": "100", "category_id": "200", "title": "Panel", "form_id": 1},
+		{"id": 2, "channel_id": "100", "category_id": "200", "title": "Panel", "form_id": 2}
+	],
+	"ticket_list": [
+		{"id": "1", "user_id": "123", "open_time": "2024-01-01T00:00:00Z", "members": ["1", "2"], "is_thread": true},
+		{"id": 2, "open_time": "2024-01-01T00:00:00Z"},
+		"not an object"
+	]
+}`
+
+	data, rowErrors, err := source.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	if len(data.Forms) != 1 || len(data.Panels) != 1 || len(data.Tickets.Tickets) != 1 {
+		t.Fatalf("unexpected counts: %d forms, %d panels, %d tickets", len(data.Forms), len(data.Panels), len(data.Tickets.Tickets))
+	}
+
+	if ticket := data.Tickets.Tickets[0]; !ticket.IsThread {
+		t.Errorf("expected ticket to be a thread")
+	}
+
+	if members := data.Tickets.Members[1]; len(members) != 2 {
+		t.Errorf("expected 2 members, got %v", members)
+	}
+
+	expected := []ImportRowError{
+		{Entity: importEntityPanel, Row: 2, Field: "form_id", Message: "form is not in the export"},
+		// Elements that are not objects are reported before any fields are read
+		{Entity: importEntityTicket, Row: 3, Message: "expected an object"},
+		{Entity: importEntityTicket, Row: 2, Field: "user_id", Message: errImportFieldMissing.Error()},
+	}
+
+	if len(rowErrors) != len(expected) {
+		t.Fatalf("expected %d row errors, got %d: %v", len(expected), len(rowErrors), rowErrors)
+	}
+
+	for i, rowError := range rowErrors {
+		if rowError != expected[i] {
+			t.Errorf("row error %d: expected %+v, got %+v", i, expected[i], rowError)
+		}
+	}
+}
+
+func TestJSONImportSourceRejectsInvalidDocument(t *testing.T) {
+	if _, _, err := (JSONImportSource{}).Parse(strings.NewReader(`{"tickets": {}}`)); err == nil {
+		t.Errorf("expected an error when tickets is not an array")
+	}
+}
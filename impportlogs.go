@@ -3,9 +3,10 @@ package database
 import (
 	"context"
 	_ "embed"
-	"strings"
+	"errors"
 	"time"
 
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
@@ -31,6 +32,29 @@ type ImportLogs struct {
 	Date       time.Time `json:"date"`
 }
 
+type ImportRunState string
+
+const (
+	ImportRunStateRunning   ImportRunState = "running"
+	ImportRunStateSucceeded ImportRunState = "succeeded"
+	ImportRunStateFailed    ImportRunState = "failed"
+	ImportRunStateCancelled ImportRunState = "cancelled"
+)
+
+// ImportRunStatus is the live progress of a run. Runs started before statuses were recorded will only have a status if
+// they have been logged to since, in which case they are reported as running.
+type ImportRunStatus struct {
+	RunId        int            `json:"run_id"`
+	RunType      string         `json:"run_type"`
+	State        ImportRunState `json:"state"`
+	StartedAt    time.Time      `json:"started_at"`
+	FinishedAt   *time.Time     `json:"finished_at"`
+	LogCount     int            `json:"log_count"`
+	RowsImported int            `json:"rows_imported"`
+	RowsSkipped  int            `json:"rows_skipped"`
+	ErrorCount   int            `json:"error_count"`
+}
+
 var (
 	//go:embed sql/import_logs/schema.sql
 	importLogsSchema string
@@ -40,6 +64,24 @@ var (
 
 	//go:embed sql/import_logs/set_run.sql
 	importLogsSetRun string
+
+	//go:embed sql/import_logs/next_run_id.sql
+	importLogsNextRunId string
+
+	//go:embed sql/import_logs/create_run.sql
+	importLogsCreateRun string
+
+	//go:embed sql/import_logs/finish_run.sql
+	importLogsFinishRun string
+
+	//go:embed sql/import_logs/add_run_counts.sql
+	importLogsAddRunCounts string
+
+	//go:embed sql/import_logs/get_run_status.sql
+	importLogsGetRunStatus string
+
+	//go:embed sql/import_logs/get_latest_run_status.sql
+	importLogsGetLatestRunStatus string
 )
 
 func newImportLogs(db *pgxpool.Pool) *ImportLogsTable {
@@ -106,28 +148,87 @@ func (s *ImportLogsTable) GetRunLogs(ctx context.Context, guildId uint64, runId
 }
 
 func (s *ImportLogsTable) CreateRun(ctx context.Context, guildId uint64, runType string) (int, error) {
-	runCount := 1
-	currentRuns, _ := s.GetRuns(ctx, guildId)
+	tx, err := s.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	var runId int
+	if err := tx.QueryRow(ctx, importLogsNextRunId, guildId).Scan(&runId); err != nil {
+		return 0, err
+	}
 
-	runCount += len(currentRuns)
+	if _, err := tx.Exec(ctx, importLogsCreateRun, guildId, runId, runType); err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.Exec(ctx, importLogsSetRun, guildId, "RUN_START", runId, runType); err != nil {
+		return 0, err
+	}
 
-	_, err := s.Exec(ctx, importLogsSetRun, guildId, "RUN_START", runCount, runType)
-	return runCount, err
+	return runId, tx.Commit(ctx)
 }
 
 func (s *ImportLogsTable) AddLog(ctx context.Context, guildId uint64, runId int, runType string, logType string, entityType string, message string) error {
-	return s.addLog(ctx, guildId, runId, runType, logType, entityType, message, 1)
+	_, err := s.Exec(ctx, importLogsSet, guildId, logType, runId, runType, entityType, message)
+	return err
 }
 
-func (s *ImportLogsTable) addLog(ctx context.Context, guildId uint64, runId int, runType string, logType string, entityType string, message string, try int) error {
-	_, err := s.Exec(ctx, importLogsSet, guildId, logType, runId, runType, entityType, message)
-	if err != nil && strings.Contains(err.Error(), "duplicate key value violates unique constraint") {
-		// Try again
-		if try > 5 {
-			return err
-		}
+// FinishRun moves a running run to its final state, returning false if the run does not exist or has already finished.
+func (s *ImportLogsTable) FinishRun(ctx context.Context, guildId uint64, runId int, state ImportRunState) (bool, error) {
+	if state == ImportRunStateRunning {
+		return false, errors.New("cannot finish a run in the running state")
+	}
 
-		return s.addLog(ctx, guildId, runId, runType, logType, entityType, message, try+1)
+	res, err := s.Exec(ctx, importLogsFinishRun, guildId, runId, state)
+	if err != nil {
+		return false, err
 	}
+
+	return res.RowsAffected() > 0, nil
+}
+
+// AddRunCounts increments the progress counters of a run.
+func (s *ImportLogsTable) AddRunCounts(ctx context.Context, guildId uint64, runId int, imported, skipped, errorCount int) error {
+	_, err := s.Exec(ctx, importLogsAddRunCounts, guildId, runId, imported, skipped, errorCount)
+	return err
+}
+
+func (s *ImportLogsTable) AddRunCountsWithTx(ctx context.Context, tx pgx.Tx, guildId uint64, runId int, imported, skipped, errorCount int) error {
+	_, err := tx.Exec(ctx, importLogsAddRunCounts, guildId, runId, imported, skipped, errorCount)
 	return err
 }
+
+func (s *ImportLogsTable) GetRunStatus(ctx context.Context, guildId uint64, runId int) (ImportRunStatus, bool, error) {
+	return scanImportRunStatus(s.QueryRow(ctx, importLogsGetRunStatus, guildId, runId))
+}
+
+// GetLatestRunStatus returns the status of the most recently created run for the guild.
+func (s *ImportLogsTable) GetLatestRunStatus(ctx context.Context, guildId uint64) (ImportRunStatus, bool, error) {
+	return scanImportRunStatus(s.QueryRow(ctx, importLogsGetLatestRunStatus, guildId))
+}
+
+func scanImportRunStatus(row pgx.Row) (ImportRunStatus, bool, error) {
+	var status ImportRunStatus
+	if err := row.Scan(
+		&status.RunId,
+		&status.RunType,
+		&status.State,
+		&status.StartedAt,
+		&status.FinishedAt,
+		&status.LogCount,
+		&status.RowsImported,
+		&status.RowsSkipped,
+		&status.ErrorCount,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ImportRunStatus{}, false, nil
+		}
+
+		return ImportRunStatus{}, false, err
+	}
+
+	return status, true, nil
+}
@@ -0,0 +1,187 @@
+package database
+
+import (
+	"context"
+	_ "embed"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// IntegrationExecutions logs each request made to a custom integration on behalf of a guild, so that integration owners
+// and guild admins can debug failures.
+type IntegrationExecutions struct {
+	*pgxpool.Pool
+}
+
+// IntegrationExecutionRetention is how long executions should be kept for before being purged
+const IntegrationExecutionRetention = time.Hour * 24 * 14
+
+type IntegrationExecution struct {
+	Id            int64         `json:"id,string"`
+	IntegrationId int           `json:"integration_id"`
+	GuildId       uint64        `json:"guild_id,string"`
+	TicketId      *int          `json:"ticket_id"`
+	StatusCode    *int          `json:"status_code"` // Nil if no response was received
+	Latency       time.Duration `json:"latency"`
+	Response      *string       `json:"response"` // Truncated to 4096 characters
+	Error         *string       `json:"error"`    // Truncated to 1024 characters
+	ExecutedAt    time.Time     `json:"executed_at"`
+}
+
+// IntegrationExecutionStats is an aggregate of an integration's executions. An execution failed if no response was
+// received, an error was recorded, or the status code was not 2xx.
+type IntegrationExecutionStats struct {
+	IntegrationId  int           `json:"integration_id"`
+	Executions     int           `json:"executions"`
+	Failures       int           `json:"failures"`
+	ErrorRate      float64       `json:"error_rate"` // Between 0 and 1
+	AverageLatency time.Duration `json:"average_latency"`
+	LastFailureAt  *time.Time    `json:"last_failure_at"`
+}
+
+var (
+	//go:embed sql/integration_executions/schema.sql
+	integrationExecutionsSchema string
+
+	//go:embed sql/integration_executions/record.sql
+	integrationExecutionsRecord string
+
+	//go:embed sql/integration_executions/get_by_owner.sql
+	integrationExecutionsGetByOwner string
+
+	//go:embed sql/integration_executions/get_by_guild.sql
+	integrationExecutionsGetByGuild string
+
+	//go:embed sql/integration_executions/get_stats_by_owner.sql
+	integrationExecutionsGetStatsByOwner string
+
+	//go:embed sql/integration_executions/get_stats_by_guild.sql
+	integrationExecutionsGetStatsByGuild string
+
+	//go:embed sql/integration_executions/purge.sql
+	integrationExecutionsPurge string
+)
+
+func newIntegrationExecutions(db *pgxpool.Pool) *IntegrationExecutions {
+	return &IntegrationExecutions{
+		db,
+	}
+}
+
+func (IntegrationExecutions) Schema() string {
+	return integrationExecutionsSchema
+}
+
+// Record logs the execution, truncating the response and error, and returns the execution with its ID and time set
+func (e *IntegrationExecutions) Record(ctx context.Context, execution IntegrationExecution) (IntegrationExecution, error) {
+	if err := e.QueryRow(ctx, integrationExecutionsRecord,
+		execution.IntegrationId,
+		execution.GuildId,
+		execution.TicketId,
+		execution.StatusCode,
+		execution.Latency.Milliseconds(),
+		execution.Response,
+		execution.Error,
+	).Scan(&execution.Id, &execution.ExecutedAt); err != nil {
+		return IntegrationExecution{}, err
+	}
+
+	return execution, nil
+}
+
+// GetByOwner returns the executions of the user's integrations across all guilds, newest first. integrationId
+// optionally filters by integration, and before is the ID of the last execution of the previous page, if any.
+func (e *IntegrationExecutions) GetByOwner(ctx context.Context, ownerId uint64, integrationId *int, before *int64, limit int) ([]IntegrationExecution, error) {
+	return e.getExecutions(ctx, integrationExecutionsGetByOwner, ownerId, limit, integrationId, before)
+}
+
+// GetByGuild returns the executions made on behalf of the guild by integrations that are still active in it, newest
+// first. integrationId optionally filters by integration, and before is the ID of the last execution of the previous
+// page, if any.
+func (e *IntegrationExecutions) GetByGuild(ctx context.Context, guildId uint64, integrationId *int, before *int64, limit int) ([]IntegrationExecution, error) {
+	return e.getExecutions(ctx, integrationExecutionsGetByGuild, guildId, limit, integrationId, before)
+}
+
+func (e *IntegrationExecutions) getExecutions(ctx context.Context, query string, args ...interface{}) ([]IntegrationExecution, error) {
+	rows, err := e.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var executions []IntegrationExecution
+	for rows.Next() {
+		var execution IntegrationExecution
+		var latencyMs int
+		if err := rows.Scan(
+			&execution.Id,
+			&execution.IntegrationId,
+			&execution.GuildId,
+			&execution.TicketId,
+			&execution.StatusCode,
+			&latencyMs,
+			&execution.Response,
+			&execution.Error,
+			&execution.ExecutedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		execution.Latency = time.Duration(latencyMs) * time.Millisecond
+		executions = append(executions, execution)
+	}
+
+	return executions, rows.Err()
+}
+
+// GetStatsByOwner returns aggregates for each of the user's integrations with executions since the given time, across
+// all guilds
+func (e *IntegrationExecutions) GetStatsByOwner(ctx context.Context, ownerId uint64, since time.Time) ([]IntegrationExecutionStats, error) {
+	return e.getStats(ctx, integrationExecutionsGetStatsByOwner, ownerId, since)
+}
+
+// GetStatsByGuild returns aggregates for each integration active in the guild with executions since the given time,
+// only counting executions made on behalf of the guild
+func (e *IntegrationExecutions) GetStatsByGuild(ctx context.Context, guildId uint64, since time.Time) ([]IntegrationExecutionStats, error) {
+	return e.getStats(ctx, integrationExecutionsGetStatsByGuild, guildId, since)
+}
+
+func (e *IntegrationExecutions) getStats(ctx context.Context, query string, args ...interface{}) ([]IntegrationExecutionStats, error) {
+	rows, err := e.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var stats []IntegrationExecutionStats
+	for rows.Next() {
+		var s IntegrationExecutionStats
+		var averageLatencyMs int
+		if err := rows.Scan(&s.IntegrationId, &s.Executions, &s.Failures, &averageLatencyMs, &s.LastFailureAt); err != nil {
+			return nil, err
+		}
+
+		s.AverageLatency = time.Duration(averageLatencyMs) * time.Millisecond
+		if s.Executions > 0 {
+			s.ErrorRate = float64(s.Failures) / float64(s.Executions)
+		}
+
+		stats = append(stats, s)
+	}
+
+	return stats, rows.Err()
+}
+
+// Purge deletes up to limit executions older than olderThan, usually IntegrationExecutionRetention, and returns the
+// number deleted. It should be called repeatedly until fewer than limit are deleted.
+func (e *IntegrationExecutions) Purge(ctx context.Context, olderThan time.Duration, limit int) (int64, error) {
+	res, err := e.Exec(ctx, integrationExecutionsPurge, olderThan, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected(), nil
+}
@@ -68,16 +68,26 @@ func (g *LegacyPremiumEntitlementGuilds) ListForUser(ctx context.Context, tx pgx
 }
 
 func (g *LegacyPremiumEntitlementGuilds) Insert(ctx context.Context, tx pgx.Tx, userId, guildId uint64, entitlementId uuid.UUID) error {
-	_, err := tx.Exec(ctx, legacyPremiumEntitlementGuildsInsert, userId, guildId, entitlementId)
-	return err
+	if _, err := tx.Exec(ctx, legacyPremiumEntitlementGuildsInsert, userId, guildId, entitlementId); err != nil {
+		return err
+	}
+
+	return publishEntitlementEventById(ctx, tx, OutboxEventEntitlementUpdated, entitlementId)
 }
 
 func (g *LegacyPremiumEntitlementGuilds) Delete(ctx context.Context, tx pgx.Tx, userId, guildId uint64) error {
-	_, err := tx.Exec(ctx, legacyPremiumEntitlementGuildsDelete, userId, guildId)
-	return err
+	return deleteEntitlementLinks(ctx, tx, legacyPremiumEntitlementGuildsDelete, userId, guildId)
 }
 
 func (g *LegacyPremiumEntitlementGuilds) DeleteByEntitlement(ctx context.Context, tx pgx.Tx, entitlementId uuid.UUID) error {
-	_, err := tx.Exec(ctx, legacyPremiumEntitlementGuildsDeleteByEntitlement, entitlementId)
-	return err
+	res, err := tx.Exec(ctx, legacyPremiumEntitlementGuildsDeleteByEntitlement, entitlementId)
+	if err != nil {
+		return err
+	}
+
+	if res.RowsAffected() == 0 {
+		return nil
+	}
+
+	return publishEntitlementEventById(ctx, tx, OutboxEventEntitlementUpdated, entitlementId)
 }
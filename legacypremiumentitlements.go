@@ -4,6 +4,7 @@ import (
 	"context"
 	_ "embed"
 	"errors"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
@@ -115,20 +116,53 @@ func (e *LegacyPremiumEntitlements) GetUserTier(ctx context.Context, userId uint
 	return &entitlement, nil
 }
 
+// SetEntitlement upserts the user's legacy entitlement, so always publishes an update event
 func (e *LegacyPremiumEntitlements) SetEntitlement(ctx context.Context, tx pgx.Tx, entitlement LegacyPremiumEntitlement) error {
-	_, err := tx.Exec(ctx, legacyPremiumEntitlementsSet,
+	if _, err := tx.Exec(ctx, legacyPremiumEntitlementsSet,
 		entitlement.UserId,
 		entitlement.TierId,
 		entitlement.SkuLabel,
 		entitlement.SkuId,
 		entitlement.IsLegacy,
 		entitlement.ExpiresAt,
-	)
+	); err != nil {
+		return err
+	}
 
-	return err
+	return publishLegacyEntitlementEvent(ctx, tx, OutboxEventEntitlementUpdated, entitlement.UserId, entitlement.SkuId, entitlement.ExpiresAt)
 }
 
 func (e *LegacyPremiumEntitlements) Delete(ctx context.Context, tx pgx.Tx, userId uint64) error {
-	_, err := tx.Exec(ctx, legacyPremiumEntitlementsDelete, userId)
-	return err
+	var skuId uuid.UUID
+	var expiresAt time.Time
+	if err := tx.QueryRow(ctx, legacyPremiumEntitlementsDelete, userId).Scan(&userId, &skuId, &expiresAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+
+		return err
+	}
+
+	return publishLegacyEntitlementEvent(ctx, tx, OutboxEventEntitlementDeleted, userId, skuId, expiresAt)
+}
+
+// publishLegacyEntitlementEvent publishes an event for a legacy entitlement, which has no entitlement ID of its own, so
+// is identified by the user ID instead
+func publishLegacyEntitlementEvent(
+	ctx context.Context,
+	tx pgx.Tx,
+	eventType OutboxEventType,
+	userId uint64,
+	skuId uuid.UUID,
+	expiresAt time.Time,
+) error {
+	payload := EntitlementEventPayload{
+		Id:        "legacy:" + strconv.FormatUint(userId, 10),
+		UserId:    &userId,
+		SkuId:     skuId.String(),
+		Source:    "legacy",
+		ExpiresAt: &expiresAt,
+	}
+
+	return publishOutboxEvent(ctx, tx, OutboxAggregateEntitlement, payload.Id, nil, eventType, payload)
 }
@@ -10,7 +10,7 @@ import (
 
 type MultiPanel struct {
 	Id                    int                    `json:"id"`
-	MessageId             uint64                 `json:"message_id,string"`
+	MessageId             uint64                 `json:"message_id,string"` // 0 if the multi-panel has not been sent yet
 	ChannelId             uint64                 `json:"channel_id,string"`
 	GuildId               uint64                 `json:"guild_id,string"`
 	SelectMenu            bool                   `json:"select_menu"`
@@ -32,7 +32,7 @@ func (MultiPanelTable) Schema() string {
 	return `
 CREATE TABLE IF NOT EXISTS multi_panels(
 	"id" SERIAL NOT NULL,
-	"message_id" int8 NOT NULL,
+	"message_id" int8,
 	"channel_id" int8 NOT NULL,
 	"guild_id" int8 NOT NULL,
 	"select_menu" bool DEFAULT 'f',
@@ -41,13 +41,14 @@ CREATE TABLE IF NOT EXISTS multi_panels(
 	PRIMARY KEY("id")
 );
 CREATE INDEX IF NOT EXISTS multi_panels_guild_id ON multi_panels("guild_id");
-CREATE INDEX IF NOT EXISTS multi_panels_message_id ON multi_panels("message_id");`
+CREATE INDEX IF NOT EXISTS multi_panels_message_id ON multi_panels("message_id");
+ALTER TABLE multi_panels ALTER COLUMN "message_id" DROP NOT NULL;`
 }
 
 func (p *MultiPanelTable) Get(ctx context.Context, id int) (MultiPanel, bool, error) {
 	query := `
 SELECT
-	"id", "message_id", "channel_id", "guild_id", "select_menu", "select_menu_placeholder", "embed"
+	"id", COALESCE("message_id", 0), "channel_id", "guild_id", "select_menu", "select_menu_placeholder", "embed"
 FROM
 	multi_panels
 WHERE
@@ -80,7 +81,7 @@ WHERE
 func (p *MultiPanelTable) GetByMessageId(ctx context.Context, messageId uint64) (MultiPanel, bool, error) {
 	query := `
 SELECT
-	"id", "message_id", "channel_id", "guild_id", "select_menu", "select_menu_placeholder", "embed"
+	"id", COALESCE("message_id", 0), "channel_id", "guild_id", "select_menu", "select_menu_placeholder", "embed"
 FROM
 	multi_panels
 WHERE
@@ -112,7 +113,7 @@ WHERE
 
 func (p *MultiPanelTable) GetByGuild(ctx context.Context, guildId uint64) ([]MultiPanel, error) {
 	query := `
-SELECT "id", "message_id", "channel_id", "guild_id", "select_menu", "select_menu_placeholder", "embed"
+SELECT "id", COALESCE("message_id", 0), "channel_id", "guild_id", "select_menu", "select_menu_placeholder", "embed"
 FROM multi_panels
 WHERE "guild_id" = $1;
 `
@@ -148,11 +149,31 @@ WHERE "guild_id" = $1;
 }
 
 func (p *MultiPanelTable) Create(ctx context.Context, panel MultiPanel) (int, error) {
+	tx, err := p.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	multiPanelId, err := p.CreateWithTx(ctx, tx, panel)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+
+	return multiPanelId, nil
+}
+
+func (p *MultiPanelTable) CreateWithTx(ctx context.Context, tx pgx.Tx, panel MultiPanel) (int, error) {
 	query := `
 INSERT INTO
 	multi_panels("message_id", "channel_id", "guild_id", "select_menu", "select_menu_placeholder", "embed")
 VALUES
-	($1, $2, $3, $4, $5, $6)
+	(NULLIF($1::int8, 0), $2, $3, $4, $5, $6)
 RETURNING
 	"id"
 ;
@@ -169,7 +190,7 @@ RETURNING
 	}
 
 	var multiPanelId int
-	if err := p.QueryRow(ctx, query,
+	if err := tx.QueryRow(ctx, query,
 		panel.MessageId, panel.ChannelId, panel.GuildId, panel.SelectMenu, panel.SelectMenuPlaceholder, embedRaw,
 	).Scan(&multiPanelId); err != nil {
 		return 0, err
@@ -181,7 +202,7 @@ RETURNING
 func (p *MultiPanelTable) Update(ctx context.Context, multiPanelId int, multiPanel MultiPanel) (err error) {
 	query := `
 UPDATE multi_panels
-	SET "message_id" = $2,
+	SET "message_id" = NULLIF($2::int8, 0),
 		"channel_id" = $3,
 		"select_menu" = $4,
 		"select_menu_placeholder" = $5,
@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
@@ -33,7 +34,7 @@ func (p *MultiPanelTargets) GetPanels(ctx context.Context, multiPanelId int) (pa
 	query := `
 SELECT
 	panels.panel_id,
-	panels.message_id,
+	COALESCE(panels.message_id, 0),
 	panels.channel_id,
 	panels.guild_id,
 	panels.title,
@@ -83,7 +84,7 @@ func (p *MultiPanelTargets) GetMultiPanels(ctx context.Context, panelId int) ([]
 	query := `
 SELECT
 	multi_panels.id,
-	multi_panels.message_id,
+	COALESCE(multi_panels.message_id, 0),
 	multi_panels.channel_id,
 	multi_panels.guild_id,
 	multi_panels.select_menu,
@@ -132,13 +133,28 @@ WHERE multi_panel_targets.panel_id = $1;
 }
 
 func (p *MultiPanelTargets) Insert(ctx context.Context, multiPanelId, panelId int) (err error) {
+	tx, err := p.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	if err := p.InsertWithTx(ctx, tx, multiPanelId, panelId); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (p *MultiPanelTargets) InsertWithTx(ctx context.Context, tx pgx.Tx, multiPanelId, panelId int) (err error) {
 	query := `
 INSERT INTO multi_panel_targets("multi_panel_id", "panel_id")
 VALUES ($1, $2) 
 ON CONFLICT("multi_panel_id", "panel_id") DO NOTHING;
 `
 
-	_, err = p.Exec(ctx, query, multiPanelId, panelId)
+	_, err = tx.Exec(ctx, query, multiPanelId, panelId)
 	return
 }
 
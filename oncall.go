@@ -2,11 +2,16 @@ package database
 
 import (
 	"context"
+	_ "embed"
+	"fmt"
+	"strings"
+	"time"
 
-	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
+// OnCall holds the manual on call toggles. Users are also on call when scheduled by OnCallRotations, unless they
+// manually toggle off during their shift.
 type OnCall struct {
 	*pgxpool.Pool
 }
@@ -17,35 +22,56 @@ func newOnCall(db *pgxpool.Pool) *OnCall {
 	}
 }
 
+var (
+	// Formatted with the rotation resolver query
+	//
+	//go:embed sql/on_call_rotations/get_users_on_call.sql
+	onCallGetUsersOnCall string
+
+	// Formatted with the rotation resolver query
+	//
+	//go:embed sql/on_call_rotations/is_on_call.sql
+	onCallIsOnCall string
+)
+
 func (b OnCall) Schema() string {
 	return `
 CREATE TABLE IF NOT EXISTS on_call(
 	"guild_id" int8 NOT NULL,
 	"user_id" int8 NOT NULL,
 	"is_on_call" bool NOT NULL,
+	"updated_at" timestamptz NOT NULL DEFAULT NOW(),
 	PRIMARY KEY("guild_id", "user_id")
-);`
+);
+ALTER TABLE on_call ADD COLUMN IF NOT EXISTS "updated_at" timestamptz NOT NULL DEFAULT NOW();`
 }
 
-func (b *OnCall) IsOnCall(ctx context.Context, guildId, userId uint64) (bool, error) {
-	query := `SELECT "is_on_call" FROM on_call WHERE "guild_id" = $1 AND "user_id" = $2;`
+// IsOnCall combines the user's manual toggle with the on call rotation schedules.
+func (b *OnCall) IsOnCall(ctx context.Context, guildId, userId uint64) (onCall bool, err error) {
+	query := fmt.Sprintf(onCallIsOnCall, onCallResolverQuery())
+	err = b.QueryRow(ctx, query, guildId, time.Now(), userId).Scan(&onCall)
+	return
+}
 
-	var onCall bool
-	if err := b.QueryRow(ctx, query, guildId, userId).Scan(&onCall); err != nil {
-		if err == pgx.ErrNoRows {
-			return false, nil
-		} else {
-			return false, err
-		}
-	}
+// GetUsersOnCall returns the users who are currently on call, either from a manual toggle or any of the guild's on call
+// rotation schedules, including team rotations.
+func (b *OnCall) GetUsersOnCall(ctx context.Context, guildId uint64) ([]uint64, error) {
+	return b.GetUsersOnCallAt(ctx, guildId, time.Now())
+}
 
-	return onCall, nil
+func (b *OnCall) GetUsersOnCallAt(ctx context.Context, guildId uint64, at time.Time) ([]uint64, error) {
+	return b.getUsersOnCall(ctx, guildId, nil, at)
 }
 
-func (b *OnCall) GetUsersOnCall(ctx context.Context, guildId uint64) ([]uint64, error) {
-	query := `SELECT "user_id" FROM on_call WHERE "guild_id" = $1 AND "is_on_call" = true;`
+// GetUsersOnCallForTeam is the same as GetUsersOnCall, but ignores the rotations of other teams.
+func (b *OnCall) GetUsersOnCallForTeam(ctx context.Context, guildId uint64, teamId int) ([]uint64, error) {
+	return b.getUsersOnCall(ctx, guildId, &teamId, time.Now())
+}
+
+func (b *OnCall) getUsersOnCall(ctx context.Context, guildId uint64, teamId *int, at time.Time) ([]uint64, error) {
+	query := fmt.Sprintf(onCallGetUsersOnCall, onCallResolverQuery())
 
-	rows, err := b.Query(ctx, query, guildId)
+	rows, err := b.Query(ctx, query, guildId, at, teamId)
 	defer rows.Close()
 	if err != nil {
 		return nil, err
@@ -71,16 +97,41 @@ func (b *OnCall) GetOnCallCount(ctx context.Context, guildId uint64) (count int,
 	return
 }
 
+// Toggle switches whether the user is on call, taking the on call rotation schedules into account, so that a user who is
+// scheduled is taken off call.
 func (b *OnCall) Toggle(ctx context.Context, guildId, userId uint64) (onCall bool, err error) {
+	tx, err := b.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	var current bool
+	if err := tx.QueryRow(ctx, fmt.Sprintf(onCallIsOnCall, onCallResolverQuery()), guildId, time.Now(), userId).Scan(&current); err != nil {
+		return false, err
+	}
+
 	query := `
-INSERT INTO on_call("guild_id", "user_id", "is_on_call") 
-VALUES($1, $2, true)
-ON CONFLICT ("guild_id", "user_id") 
-DO UPDATE SET "is_on_call" = NOT on_call.is_on_call
-RETURNING "is_on_call";`
+INSERT INTO on_call("guild_id", "user_id", "is_on_call", "updated_at")
+VALUES($1, $2, $3, NOW())
+ON CONFLICT ("guild_id", "user_id")
+DO UPDATE SET "is_on_call" = $3, "updated_at" = NOW();`
 
-	err = b.QueryRow(ctx, query, guildId, userId).Scan(&onCall)
-	return
+	if _, err := tx.Exec(ctx, query, guildId, userId, !current); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, err
+	}
+
+	return !current, nil
+}
+
+// The resolver query without its trailing semicolon, so that it can be used as a CTE
+func onCallResolverQuery() string {
+	return strings.TrimSuffix(strings.TrimSpace(onCallRotationsResolve), ";")
 }
 
 func (b *OnCall) Remove(ctx context.Context, guildId, userId uint64) (err error) {
@@ -0,0 +1,311 @@
+package database
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// OnCallRotations schedules members of a guild or support team to be on call in turn, e.g. a weekly rotation. The
+// schedule is combined with the manual toggles by OnCall.GetUsersOnCall. Members of a team rotation are only on call
+// for that team, see OnCall.GetUsersOnCallForTeam.
+type OnCallRotations struct {
+	*pgxpool.Pool
+}
+
+type OnCallRotation struct {
+	Id          int           `json:"id"`
+	GuildId     uint64        `json:"guild_id,string"`
+	TeamId      *int          `json:"team_id"` // Nil for guild-wide rotations
+	Name        string        `json:"name"`
+	StartTime   time.Time     `json:"start_time"`   // When the first shift starts, and so the time of each handoff
+	ShiftLength time.Duration `json:"shift_length"` // e.g. 7 days for a weekly rotation
+}
+
+type OnCallOverride struct {
+	Id       int       `json:"id"`
+	UserId   uint64    `json:"user_id,string"`
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+}
+
+// ScheduledOnCall is the member of a rotation who is on call at a point in time
+type ScheduledOnCall struct {
+	RotationId int       `json:"rotation_id"`
+	TeamId     *int      `json:"team_id"`
+	UserId     uint64    `json:"user_id,string"`
+	IsOverride bool      `json:"is_override"`
+	ShiftStart time.Time `json:"shift_start"`
+	ShiftEnd   time.Time `json:"shift_end"`
+}
+
+var (
+	//go:embed sql/on_call_rotations/schema.sql
+	onCallRotationsSchema string
+
+	//go:embed sql/on_call_rotations/get_by_guild.sql
+	onCallRotationsGetByGuild string
+
+	//go:embed sql/on_call_rotations/create.sql
+	onCallRotationsCreate string
+
+	//go:embed sql/on_call_rotations/update.sql
+	onCallRotationsUpdate string
+
+	//go:embed sql/on_call_rotations/delete.sql
+	onCallRotationsDelete string
+
+	//go:embed sql/on_call_rotations/get_members.sql
+	onCallRotationsGetMembers string
+
+	//go:embed sql/on_call_rotations/delete_members.sql
+	onCallRotationsDeleteMembers string
+
+	//go:embed sql/on_call_rotations/insert_member.sql
+	onCallRotationsInsertMember string
+
+	//go:embed sql/on_call_rotations/get_overrides.sql
+	onCallRotationsGetOverrides string
+
+	//go:embed sql/on_call_rotations/create_override.sql
+	onCallRotationsCreateOverride string
+
+	//go:embed sql/on_call_rotations/delete_override.sql
+	onCallRotationsDeleteOverride string
+
+	//go:embed sql/on_call_rotations/resolve.sql
+	onCallRotationsResolve string
+)
+
+var ErrOnCallRotationNotFound = errors.New("on call rotation not found")
+
+func newOnCallRotations(db *pgxpool.Pool) *OnCallRotations {
+	return &OnCallRotations{
+		db,
+	}
+}
+
+func (OnCallRotations) Schema() string {
+	return onCallRotationsSchema
+}
+
+func (r *OnCallRotations) GetByGuild(ctx context.Context, guildId uint64) ([]OnCallRotation, error) {
+	rows, err := r.Query(ctx, onCallRotationsGetByGuild, guildId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var rotations []OnCallRotation
+	for rows.Next() {
+		rotation := OnCallRotation{
+			GuildId: guildId,
+		}
+
+		if err := rows.Scan(
+			&rotation.Id,
+			&rotation.TeamId,
+			&rotation.Name,
+			&rotation.StartTime,
+			&rotation.ShiftLength,
+		); err != nil {
+			return nil, err
+		}
+
+		rotations = append(rotations, rotation)
+	}
+
+	return rotations, rows.Err()
+}
+
+// Create returns ErrSupportTeamNotFound if the rotation's team does not belong to the guild.
+func (r *OnCallRotations) Create(ctx context.Context, rotation OnCallRotation) (id int, err error) {
+	shiftLength, err := toInterval(rotation.ShiftLength)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := r.QueryRow(ctx, onCallRotationsCreate,
+		rotation.GuildId,
+		rotation.TeamId,
+		rotation.Name,
+		rotation.StartTime,
+		shiftLength,
+	).Scan(&id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrSupportTeamNotFound
+		}
+
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// Update returns ErrSupportTeamNotFound if the rotation's team does not belong to the guild.
+func (r *OnCallRotations) Update(ctx context.Context, rotation OnCallRotation) error {
+	shiftLength, err := toInterval(rotation.ShiftLength)
+	if err != nil {
+		return err
+	}
+
+	var validTeam bool
+	if err := r.QueryRow(ctx, onCallRotationsUpdate,
+		rotation.GuildId,
+		rotation.Id,
+		rotation.TeamId,
+		rotation.Name,
+		rotation.StartTime,
+		shiftLength,
+	).Scan(&validTeam); err != nil {
+		return err
+	}
+
+	if !validTeam {
+		return ErrSupportTeamNotFound
+	}
+
+	return nil
+}
+
+func (r *OnCallRotations) Delete(ctx context.Context, guildId uint64, id int) (err error) {
+	_, err = r.Exec(ctx, onCallRotationsDelete, guildId, id)
+	return
+}
+
+// GetMembers returns the members of the rotation, in the order that they are on call
+func (r *OnCallRotations) GetMembers(ctx context.Context, guildId uint64, rotationId int) ([]uint64, error) {
+	rows, err := r.Query(ctx, onCallRotationsGetMembers, guildId, rotationId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var members []uint64
+	for rows.Next() {
+		var userId uint64
+		if err := rows.Scan(&userId); err != nil {
+			return nil, err
+		}
+
+		members = append(members, userId)
+	}
+
+	return members, rows.Err()
+}
+
+func (r *OnCallRotations) SetMembers(ctx context.Context, guildId uint64, rotationId int, members []uint64) error {
+	tx, err := r.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	if err := r.SetMembersWithTx(ctx, tx, guildId, rotationId, members); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// SetMembersWithTx replaces the members of the rotation. Changing the members changes who is on call for the current
+// and all future shifts. Returns ErrOnCallRotationNotFound if members are given and the rotation does not belong to
+// the guild.
+func (r *OnCallRotations) SetMembersWithTx(ctx context.Context, tx pgx.Tx, guildId uint64, rotationId int, members []uint64) error {
+	// Remove existing members
+	if _, err := tx.Exec(ctx, onCallRotationsDeleteMembers, guildId, rotationId); err != nil {
+		return err
+	}
+
+	// Add each member
+	for position, userId := range members {
+		res, err := tx.Exec(ctx, onCallRotationsInsertMember, guildId, rotationId, userId, position)
+		if err != nil {
+			return err
+		}
+
+		if res.RowsAffected() == 0 {
+			return ErrOnCallRotationNotFound
+		}
+	}
+
+	return nil
+}
+
+// GetOverrides returns the rotation's overrides that overlap the period between from and to
+func (r *OnCallRotations) GetOverrides(ctx context.Context, guildId uint64, rotationId int, from, to time.Time) ([]OnCallOverride, error) {
+	rows, err := r.Query(ctx, onCallRotationsGetOverrides, guildId, rotationId, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var overrides []OnCallOverride
+	for rows.Next() {
+		var override OnCallOverride
+		if err := rows.Scan(&override.Id, &override.UserId, &override.StartsAt, &override.EndsAt); err != nil {
+			return nil, err
+		}
+
+		overrides = append(overrides, override)
+	}
+
+	return overrides, rows.Err()
+}
+
+// CreateOverride puts the user on call in place of the rotation's scheduled member between the override's start and
+// end. If overrides overlap, the most recently created applies. Returns ErrOnCallRotationNotFound if the rotation does
+// not belong to the guild.
+func (r *OnCallRotations) CreateOverride(ctx context.Context, guildId uint64, rotationId int, override OnCallOverride) (id int, err error) {
+	if err := r.QueryRow(ctx, onCallRotationsCreateOverride,
+		guildId,
+		rotationId,
+		override.UserId,
+		override.StartsAt,
+		override.EndsAt,
+	).Scan(&id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrOnCallRotationNotFound
+		}
+
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (r *OnCallRotations) DeleteOverride(ctx context.Context, guildId uint64, id int) (err error) {
+	_, err = r.Exec(ctx, onCallRotationsDeleteOverride, guildId, id)
+	return
+}
+
+// GetScheduledOnCall returns who is on call for each of the guild's rotations at the given time, ignoring manual
+// toggles. Rotations without any members and without an active override are omitted.
+func (r *OnCallRotations) GetScheduledOnCall(ctx context.Context, guildId uint64, at time.Time) ([]ScheduledOnCall, error) {
+	rows, err := r.Query(ctx, onCallRotationsResolve, guildId, at)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var scheduled []ScheduledOnCall
+	for rows.Next() {
+		var s ScheduledOnCall
+		if err := rows.Scan(&s.RotationId, &s.TeamId, &s.UserId, &s.IsOverride, &s.ShiftStart, &s.ShiftEnd); err != nil {
+			return nil, err
+		}
+
+		scheduled = append(scheduled, s)
+	}
+
+	return scheduled, rows.Err()
+}
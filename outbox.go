@@ -0,0 +1,321 @@
+package database
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	jsoniter "github.com/json-iterator/go"
+)
+
+// OutboxTable stores change-data events that are written in the same transaction as the domain change that caused
+// them. Events are delivered at-least-once by a relay (see the outbox package), so consumers must be idempotent.
+type OutboxTable struct {
+	*pgxpool.Pool
+}
+
+// ErrOutboxLeaseLost is returned when an event is no longer leased by the caller, e.g. because the lease expired and
+// the event was claimed by another relay.
+var ErrOutboxLeaseLost = errors.New("outbox lease lost")
+
+type OutboxAggregateType string
+
+const (
+	OutboxAggregateTicket      OutboxAggregateType = "ticket"
+	OutboxAggregatePanel       OutboxAggregateType = "panel"
+	OutboxAggregateEntitlement OutboxAggregateType = "entitlement"
+)
+
+type OutboxEventType string
+
+const (
+	OutboxEventTicketOpened       OutboxEventType = "ticket.opened"
+	OutboxEventTicketClosed       OutboxEventType = "ticket.closed"
+	OutboxEventTicketReopened     OutboxEventType = "ticket.reopened"
+	OutboxEventPanelCreated       OutboxEventType = "panel.created"
+	OutboxEventPanelUpdated       OutboxEventType = "panel.updated"
+	OutboxEventPanelDeleted       OutboxEventType = "panel.deleted"
+	OutboxEventEntitlementCreated OutboxEventType = "entitlement.created"
+	OutboxEventEntitlementUpdated OutboxEventType = "entitlement.updated"
+	OutboxEventEntitlementDeleted OutboxEventType = "entitlement.deleted"
+)
+
+type OutboxEvent struct {
+	Id            int64               `json:"id"`
+	AggregateType OutboxAggregateType `json:"aggregate_type"`
+	AggregateId   string              `json:"aggregate_id"`
+	GuildId       *uint64             `json:"guild_id,string,omitempty"`
+	EventType     OutboxEventType     `json:"event_type"`
+	Payload       jsoniter.RawMessage `json:"payload"`
+	CreatedAt     time.Time           `json:"created_at"`
+	Attempts      int                 `json:"attempts"`
+	LeaseId       uuid.UUID           `json:"-"`
+}
+
+type TicketEventPayload struct {
+	GuildId  uint64 `json:"guild_id,string"`
+	TicketId int    `json:"ticket_id"`
+	UserId   uint64 `json:"user_id,string"`
+	PanelId  *int   `json:"panel_id"`
+}
+
+type PanelEventPayload struct {
+	GuildId uint64 `json:"guild_id,string"`
+	PanelId int    `json:"panel_id"`
+	Panel   *Panel `json:"panel,omitempty"` // Null on delete
+}
+
+type EntitlementEventPayload struct {
+	Id        string     `json:"id"`
+	GuildId   *uint64    `json:"guild_id,string"`
+	UserId    *uint64    `json:"user_id,string"`
+	SkuId     string     `json:"sku_id"`
+	Source    string     `json:"source"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+var (
+	//go:embed sql/outbox/schema.sql
+	outboxSchema string
+
+	//go:embed sql/outbox/insert.sql
+	outboxInsert string
+
+	//go:embed sql/outbox/claim_batch.sql
+	outboxClaimBatch string
+
+	//go:embed sql/outbox/renew_lease.sql
+	outboxRenewLease string
+
+	//go:embed sql/outbox/mark_delivered.sql
+	outboxMarkDelivered string
+
+	//go:embed sql/outbox/mark_failed.sql
+	outboxMarkFailed string
+
+	//go:embed sql/outbox/mark_dead.sql
+	outboxMarkDead string
+
+	//go:embed sql/outbox/purge_delivered.sql
+	outboxPurgeDelivered string
+
+	//go:embed sql/outbox/get_pending_count.sql
+	outboxGetPendingCount string
+)
+
+func newOutboxTable(db *pgxpool.Pool) *OutboxTable {
+	return &OutboxTable{
+		db,
+	}
+}
+
+func (OutboxTable) Schema() string {
+	return outboxSchema
+}
+
+// NewOutboxEvent serialises the payload and builds an event ready to be published.
+func NewOutboxEvent(aggregateType OutboxAggregateType, aggregateId string, guildId *uint64, eventType OutboxEventType, payload any) (OutboxEvent, error) {
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return OutboxEvent{}, err
+	}
+
+	return OutboxEvent{
+		AggregateType: aggregateType,
+		AggregateId:   aggregateId,
+		GuildId:       guildId,
+		EventType:     eventType,
+		Payload:       encoded,
+	}, nil
+}
+
+// Publish writes an event outside any domain transaction. Prefer PublishWithTx where a domain change is being made.
+func (o *OutboxTable) Publish(ctx context.Context, event OutboxEvent) (OutboxEvent, error) {
+	tx, err := o.Begin(ctx)
+	if err != nil {
+		return OutboxEvent{}, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	event, err = o.PublishWithTx(ctx, tx, event)
+	if err != nil {
+		return OutboxEvent{}, err
+	}
+
+	return event, tx.Commit(ctx)
+}
+
+func (o *OutboxTable) PublishWithTx(ctx context.Context, tx pgx.Tx, event OutboxEvent) (OutboxEvent, error) {
+	return insertOutboxEvent(ctx, tx, event)
+}
+
+func insertOutboxEvent(ctx context.Context, tx pgx.Tx, event OutboxEvent) (OutboxEvent, error) {
+	payload := string(event.Payload)
+	if len(event.Payload) == 0 {
+		payload = "{}"
+	}
+
+	if err := tx.QueryRow(ctx, outboxInsert,
+		event.AggregateType,
+		event.AggregateId,
+		event.GuildId,
+		event.EventType,
+		payload,
+	).Scan(&event.Id, &event.CreatedAt); err != nil {
+		return OutboxEvent{}, err
+	}
+
+	return event, nil
+}
+
+// ClaimBatch leases up to limit undelivered events for the given duration. All events in the batch share a lease ID,
+// which must be passed to RenewLease and the Mark functions. Events whose lease expires without being marked as
+// delivered or failed, e.g. because the relay crashed, become claimable again.
+func (o *OutboxTable) ClaimBatch(ctx context.Context, limit int, lease time.Duration) ([]OutboxEvent, error) {
+	leaseId := uuid.New()
+
+	rows, err := o.Query(ctx, outboxClaimBatch, limit, lease, leaseId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var events []OutboxEvent
+	for rows.Next() {
+		var event OutboxEvent
+		var payload string
+		if err := rows.Scan(
+			&event.Id,
+			&event.AggregateType,
+			&event.AggregateId,
+			&event.GuildId,
+			&event.EventType,
+			&payload,
+			&event.CreatedAt,
+			&event.Attempts,
+		); err != nil {
+			return nil, err
+		}
+
+		event.Payload = jsoniter.RawMessage(payload)
+		event.LeaseId = leaseId
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// UPDATE ... RETURNING does not preserve the order of the CTE
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Id < events[j].Id
+	})
+
+	return events, nil
+}
+
+// RenewLease extends the lease on all events claimed under leaseId that are still outstanding, returning the number
+// of events renewed. Events whose lease has already expired are not renewed, as they may have been claimed by another
+// relay.
+func (o *OutboxTable) RenewLease(ctx context.Context, leaseId uuid.UUID, lease time.Duration) (int64, error) {
+	res, err := o.Exec(ctx, outboxRenewLease, leaseId, lease)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected(), nil
+}
+
+// MarkDelivered returns ErrOutboxLeaseLost if any of the events are no longer leased under leaseId. Events that are
+// still leased are marked as delivered regardless.
+func (o *OutboxTable) MarkDelivered(ctx context.Context, leaseId uuid.UUID, ids []int64) error {
+	idArray := &pgtype.Int8Array{}
+	if err := idArray.Set(ids); err != nil {
+		return err
+	}
+
+	res, err := o.Exec(ctx, outboxMarkDelivered, idArray, leaseId)
+	if err != nil {
+		return err
+	}
+
+	if res.RowsAffected() < int64(len(ids)) {
+		return ErrOutboxLeaseLost
+	}
+
+	return nil
+}
+
+// MarkFailed releases the lease on an event, making it available again after retryAfter.
+func (o *OutboxTable) MarkFailed(ctx context.Context, leaseId uuid.UUID, id int64, retryAfter time.Duration, errorMessage string) error {
+	res, err := o.Exec(ctx, outboxMarkFailed, id, retryAfter, errorMessage, leaseId)
+	if err != nil {
+		return err
+	}
+
+	if res.RowsAffected() == 0 {
+		return ErrOutboxLeaseLost
+	}
+
+	return nil
+}
+
+// MarkDead stops any further delivery attempts for an event.
+func (o *OutboxTable) MarkDead(ctx context.Context, leaseId uuid.UUID, id int64, errorMessage string) error {
+	res, err := o.Exec(ctx, outboxMarkDead, id, errorMessage, leaseId)
+	if err != nil {
+		return err
+	}
+
+	if res.RowsAffected() == 0 {
+		return ErrOutboxLeaseLost
+	}
+
+	return nil
+}
+
+func (o *OutboxTable) PurgeDelivered(ctx context.Context, olderThan time.Duration) (int64, error) {
+	res, err := o.Exec(ctx, outboxPurgeDelivered, olderThan)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected(), nil
+}
+
+func (o *OutboxTable) GetPendingCount(ctx context.Context) (count int, err error) {
+	err = o.QueryRow(ctx, outboxGetPendingCount).Scan(&count)
+	return
+}
+
+// publishOutboxEvent is used by other tables to record an event as part of their own transaction.
+func publishOutboxEvent(
+	ctx context.Context,
+	tx pgx.Tx,
+	aggregateType OutboxAggregateType,
+	aggregateId string,
+	guildId *uint64,
+	eventType OutboxEventType,
+	payload any,
+) error {
+	event, err := NewOutboxEvent(aggregateType, aggregateId, guildId, eventType, payload)
+	if err != nil {
+		return err
+	}
+
+	_, err = insertOutboxEvent(ctx, tx, event)
+	return err
+}
+
+func publishTicketEvent(ctx context.Context, tx pgx.Tx, eventType OutboxEventType, payload TicketEventPayload) error {
+	return publishOutboxEvent(ctx, tx, OutboxAggregateTicket, strconv.Itoa(payload.TicketId), &payload.GuildId, eventType, payload)
+}
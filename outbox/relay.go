@@ -0,0 +1,159 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	database "github.com/jadevelopmentgrp/Tickets-Database"
+	"github.com/sirupsen/logrus"
+)
+
+type Config struct {
+	BatchSize     int
+	LeaseDuration time.Duration
+	PollInterval  time.Duration
+	MaxAttempts   int
+	Backoff       BackoffFunc
+}
+
+func DefaultConfig() Config {
+	return Config{
+		BatchSize:     100,
+		LeaseDuration: time.Minute,
+		PollInterval:  time.Second,
+		MaxAttempts:   10,
+		Backoff:       ExponentialBackoff(time.Second, time.Hour),
+	}
+}
+
+// BackoffFunc returns how long to wait before retrying an event that has failed attempt times.
+type BackoffFunc func(attempt int) time.Duration
+
+func ExponentialBackoff(base, max time.Duration) BackoffFunc {
+	return func(attempt int) time.Duration {
+		delay := base
+		for i := 1; i < attempt; i++ {
+			delay *= 2
+			if delay >= max {
+				return max
+			}
+		}
+
+		return delay
+	}
+}
+
+type Relay struct {
+	table  *database.OutboxTable
+	sinks  []Sink
+	config Config
+}
+
+func NewRelay(table *database.OutboxTable, config Config, sinks ...Sink) *Relay {
+	return &Relay{
+		table:  table,
+		sinks:  sinks,
+		config: config,
+	}
+}
+
+// Run relays events until the context is cancelled. Multiple relays may run concurrently against the same database.
+func (r *Relay) Run(ctx context.Context) error {
+	for {
+		delivered, err := r.RelayOnce(ctx)
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return nil
+			}
+
+			logrus.Errorf("Error relaying outbox events: %s", err.Error())
+		}
+
+		// If we claimed a full batch, there are probably more events waiting
+		if err == nil && delivered >= r.config.BatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(r.config.PollInterval):
+		}
+	}
+}
+
+// RelayOnce claims a single batch and delivers it to all sinks, returning the number of events claimed. The lease is
+// renewed before each event is delivered, and the outcome of each event is recorded before moving on to the next, so
+// that a slow batch does not outlive its lease and cause events to be delivered twice. If the lease is lost, the rest
+// of the batch is left for whichever relay now holds it.
+func (r *Relay) RelayOnce(ctx context.Context) (int, error) {
+	events, err := r.table.ClaimBatch(ctx, r.config.BatchSize, r.config.LeaseDuration)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	var errs []error
+	for i, event := range events {
+		// The first event was leased by ClaimBatch itself
+		if i > 0 {
+			renewed, err := r.table.RenewLease(ctx, event.LeaseId, r.config.LeaseDuration)
+			if err != nil {
+				errs = append(errs, err)
+				break
+			}
+
+			if renewed == 0 {
+				errs = append(errs, database.ErrOutboxLeaseLost)
+				break
+			}
+		}
+
+		if err := r.deliver(ctx, event); err != nil {
+			err = r.fail(ctx, event, err)
+		} else {
+			err = r.table.MarkDelivered(ctx, event.LeaseId, []int64{event.Id})
+		}
+
+		if err != nil {
+			errs = append(errs, err)
+
+			if errors.Is(err, database.ErrOutboxLeaseLost) || ctx.Err() != nil {
+				break
+			}
+		}
+	}
+
+	return len(events), errors.Join(errs...)
+}
+
+func (r *Relay) deliver(ctx context.Context, event database.OutboxEvent) error {
+	// Leave time to record the outcome before the lease expires
+	ctx, cancel := context.WithTimeout(ctx, r.deliveryTimeout())
+	defer cancel()
+
+	for _, sink := range r.sinks {
+		if err := sink.Deliver(ctx, event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *Relay) fail(ctx context.Context, event database.OutboxEvent, cause error) error {
+	if r.config.MaxAttempts > 0 && event.Attempts >= r.config.MaxAttempts {
+		logrus.Warnf("Giving up on outbox event %d (%s) after %d attempts: %s", event.Id, event.EventType, event.Attempts, cause.Error())
+		return r.table.MarkDead(ctx, event.LeaseId, event.Id, cause.Error())
+	}
+
+	return r.table.MarkFailed(ctx, event.LeaseId, event.Id, r.config.Backoff(event.Attempts), cause.Error())
+}
+
+func (r *Relay) deliveryTimeout() time.Duration {
+	return r.config.LeaseDuration * 9 / 10
+}
@@ -0,0 +1,37 @@
+package outbox
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExponentialBackoff(t *testing.T) {
+	backoff := ExponentialBackoff(time.Second, time.Minute)
+
+	cases := []struct {
+		attempt  int
+		expected time.Duration
+	}{
+		{0, time.Second},
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{6, 32 * time.Second},
+		{7, time.Minute},
+		{100, time.Minute},
+	}
+
+	for _, c := range cases {
+		if got := backoff(c.attempt); got != c.expected {
+			t.Errorf("attempt %d: expected %s, got %s", c.attempt, c.expected, got)
+		}
+	}
+}
+
+func TestDeliveryTimeoutWithinLease(t *testing.T) {
+	relay := NewRelay(nil, Config{LeaseDuration: time.Minute})
+
+	if timeout := relay.deliveryTimeout(); timeout <= 0 || timeout >= time.Minute {
+		t.Errorf("expected delivery timeout to be shorter than the lease, got %s", timeout)
+	}
+}
@@ -0,0 +1,139 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	database "github.com/jadevelopmentgrp/Tickets-Database"
+	jsoniter "github.com/json-iterator/go"
+)
+
+var json = jsoniter.ConfigCompatibleWithStandardLibrary
+
+// Sink receives events claimed by the relay. Delivery is at-least-once: if Deliver returns an error, or the relay
+// crashes before the event is marked as delivered, the event will be passed to every sink again.
+type Sink interface {
+	Deliver(ctx context.Context, event database.OutboxEvent) error
+}
+
+type HttpSink struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+var _ Sink = (*HttpSink)(nil)
+
+func NewHttpSink(url string, headers map[string]string, timeout time.Duration) *HttpSink {
+	return &HttpSink{
+		url:     url,
+		headers: headers,
+		client: &http.Client{
+			Timeout: timeout,
+		},
+	}
+}
+
+func (s *HttpSink) Deliver(ctx context.Context, event database.OutboxEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", fmt.Sprintf("outbox-%d", event.Id))
+	for key, value := range s.headers {
+		req.Header.Set(key, value)
+	}
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 64*1024))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("webhook returned status code %d", res.StatusCode)
+	}
+
+	return nil
+}
+
+// WriterSink writes each event as a single line of JSON.
+type WriterSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+var _ Sink = (*WriterSink)(nil)
+
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{
+		w: w,
+	}
+}
+
+func NewStdoutSink() *WriterSink {
+	return NewWriterSink(os.Stdout)
+}
+
+func (s *WriterSink) Deliver(_ context.Context, event database.OutboxEvent) error {
+	encoded, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err = s.w.Write(append(encoded, '\n'))
+	return err
+}
+
+// FileSink appends events to a file as newline delimited JSON.
+type FileSink struct {
+	*WriterSink
+	file *os.File
+}
+
+var _ Sink = (*FileSink)(nil)
+
+func NewFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileSink{
+		WriterSink: NewWriterSink(file),
+		file:       file,
+	}, nil
+}
+
+func (s *FileSink) Deliver(ctx context.Context, event database.OutboxEvent) error {
+	if err := s.WriterSink.Deliver(ctx, event); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.file.Sync()
+}
+
+func (s *FileSink) Close() error {
+	return s.file.Close()
+}
@@ -0,0 +1,504 @@
+package database
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jadevelopmentgrp/Tickets-Utilities/model"
+	jsoniter "github.com/json-iterator/go"
+)
+
+type AccessConditionType string
+
+const (
+	// AccessConditionAccountAge matches on the age of the user's Discord account, derived from their user ID
+	AccessConditionAccountAge AccessConditionType = "account_age"
+	// AccessConditionMemberAge matches on how long the user has been a member of the guild
+	AccessConditionMemberAge AccessConditionType = "member_age"
+	// AccessConditionHasAnyRole matches if the user has at least one of the roles
+	AccessConditionHasAnyRole AccessConditionType = "has_any_role"
+	// AccessConditionHasAllRoles matches if the user has every one of the roles
+	AccessConditionHasAllRoles AccessConditionType = "has_all_roles"
+	// AccessConditionTicketCount matches on the number of tickets the user has previously opened in the guild
+	AccessConditionTicketCount AccessConditionType = "ticket_count"
+	// AccessConditionEntitlementTier matches if the guild has one of the entitlement tiers
+	AccessConditionEntitlementTier AccessConditionType = "entitlement_tier"
+	// AccessConditionTimeWindow matches during a window of time on certain days of the week
+	AccessConditionTimeWindow AccessConditionType = "time_window"
+)
+
+var ErrInvalidAccessCondition = errors.New("invalid access condition")
+
+// AccessCondition is stored as JSON. Only the fields relevant to the condition type are used.
+type AccessCondition struct {
+	Type   AccessConditionType `json:"type"`
+	Negate bool                `json:"negate,omitempty"`
+
+	// account_age and member_age. At least one bound must be set.
+	MinSeconds *int64 `json:"min_seconds,omitempty"`
+	MaxSeconds *int64 `json:"max_seconds,omitempty"`
+
+	// has_any_role and has_all_roles
+	RoleIds Snowflakes `json:"role_ids,omitempty"`
+
+	// ticket_count. At least one bound must be set.
+	MinCount *int `json:"min_count,omitempty"`
+	MaxCount *int `json:"max_count,omitempty"`
+
+	// entitlement_tier
+	Tiers []model.EntitlementTier `json:"tiers,omitempty"`
+
+	// time_window. The window may wrap past midnight, e.g. 1320 - 360 for 22:00 - 06:00.
+	Timezone    string         `json:"timezone,omitempty"` // IANA name, defaults to UTC
+	Weekdays    []time.Weekday `json:"weekdays,omitempty"` // Empty matches every day
+	StartMinute *int           `json:"start_minute,omitempty"`
+	EndMinute   *int           `json:"end_minute,omitempty"`
+}
+
+// Snowflakes are encoded as a JSON array of strings, as they cannot be represented by a JSON number. Numbers are still
+// accepted when decoding, for conditions stored before IDs were encoded as strings.
+type Snowflakes []uint64
+
+func (s Snowflakes) MarshalJSON() ([]byte, error) {
+	if s == nil {
+		return []byte("null"), nil
+	}
+
+	encoded := make([]string, len(s))
+	for i, id := range s {
+		encoded[i] = strconv.FormatUint(id, 10)
+	}
+
+	return json.Marshal(encoded)
+}
+
+func (s *Snowflakes) UnmarshalJSON(data []byte) error {
+	var raw []jsoniter.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	if raw == nil {
+		*s = nil
+		return nil
+	}
+
+	ids := make(Snowflakes, len(raw))
+	for i, value := range raw {
+		str := string(value)
+		if len(value) > 0 && value[0] == '"' {
+			if err := json.Unmarshal(value, &str); err != nil {
+				return err
+			}
+		}
+
+		id, err := strconv.ParseUint(str, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid snowflake %s: %w", value, err)
+		}
+
+		ids[i] = id
+	}
+
+	*s = ids
+	return nil
+}
+
+// PanelAccessConditionRule matches if all of its conditions match. A rule without conditions always matches.
+type PanelAccessConditionRule struct {
+	Action     AccessControlAction `json:"action"`
+	Conditions []AccessCondition   `json:"conditions"`
+}
+
+// AccessControlSubject is the member attempting to open a ticket
+type AccessControlSubject struct {
+	UserId   uint64
+	RoleIds  []uint64
+	JoinedAt *time.Time             // If nil, member_age conditions do not match
+	Tier     *model.EntitlementTier // The guild's entitlement tier, nil if it has none
+	Now      time.Time              // Defaults to the current time
+}
+
+type AccessConditionTrace struct {
+	Condition AccessCondition `json:"condition"`
+	Matched   bool            `json:"matched"`
+	Detail    string          `json:"detail"`
+}
+
+type AccessRuleTrace struct {
+	Position   int                    `json:"position"`
+	Action     AccessControlAction    `json:"action"`
+	Matched    bool                   `json:"matched"`
+	Conditions []AccessConditionTrace `json:"conditions"`
+}
+
+// AccessControlEvaluation explains which rule matched. Rules are evaluated in order until one matches, so the trace
+// does not include the rules after the matched rule.
+type AccessControlEvaluation struct {
+	Matched  bool                `json:"matched"`
+	Action   AccessControlAction `json:"action"`   // Empty if no rule matched
+	Position *int                `json:"position"` // Position of the matched rule
+	Legacy   bool                `json:"legacy"`   // Whether the legacy role rules were evaluated, as no condition rules exist
+	Trace    []AccessRuleTrace   `json:"trace"`
+}
+
+// Discord epoch, in milliseconds since the Unix epoch
+const discordEpoch = 1420070400000
+
+var (
+	//go:embed sql/panel_access_control_rules/get_condition_rules.sql
+	panelAccessControlRulesGetConditionRules string
+
+	//go:embed sql/panel_access_control_rules/delete_condition_rules.sql
+	panelAccessControlRulesDeleteConditionRules string
+
+	//go:embed sql/panel_access_control_rules/insert_condition_rule.sql
+	panelAccessControlRulesInsertConditionRule string
+)
+
+func (p *PanelAccessControlRules) GetConditionRules(ctx context.Context, panelId int) ([]PanelAccessConditionRule, error) {
+	rows, err := p.Query(ctx, panelAccessControlRulesGetConditionRules, panelId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var rules []PanelAccessConditionRule
+	for rows.Next() {
+		var position int
+		var rule PanelAccessConditionRule
+		var conditions []byte
+		if err := rows.Scan(&position, &rule.Action, &conditions); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(conditions, &rule.Conditions); err != nil {
+			return nil, err
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, rows.Err()
+}
+
+func (p *PanelAccessControlRules) ReplaceConditionRules(ctx context.Context, panelId int, rules []PanelAccessConditionRule) error {
+	tx, err := p.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	if err := p.ReplaceConditionRulesWithTx(ctx, tx, panelId, rules); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ReplaceConditionRulesWithTx validates and stores the rules. Once a panel has condition rules, its legacy role rules
+// are no longer evaluated.
+func (p *PanelAccessControlRules) ReplaceConditionRulesWithTx(ctx context.Context, tx pgx.Tx, panelId int, rules []PanelAccessConditionRule) error {
+	for _, rule := range rules {
+		if rule.Action != AccessControlActionAllow && rule.Action != AccessControlActionDeny {
+			return fmt.Errorf("%w: invalid action %s", ErrInvalidAccessCondition, rule.Action)
+		}
+
+		for _, condition := range rule.Conditions {
+			if err := condition.Validate(); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Remove existing rules
+	if _, err := tx.Exec(ctx, panelAccessControlRulesDeleteConditionRules, panelId); err != nil {
+		return err
+	}
+
+	// Add each rule
+	for position, rule := range rules {
+		conditions := rule.Conditions
+		if conditions == nil {
+			conditions = []AccessCondition{}
+		}
+
+		encoded, err := json.Marshal(conditions)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(ctx, panelAccessControlRulesInsertConditionRule, panelId, position, rule.Action, encoded); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c AccessCondition) Validate() error {
+	switch c.Type {
+	case AccessConditionAccountAge, AccessConditionMemberAge:
+		if c.MinSeconds == nil && c.MaxSeconds == nil {
+			return fmt.Errorf("%w: %s requires min_seconds or max_seconds", ErrInvalidAccessCondition, c.Type)
+		}
+	case AccessConditionHasAnyRole, AccessConditionHasAllRoles:
+		if len(c.RoleIds) == 0 {
+			return fmt.Errorf("%w: %s requires role_ids", ErrInvalidAccessCondition, c.Type)
+		}
+	case AccessConditionTicketCount:
+		if c.MinCount == nil && c.MaxCount == nil {
+			return fmt.Errorf("%w: %s requires min_count or max_count", ErrInvalidAccessCondition, c.Type)
+		}
+	case AccessConditionEntitlementTier:
+		if len(c.Tiers) == 0 {
+			return fmt.Errorf("%w: %s requires tiers", ErrInvalidAccessCondition, c.Type)
+		}
+	case AccessConditionTimeWindow:
+		if c.StartMinute == nil || c.EndMinute == nil {
+			return fmt.Errorf("%w: %s requires start_minute and end_minute", ErrInvalidAccessCondition, c.Type)
+		}
+
+		if *c.StartMinute < 0 || *c.StartMinute >= 24*60 || *c.EndMinute < 0 || *c.EndMinute > 24*60 {
+			return fmt.Errorf("%w: %s minutes must be within a day", ErrInvalidAccessCondition, c.Type)
+		}
+
+		if *c.StartMinute == *c.EndMinute {
+			return fmt.Errorf("%w: %s start_minute and end_minute must differ", ErrInvalidAccessCondition, c.Type)
+		}
+
+		if _, err := time.LoadLocation(c.Timezone); err != nil {
+			return fmt.Errorf("%w: invalid timezone %s", ErrInvalidAccessCondition, c.Timezone)
+		}
+	default:
+		return fmt.Errorf("%w: unknown type %s", ErrInvalidAccessCondition, c.Type)
+	}
+
+	return nil
+}
+
+// EvaluatePanelAccess evaluates the panel's condition rules in order, stopping at the first rule that matches. If the
+// panel has no condition rules, its legacy role rules are evaluated instead, each as a has_any_role condition.
+func (d *Database) EvaluatePanelAccess(
+	ctx context.Context,
+	guildId uint64,
+	panelId int,
+	subject AccessControlSubject,
+) (AccessControlEvaluation, error) {
+	rules, err := d.PanelAccessControlRules.GetConditionRules(ctx, panelId)
+	if err != nil {
+		return AccessControlEvaluation{}, err
+	}
+
+	var legacy bool
+	if len(rules) == 0 {
+		legacyRules, err := d.PanelAccessControlRules.GetAll(ctx, panelId)
+		if err != nil {
+			return AccessControlEvaluation{}, err
+		}
+
+		legacy = true
+		for _, legacyRule := range legacyRules {
+			rules = append(rules, PanelAccessConditionRule{
+				Action: legacyRule.Action,
+				Conditions: []AccessCondition{
+					{
+						Type:    AccessConditionHasAnyRole,
+						RoleIds: []uint64{legacyRule.RoleId},
+					},
+				},
+			})
+		}
+	}
+
+	// Only fetched if a rule depends on it
+	var ticketCount *int
+	getTicketCount := func() (int, error) {
+		if ticketCount == nil {
+			count, err := d.Tickets.GetTotalCountByUser(ctx, guildId, subject.UserId)
+			if err != nil {
+				return 0, err
+			}
+
+			ticketCount = &count
+		}
+
+		return *ticketCount, nil
+	}
+
+	evaluation, err := evaluateAccessRules(rules, subject, getTicketCount)
+	if err != nil {
+		return AccessControlEvaluation{}, err
+	}
+
+	evaluation.Legacy = legacy
+	return evaluation, nil
+}
+
+func evaluateAccessRules(
+	rules []PanelAccessConditionRule,
+	subject AccessControlSubject,
+	getTicketCount func() (int, error),
+) (AccessControlEvaluation, error) {
+	if subject.Now.IsZero() {
+		subject.Now = time.Now()
+	}
+
+	evaluation := AccessControlEvaluation{
+		Trace: make([]AccessRuleTrace, 0, len(rules)),
+	}
+
+	for position, rule := range rules {
+		ruleTrace := AccessRuleTrace{
+			Position:   position,
+			Action:     rule.Action,
+			Matched:    true,
+			Conditions: make([]AccessConditionTrace, 0, len(rule.Conditions)),
+		}
+
+		// Every condition is evaluated, even after one fails, so that the trace is complete
+		for _, condition := range rule.Conditions {
+			matched, detail, err := evaluateAccessCondition(condition, subject, getTicketCount)
+			if err != nil {
+				return AccessControlEvaluation{}, err
+			}
+
+			if condition.Negate {
+				matched = !matched
+			}
+
+			ruleTrace.Matched = ruleTrace.Matched && matched
+			ruleTrace.Conditions = append(ruleTrace.Conditions, AccessConditionTrace{
+				Condition: condition,
+				Matched:   matched,
+				Detail:    detail,
+			})
+		}
+
+		evaluation.Trace = append(evaluation.Trace, ruleTrace)
+
+		if ruleTrace.Matched {
+			evaluation.Matched = true
+			evaluation.Action = rule.Action
+			evaluation.Position = ptr(position)
+			break
+		}
+	}
+
+	return evaluation, nil
+}
+
+// Returns whether the condition matched before negation, and a description of the value that was compared
+func evaluateAccessCondition(
+	condition AccessCondition,
+	subject AccessControlSubject,
+	getTicketCount func() (int, error),
+) (bool, string, error) {
+	switch condition.Type {
+	case AccessConditionAccountAge:
+		createdAt := time.UnixMilli(int64(subject.UserId>>22) + discordEpoch)
+		age := subject.Now.Sub(createdAt)
+		return withinSeconds(age, condition), fmt.Sprintf("account is %s old", age.Truncate(time.Second)), nil
+	case AccessConditionMemberAge:
+		if subject.JoinedAt == nil {
+			return false, "join time unknown", nil
+		}
+
+		age := subject.Now.Sub(*subject.JoinedAt)
+		return withinSeconds(age, condition), fmt.Sprintf("member for %s", age.Truncate(time.Second)), nil
+	case AccessConditionHasAnyRole, AccessConditionHasAllRoles:
+		roles := make(map[uint64]struct{}, len(subject.RoleIds))
+		for _, roleId := range subject.RoleIds {
+			roles[roleId] = struct{}{}
+		}
+
+		var held int
+		for _, roleId := range condition.RoleIds {
+			if _, ok := roles[roleId]; ok {
+				held++
+			}
+		}
+
+		detail := fmt.Sprintf("has %d of %d roles", held, len(condition.RoleIds))
+		if condition.Type == AccessConditionHasAnyRole {
+			return held > 0, detail, nil
+		}
+
+		return held == len(condition.RoleIds), detail, nil
+	case AccessConditionTicketCount:
+		count, err := getTicketCount()
+		if err != nil {
+			return false, "", err
+		}
+
+		matched := (condition.MinCount == nil || count >= *condition.MinCount) &&
+			(condition.MaxCount == nil || count <= *condition.MaxCount)
+		return matched, fmt.Sprintf("opened %d tickets", count), nil
+	case AccessConditionEntitlementTier:
+		if subject.Tier == nil {
+			return false, "no entitlement tier", nil
+		}
+
+		for _, tier := range condition.Tiers {
+			if tier == *subject.Tier {
+				return true, fmt.Sprintf("tier is %s", tier), nil
+			}
+		}
+
+		return false, fmt.Sprintf("tier is %s", *subject.Tier), nil
+	case AccessConditionTimeWindow:
+		location, err := time.LoadLocation(condition.Timezone)
+		if err != nil {
+			return false, "", err
+		}
+
+		now := subject.Now.In(location)
+		detail := now.Format("Monday 15:04 MST")
+
+		// A window that wraps past midnight belongs to the day it started on
+		minute := now.Hour()*60 + now.Minute()
+		start, end := *condition.StartMinute, *condition.EndMinute
+
+		var inWindow bool
+		day := now.Weekday()
+		if start <= end {
+			inWindow = minute >= start && minute < end
+		} else if minute >= start {
+			inWindow = true
+		} else if minute < end {
+			inWindow = true
+			day = (day + 6) % 7
+		}
+
+		if !inWindow {
+			return false, detail, nil
+		}
+
+		if len(condition.Weekdays) == 0 {
+			return true, detail, nil
+		}
+
+		for _, weekday := range condition.Weekdays {
+			if weekday == day {
+				return true, detail, nil
+			}
+		}
+
+		return false, detail, nil
+	default:
+		return false, "", fmt.Errorf("%w: unknown type %s", ErrInvalidAccessCondition, condition.Type)
+	}
+}
+
+func withinSeconds(age time.Duration, condition AccessCondition) bool {
+	seconds := int64(age.Seconds())
+	return (condition.MinSeconds == nil || seconds >= *condition.MinSeconds) &&
+		(condition.MaxSeconds == nil || seconds <= *condition.MaxSeconds)
+}
@@ -0,0 +1,355 @@
+package database
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jadevelopmentgrp/Tickets-Utilities/model"
+)
+
+func TestEvaluateAccessRules(t *testing.T) {
+	// 2024-01-01 was a Monday
+	now := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+	roleId, otherRoleId := uint64(1), uint64(2)
+	tier := model.EntitlementTierPremium
+
+	hasAnyRole := func(roleIds ...uint64) AccessCondition {
+		return AccessCondition{Type: AccessConditionHasAnyRole, RoleIds: roleIds}
+	}
+
+	cases := []struct {
+		name     string
+		rules    []PanelAccessConditionRule
+		subject  AccessControlSubject
+		matched  bool
+		action   AccessControlAction
+		position int
+	}{
+		{
+			name: "no rules",
+		},
+		{
+			name: "rule without conditions matches",
+			rules: []PanelAccessConditionRule{
+				{Action: AccessControlActionDeny},
+			},
+			matched: true,
+			action:  AccessControlActionDeny,
+		},
+		{
+			name: "first matching rule wins",
+			rules: []PanelAccessConditionRule{
+				{Action: AccessControlActionAllow, Conditions: []AccessCondition{hasAnyRole(otherRoleId)}},
+				{Action: AccessControlActionDeny, Conditions: []AccessCondition{hasAnyRole(roleId)}},
+				{Action: AccessControlActionAllow},
+			},
+			subject:  AccessControlSubject{RoleIds: []uint64{roleId}},
+			matched:  true,
+			action:   AccessControlActionDeny,
+			position: 1,
+		},
+		{
+			name: "every condition must match",
+			rules: []PanelAccessConditionRule{
+				{
+					Action: AccessControlActionAllow,
+					Conditions: []AccessCondition{
+						{Type: AccessConditionHasAllRoles, RoleIds: []uint64{roleId, otherRoleId}},
+					},
+				},
+			},
+			subject: AccessControlSubject{RoleIds: []uint64{roleId}},
+		},
+		{
+			name: "negated condition",
+			rules: []PanelAccessConditionRule{
+				{
+					Action: AccessControlActionDeny,
+					Conditions: []AccessCondition{
+						{Type: AccessConditionHasAnyRole, RoleIds: []uint64{roleId}, Negate: true},
+					},
+				},
+			},
+			subject: AccessControlSubject{RoleIds: []uint64{otherRoleId}},
+			matched: true,
+			action:  AccessControlActionDeny,
+		},
+		{
+			name: "member age without join time does not match",
+			rules: []PanelAccessConditionRule{
+				{
+					Action:     AccessControlActionAllow,
+					Conditions: []AccessCondition{{Type: AccessConditionMemberAge, MinSeconds: ptr(int64(0))}},
+				},
+			},
+		},
+		{
+			name: "member age",
+			rules: []PanelAccessConditionRule{
+				{
+					Action:     AccessControlActionAllow,
+					Conditions: []AccessCondition{{Type: AccessConditionMemberAge, MinSeconds: ptr(int64(3600))}},
+				},
+			},
+			subject: AccessControlSubject{JoinedAt: ptr(now.Add(-2 * time.Hour))},
+			matched: true,
+			action:  AccessControlActionAllow,
+		},
+		{
+			name: "ticket count",
+			rules: []PanelAccessConditionRule{
+				{
+					Action:     AccessControlActionDeny,
+					Conditions: []AccessCondition{{Type: AccessConditionTicketCount, MinCount: ptr(3)}},
+				},
+			},
+			matched: true,
+			action:  AccessControlActionDeny,
+		},
+		{
+			name: "entitlement tier",
+			rules: []PanelAccessConditionRule{
+				{
+					Action: AccessControlActionAllow,
+					Conditions: []AccessCondition{
+						{Type: AccessConditionEntitlementTier, Tiers: []model.EntitlementTier{tier}},
+					},
+				},
+			},
+			subject: AccessControlSubject{Tier: &tier},
+			matched: true,
+			action:  AccessControlActionAllow,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			c.subject.Now = now
+			getTicketCount := func() (int, error) {
+				return 3, nil
+			}
+
+			evaluation, err := evaluateAccessRules(c.rules, c.subject, getTicketCount)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if evaluation.Matched != c.matched {
+				t.Fatalf("expected matched to be %t, got %t", c.matched, evaluation.Matched)
+			}
+
+			if !c.matched {
+				if evaluation.Position != nil {
+					t.Errorf("expected no position, got %d", *evaluation.Position)
+				}
+
+				return
+			}
+
+			if evaluation.Action != c.action {
+				t.Errorf("expected action %s, got %s", c.action, evaluation.Action)
+			}
+
+			if evaluation.Position == nil || *evaluation.Position != c.position {
+				t.Errorf("expected position %d, got %v", c.position, evaluation.Position)
+			}
+
+			// Rules after the matched rule are not evaluated
+			if len(evaluation.Trace) != c.position+1 {
+				t.Errorf("expected %d traced rules, got %d", c.position+1, len(evaluation.Trace))
+			}
+		})
+	}
+}
+
+func TestEvaluateAccessRulesTicketCount(t *testing.T) {
+	rules := []PanelAccessConditionRule{
+		{
+			Action: AccessControlActionDeny,
+			Conditions: []AccessCondition{
+				{Type: AccessConditionTicketCount, MinCount: ptr(10)},
+				{Type: AccessConditionTicketCount, MaxCount: ptr(0)},
+			},
+		},
+		{
+			Action:     AccessControlActionDeny,
+			Conditions: []AccessCondition{{Type: AccessConditionTicketCount, MinCount: ptr(10)}},
+		},
+	}
+
+	var calls int
+	_, err := evaluateAccessRules(rules, AccessControlSubject{}, func() (int, error) {
+		calls++
+		return 5, nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// evaluateAccessRules does not cache the count itself, EvaluatePanelAccess does
+	if calls != 3 {
+		t.Errorf("expected the count to be requested for each condition, got %d calls", calls)
+	}
+
+	expectedErr := errors.New("database unavailable")
+	if _, err := evaluateAccessRules(rules, AccessControlSubject{}, func() (int, error) {
+		return 0, expectedErr
+	}); !errors.Is(err, expectedErr) {
+		t.Errorf("expected the count error to be returned, got %v", err)
+	}
+}
+
+func TestTimeWindowCondition(t *testing.T) {
+	window := func(start, end int, weekdays ...time.Weekday) AccessCondition {
+		return AccessCondition{
+			Type:        AccessConditionTimeWindow,
+			Timezone:    "UTC",
+			Weekdays:    weekdays,
+			StartMinute: ptr(start),
+			EndMinute:   ptr(end),
+		}
+	}
+
+	// 2024-01-01 was a Monday
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, time.January, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	cases := []struct {
+		name      string
+		condition AccessCondition
+		now       time.Time
+		matched   bool
+	}{
+		{"inside window", window(9*60, 17*60), at(1, 12, 0), true},
+		{"at start", window(9*60, 17*60), at(1, 9, 0), true},
+		{"at end", window(9*60, 17*60), at(1, 17, 0), false},
+		{"before window", window(9*60, 17*60), at(1, 8, 59), false},
+		{"window until midnight", window(22*60, 24*60), at(1, 23, 59), true},
+		{"wrapping window before midnight", window(22*60, 6*60), at(1, 23, 0), true},
+		{"wrapping window after midnight", window(22*60, 6*60), at(2, 5, 59), true},
+		{"outside wrapping window", window(22*60, 6*60), at(2, 6, 0), false},
+		{"matching weekday", window(9*60, 17*60, time.Monday), at(1, 12, 0), true},
+		{"other weekday", window(9*60, 17*60, time.Tuesday), at(1, 12, 0), false},
+		{"wrapping window belongs to the day it started", window(22*60, 6*60, time.Monday), at(2, 2, 0), true},
+		{"wrapping window does not belong to the next day", window(22*60, 6*60, time.Tuesday), at(2, 2, 0), false},
+		{
+			name: "timezone",
+			condition: AccessCondition{
+				Type:        AccessConditionTimeWindow,
+				Timezone:    "America/New_York",
+				StartMinute: ptr(9 * 60),
+				EndMinute:   ptr(17 * 60),
+			},
+			now:     at(1, 15, 0), // 10:00 in New York
+			matched: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if err := c.condition.Validate(); err != nil {
+				t.Fatalf("unexpected validation error: %v", err)
+			}
+
+			matched, _, err := evaluateAccessCondition(c.condition, AccessControlSubject{Now: c.now}, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if matched != c.matched {
+				t.Errorf("expected matched to be %t, got %t", c.matched, matched)
+			}
+		})
+	}
+}
+
+func TestAccessConditionValidate(t *testing.T) {
+	cases := []struct {
+		name      string
+		condition AccessCondition
+		valid     bool
+	}{
+		{"account age", AccessCondition{Type: AccessConditionAccountAge, MinSeconds: ptr(int64(60))}, true},
+		{"account age without bounds", AccessCondition{Type: AccessConditionAccountAge}, false},
+		{"roles", AccessCondition{Type: AccessConditionHasAnyRole, RoleIds: []uint64{1}}, true},
+		{"roles without IDs", AccessCondition{Type: AccessConditionHasAllRoles}, false},
+		{"ticket count without bounds", AccessCondition{Type: AccessConditionTicketCount}, false},
+		{"entitlement tier without tiers", AccessCondition{Type: AccessConditionEntitlementTier}, false},
+		{"unknown type", AccessCondition{Type: "unknown"}, false},
+		{
+			name:      "time window",
+			condition: AccessCondition{Type: AccessConditionTimeWindow, StartMinute: ptr(0), EndMinute: ptr(24 * 60)},
+			valid:     true,
+		},
+		{
+			name:      "time window without end",
+			condition: AccessCondition{Type: AccessConditionTimeWindow, StartMinute: ptr(0)},
+		},
+		{
+			name:      "time window with equal start and end",
+			condition: AccessCondition{Type: AccessConditionTimeWindow, StartMinute: ptr(60), EndMinute: ptr(60)},
+		},
+		{
+			name:      "time window starting at the end of the day",
+			condition: AccessCondition{Type: AccessConditionTimeWindow, StartMinute: ptr(24 * 60), EndMinute: ptr(60)},
+		},
+		{
+			name: "time window with invalid timezone",
+			condition: AccessCondition{
+				Type:        AccessConditionTimeWindow,
+				Timezone:    "Not/A_Zone",
+				StartMinute: ptr(0),
+				EndMinute:   ptr(60),
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := c.condition.Validate()
+			if c.valid && err != nil {
+				t.Errorf("expected to be valid, got %v", err)
+			} else if !c.valid && !errors.Is(err, ErrInvalidAccessCondition) {
+				t.Errorf("expected ErrInvalidAccessCondition, got %v", err)
+			}
+		})
+	}
+}
+
+func TestSnowflakesJSON(t *testing.T) {
+	ids := Snowflakes{1, 18446744073709551615}
+
+	encoded, err := json.Marshal(ids)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if string(encoded) != `["1","18446744073709551615"]` {
+		t.Errorf("unexpected encoding %s", encoded)
+	}
+
+	var decoded Snowflakes
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(decoded, ids) {
+		t.Errorf("expected %v, got %v", ids, decoded)
+	}
+
+	// Conditions stored before IDs were encoded as strings
+	if err := json.Unmarshal([]byte(`[1, "2"]`), &decoded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(decoded, Snowflakes{1, 2}) {
+		t.Errorf("expected [1 2], got %v", decoded)
+	}
+
+	if err := json.Unmarshal([]byte(`["not a snowflake"]`), &decoded); err == nil {
+		t.Error("expected an error decoding an invalid snowflake")
+	}
+}
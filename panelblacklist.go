@@ -0,0 +1,380 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// PanelBlacklist bars users from opening tickets from a single panel, while still allowing them to use the guild's other
+// panels. The guild-wide equivalent is Blacklist.
+type PanelBlacklist struct {
+	*pgxpool.Pool
+}
+
+// PanelRoleBlacklist bars users with a role from opening tickets from a single panel. The guild-wide equivalent is
+// RoleBlacklist.
+type PanelRoleBlacklist struct {
+	*pgxpool.Pool
+}
+
+// BlacklistMatch describes the blacklist entry that prevents a user from opening a ticket.
+type BlacklistMatch struct {
+	Type      BlacklistType `json:"type"`
+	SubjectId uint64        `json:"subject_id,string"` // User, role or guild ID, depending on the type
+	PanelId   *int          `json:"panel_id"`
+	Reason    *string       `json:"reason"`
+	ExpiresAt *time.Time    `json:"expires_at"`
+}
+
+func newPanelBlacklist(db *pgxpool.Pool) *PanelBlacklist {
+	return &PanelBlacklist{
+		db,
+	}
+}
+
+func newPanelRoleBlacklist(db *pgxpool.Pool) *PanelRoleBlacklist {
+	return &PanelRoleBlacklist{
+		db,
+	}
+}
+
+func (b PanelBlacklist) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS panel_blacklist(
+	"guild_id" int8 NOT NULL,
+	"panel_id" int4 NOT NULL,
+	"user_id" int8 NOT NULL,
+	"reason" TEXT,
+	"created_by" int8,
+	"created_at" timestamptz NOT NULL DEFAULT NOW(),
+	"expires_at" timestamptz,
+	FOREIGN KEY("panel_id") REFERENCES panels("panel_id") ON DELETE CASCADE,
+	PRIMARY KEY("panel_id", "user_id")
+);
+CREATE INDEX IF NOT EXISTS panel_blacklist_guild_id_user_id ON panel_blacklist("guild_id", "user_id");
+CREATE INDEX IF NOT EXISTS panel_blacklist_expires_at ON panel_blacklist("expires_at") WHERE "expires_at" IS NOT NULL;
+`
+}
+
+func (b PanelRoleBlacklist) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS panel_role_blacklist(
+	"guild_id" int8 NOT NULL,
+	"panel_id" int4 NOT NULL,
+	"role_id" int8 NOT NULL,
+	"reason" TEXT,
+	"created_by" int8,
+	"created_at" timestamptz NOT NULL DEFAULT NOW(),
+	"expires_at" timestamptz,
+	FOREIGN KEY("panel_id") REFERENCES panels("panel_id") ON DELETE CASCADE,
+	PRIMARY KEY("panel_id", "role_id")
+);
+CREATE INDEX IF NOT EXISTS panel_role_blacklist_expires_at ON panel_role_blacklist("expires_at") WHERE "expires_at" IS NOT NULL;
+`
+}
+
+// IsBlacklistedForPanel checks every blacklist that could prevent the user from opening a ticket from the panel: the
+// global blacklist, the server blacklist, the guild's user and role blacklists, and the panel's user and role
+// blacklists. Returns nil if the user is not blacklisted. If multiple entries match, the broadest is returned, in the
+// order listed above.
+func (b *PanelBlacklist) IsBlacklistedForPanel(
+	ctx context.Context,
+	guildId uint64,
+	panelId int,
+	userId uint64,
+	roleIds []uint64,
+) (*BlacklistMatch, error) {
+	query := `
+SELECT "type", "subject_id", "panel_id", "reason", "expires_at"
+FROM (
+	SELECT 1 AS "priority", 'global' AS "type", "user_id" AS "subject_id", NULL::int4 AS "panel_id", "reason", "expires_at"
+	FROM global_blacklist
+	WHERE "user_id" = $3
+	UNION ALL
+	SELECT 2, 'server', "guild_id", NULL, "reason", "expires_at"
+	FROM server_blacklist
+	WHERE "guild_id" = $1
+	UNION ALL
+	SELECT 3, 'user', "user_id", NULL, "reason", "expires_at"
+	FROM blacklist
+	WHERE "guild_id" = $1 AND "user_id" = $3
+	UNION ALL
+	SELECT 4, 'role', "role_id", NULL, "reason", "expires_at"
+	FROM role_blacklist
+	WHERE "guild_id" = $1 AND "role_id" = ANY($4)
+	UNION ALL
+	SELECT 5, 'panel_user', "user_id", "panel_id", "reason", "expires_at"
+	FROM panel_blacklist
+	WHERE "guild_id" = $1 AND "panel_id" = $2 AND "user_id" = $3
+	UNION ALL
+	SELECT 6, 'panel_role', "role_id", "panel_id", "reason", "expires_at"
+	FROM panel_role_blacklist
+	WHERE "guild_id" = $1 AND "panel_id" = $2 AND "role_id" = ANY($4)
+) matches
+WHERE "expires_at" IS NULL OR "expires_at" > NOW()
+ORDER BY "priority"
+LIMIT 1;`
+
+	roleIdArray := &pgtype.Int8Array{}
+	if err := roleIdArray.Set(roleIds); err != nil {
+		return nil, err
+	}
+
+	var match BlacklistMatch
+	if err := b.QueryRow(ctx, query, guildId, panelId, userId, roleIdArray).Scan(
+		&match.Type,
+		&match.SubjectId,
+		&match.PanelId,
+		&match.Reason,
+		&match.ExpiresAt,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &match, nil
+}
+
+// IsBlacklisted only checks the panel blacklist. Use IsBlacklistedForPanel to check every blacklist that applies.
+func (b *PanelBlacklist) IsBlacklisted(ctx context.Context, guildId uint64, panelId int, userId uint64) (blacklisted bool, err error) {
+	query := `
+SELECT EXISTS(
+	SELECT 1
+	FROM panel_blacklist
+	WHERE "guild_id" = $1 AND "panel_id" = $2 AND "user_id" = $3 AND ("expires_at" IS NULL OR "expires_at" > NOW())
+);`
+
+	err = b.QueryRow(ctx, query, guildId, panelId, userId).Scan(&blacklisted)
+	return
+}
+
+func (b *PanelBlacklist) GetBlacklistedUsers(ctx context.Context, guildId uint64, panelId int) ([]BlacklistEntry, error) {
+	query := `
+SELECT "user_id", "reason", "created_by", "created_at", "expires_at"
+FROM panel_blacklist
+WHERE "guild_id" = $1 AND "panel_id" = $2 AND ("expires_at" IS NULL OR "expires_at" > NOW())
+ORDER BY "created_at" DESC, "user_id";`
+
+	return queryBlacklistEntries(ctx, b.Pool, query, guildId, panelId)
+}
+
+// GetPanelsByUser returns the IDs of the panels in the guild that the user is blacklisted from.
+func (b *PanelBlacklist) GetPanelsByUser(ctx context.Context, guildId, userId uint64) (panelIds []int, e error) {
+	query := `
+SELECT "panel_id"
+FROM panel_blacklist
+WHERE "guild_id" = $1 AND "user_id" = $2 AND ("expires_at" IS NULL OR "expires_at" > NOW());`
+
+	rows, err := b.Query(ctx, query, guildId, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var panelId int
+		if err := rows.Scan(&panelId); err != nil {
+			return nil, err
+		}
+
+		panelIds = append(panelIds, panelId)
+	}
+
+	return panelIds, rows.Err()
+}
+
+// Add permanently blacklists the user from the panel, without a reason.
+func (b *PanelBlacklist) Add(ctx context.Context, guildId uint64, panelId int, userId uint64) (err error) {
+	return b.AddWithMetadata(ctx, guildId, panelId, userId, BlacklistMetadata{})
+}
+
+// AddWithMetadata blacklists the user from the panel, replacing any existing blacklist, and records it in the user's
+// history. Nothing is written if the panel does not belong to the guild.
+func (b *PanelBlacklist) AddWithMetadata(ctx context.Context, guildId uint64, panelId int, userId uint64, metadata BlacklistMetadata) (err error) {
+	query := `
+WITH added AS (
+	INSERT INTO panel_blacklist("guild_id", "panel_id", "user_id", "reason", "created_by", "created_at", "expires_at")
+	SELECT "guild_id", "panel_id", $3, $4, $5, NOW(), $6
+	FROM panels
+	WHERE "panel_id" = $2 AND "guild_id" = $1
+	ON CONFLICT("panel_id", "user_id") DO UPDATE
+	SET "reason" = EXCLUDED."reason",
+		"created_by" = EXCLUDED."created_by",
+		"created_at" = EXCLUDED."created_at",
+		"expires_at" = EXCLUDED."expires_at"
+	RETURNING "guild_id", "panel_id", "user_id", "reason", "created_by", "expires_at"
+)
+INSERT INTO blacklist_history("type", "guild_id", "panel_id", "subject_id", "action", "reason", "actor_id", "expires_at")
+SELECT 'panel_user', "guild_id", "panel_id", "user_id", 'added', "reason", "created_by", "expires_at"
+FROM added;`
+
+	_, err = b.Exec(ctx, query, guildId, panelId, userId, metadata.Reason, metadata.CreatedBy, metadata.ExpiresAt)
+	return
+}
+
+func (b *PanelBlacklist) Remove(ctx context.Context, guildId uint64, panelId int, userId uint64) (err error) {
+	return b.remove(ctx, guildId, panelId, userId, nil)
+}
+
+// RemoveBy is the same as Remove, but records who removed the blacklist in the user's history.
+func (b *PanelBlacklist) RemoveBy(ctx context.Context, guildId uint64, panelId int, userId, removedBy uint64) (err error) {
+	return b.remove(ctx, guildId, panelId, userId, &removedBy)
+}
+
+func (b *PanelBlacklist) remove(ctx context.Context, guildId uint64, panelId int, userId uint64, removedBy *uint64) (err error) {
+	query := `
+WITH removed AS (
+	DELETE FROM panel_blacklist WHERE "guild_id" = $1 AND "panel_id" = $2 AND "user_id" = $3
+	RETURNING "guild_id", "panel_id", "user_id", "expires_at"
+)
+INSERT INTO blacklist_history("type", "guild_id", "panel_id", "subject_id", "action", "actor_id", "expires_at")
+SELECT
+	'panel_user',
+	"guild_id",
+	"panel_id",
+	"user_id",
+	CASE WHEN "expires_at" <= NOW() THEN 'expired'::blacklist_action ELSE 'removed'::blacklist_action END,
+	CASE WHEN "expires_at" <= NOW() THEN NULL ELSE $4::int8 END,
+	"expires_at"
+FROM removed;`
+
+	_, err = b.Exec(ctx, query, guildId, panelId, userId, removedBy)
+	return
+}
+
+// DeleteExpired removes all expired blacklists, recording their expiry in history, and returns the number removed.
+func (b *PanelBlacklist) DeleteExpired(ctx context.Context) (int64, error) {
+	query := `
+WITH removed AS (
+	DELETE FROM panel_blacklist WHERE "expires_at" <= NOW()
+	RETURNING "guild_id", "panel_id", "user_id", "expires_at"
+)
+INSERT INTO blacklist_history("type", "guild_id", "panel_id", "subject_id", "action", "expires_at")
+SELECT 'panel_user', "guild_id", "panel_id", "user_id", 'expired', "expires_at"
+FROM removed;`
+
+	res, err := b.Exec(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected(), nil
+}
+
+func (b *PanelRoleBlacklist) GetBlacklistedRoles(ctx context.Context, guildId uint64, panelId int) ([]BlacklistEntry, error) {
+	query := `
+SELECT "role_id", "reason", "created_by", "created_at", "expires_at"
+FROM panel_role_blacklist
+WHERE "guild_id" = $1 AND "panel_id" = $2 AND ("expires_at" IS NULL OR "expires_at" > NOW())
+ORDER BY "created_at" DESC, "role_id";`
+
+	return queryBlacklistEntries(ctx, b.Pool, query, guildId, panelId)
+}
+
+// Add permanently blacklists the role from the panel, without a reason.
+func (b *PanelRoleBlacklist) Add(ctx context.Context, guildId uint64, panelId int, roleId uint64) (err error) {
+	return b.AddWithMetadata(ctx, guildId, panelId, roleId, BlacklistMetadata{})
+}
+
+// AddWithMetadata blacklists the role from the panel, replacing any existing blacklist, and records it in the role's
+// history. Nothing is written if the panel does not belong to the guild.
+func (b *PanelRoleBlacklist) AddWithMetadata(ctx context.Context, guildId uint64, panelId int, roleId uint64, metadata BlacklistMetadata) (err error) {
+	query := `
+WITH added AS (
+	INSERT INTO panel_role_blacklist("guild_id", "panel_id", "role_id", "reason", "created_by", "created_at", "expires_at")
+	SELECT "guild_id", "panel_id", $3, $4, $5, NOW(), $6
+	FROM panels
+	WHERE "panel_id" = $2 AND "guild_id" = $1
+	ON CONFLICT("panel_id", "role_id") DO UPDATE
+	SET "reason" = EXCLUDED."reason",
+		"created_by" = EXCLUDED."created_by",
+		"created_at" = EXCLUDED."created_at",
+		"expires_at" = EXCLUDED."expires_at"
+	RETURNING "guild_id", "panel_id", "role_id", "reason", "created_by", "expires_at"
+)
+INSERT INTO blacklist_history("type", "guild_id", "panel_id", "subject_id", "action", "reason", "actor_id", "expires_at")
+SELECT 'panel_role', "guild_id", "panel_id", "role_id", 'added', "reason", "created_by", "expires_at"
+FROM added;`
+
+	_, err = b.Exec(ctx, query, guildId, panelId, roleId, metadata.Reason, metadata.CreatedBy, metadata.ExpiresAt)
+	return
+}
+
+func (b *PanelRoleBlacklist) Remove(ctx context.Context, guildId uint64, panelId int, roleId uint64) (err error) {
+	return b.remove(ctx, guildId, panelId, roleId, nil)
+}
+
+// RemoveBy is the same as Remove, but records who removed the blacklist in the role's history.
+func (b *PanelRoleBlacklist) RemoveBy(ctx context.Context, guildId uint64, panelId int, roleId, removedBy uint64) (err error) {
+	return b.remove(ctx, guildId, panelId, roleId, &removedBy)
+}
+
+func (b *PanelRoleBlacklist) remove(ctx context.Context, guildId uint64, panelId int, roleId uint64, removedBy *uint64) (err error) {
+	query := `
+WITH removed AS (
+	DELETE FROM panel_role_blacklist WHERE "guild_id" = $1 AND "panel_id" = $2 AND "role_id" = $3
+	RETURNING "guild_id", "panel_id", "role_id", "expires_at"
+)
+INSERT INTO blacklist_history("type", "guild_id", "panel_id", "subject_id", "action", "actor_id", "expires_at")
+SELECT
+	'panel_role',
+	"guild_id",
+	"panel_id",
+	"role_id",
+	CASE WHEN "expires_at" <= NOW() THEN 'expired'::blacklist_action ELSE 'removed'::blacklist_action END,
+	CASE WHEN "expires_at" <= NOW() THEN NULL ELSE $4::int8 END,
+	"expires_at"
+FROM removed;`
+
+	_, err = b.Exec(ctx, query, guildId, panelId, roleId, removedBy)
+	return
+}
+
+// DeleteExpired removes all expired blacklists, recording their expiry in history, and returns the number removed.
+func (b *PanelRoleBlacklist) DeleteExpired(ctx context.Context) (int64, error) {
+	query := `
+WITH removed AS (
+	DELETE FROM panel_role_blacklist WHERE "expires_at" <= NOW()
+	RETURNING "guild_id", "panel_id", "role_id", "expires_at"
+)
+INSERT INTO blacklist_history("type", "guild_id", "panel_id", "subject_id", "action", "expires_at")
+SELECT 'panel_role', "guild_id", "panel_id", "role_id", 'expired', "expires_at"
+FROM removed;`
+
+	res, err := b.Exec(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected(), nil
+}
+
+func queryBlacklistEntries(ctx context.Context, db *pgxpool.Pool, query string, args ...interface{}) ([]BlacklistEntry, error) {
+	rows, err := db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var entries []BlacklistEntry
+	for rows.Next() {
+		var entry BlacklistEntry
+		if err := rows.Scan(&entry.Id, &entry.Reason, &entry.CreatedBy, &entry.CreatedAt, &entry.ExpiresAt); err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
@@ -2,16 +2,20 @@ package database
 
 import (
 	"context"
+	"errors"
+	"strconv"
 
 	"github.com/jackc/pgtype"
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
+var ErrPanelNotFound = errors.New("panel not found")
+
 // ALTER TABLE panels ADD COLUMN default_team bool NOT NULL DEFAULT 't';
 type Panel struct {
 	PanelId             int     `json:"panel_id"`
-	MessageId           uint64  `json:"message_id,string"`
+	MessageId           uint64  `json:"message_id,string"` // 0 if the panel has not been sent yet
 	ChannelId           uint64  `json:"channel_id,string"`
 	GuildId             uint64  `json:"guild_id,string"`
 	Title               string  `json:"title"`
@@ -55,7 +59,7 @@ func (p PanelTable) Schema() string {
 	return `
 CREATE TABLE IF NOT EXISTS panels(
 	"panel_id" SERIAL NOT NULL UNIQUE,
-	"message_id" int8 NOT NULL UNIQUE,
+	"message_id" int8 UNIQUE,
 	"channel_id" int8 NOT NULL,
 	"guild_id" int8 NOT NULL,
 	"title" varchar(255) NOT NULL,
@@ -86,14 +90,15 @@ CREATE INDEX IF NOT EXISTS panels_guild_id ON panels("guild_id");
 CREATE INDEX IF NOT EXISTS panels_message_id ON panels("message_id");
 CREATE INDEX IF NOT EXISTS panels_form_id ON panels("form_id");
 CREATE INDEX IF NOT EXISTS panels_guild_id_form_id ON panels("guild_id", "form_id");
-CREATE INDEX IF NOT EXISTS panels_custom_id ON panels("custom_id");`
+CREATE INDEX IF NOT EXISTS panels_custom_id ON panels("custom_id");
+ALTER TABLE panels ALTER COLUMN "message_id" DROP NOT NULL;`
 }
 
 func (p *PanelTable) Get(ctx context.Context, messageId uint64) (panel Panel, e error) {
 	query := `
 SELECT
 	panel_id,
-	message_id,
+	COALESCE(message_id, 0),
 	channel_id,
 	guild_id,
 	title,
@@ -131,7 +136,7 @@ func (p *PanelTable) GetById(ctx context.Context, panelId int) (panel Panel, e e
 	query := `
 SELECT
 	panel_id,
-	message_id,
+	COALESCE(message_id, 0),
 	channel_id,
 	guild_id,
 	title,
@@ -169,7 +174,7 @@ func (p *PanelTable) GetByCustomId(ctx context.Context, guildId uint64, customId
 	query := `
 SELECT
 	panel_id,
-	message_id,
+	COALESCE(message_id, 0),
 	channel_id,
 	guild_id,
 	title,
@@ -210,7 +215,7 @@ func (p *PanelTable) GetByFormId(ctx context.Context, guildId uint64, formId int
 	query := `
 SELECT
 	panel_id,
-	message_id,
+	COALESCE(message_id, 0),
 	channel_id,
 	guild_id,
 	title,
@@ -251,7 +256,7 @@ func (p *PanelTable) GetByFormCustomId(ctx context.Context, guildId uint64, cust
 	query := `
 SELECT
 	panels.panel_id,
-	panels.message_id,
+	COALESCE(panels.message_id, 0),
 	panels.channel_id,
 	panels.guild_id,
 	panels.title,
@@ -294,7 +299,7 @@ func (p *PanelTable) GetByGuild(ctx context.Context, guildId uint64) (panels []P
 	query := `
 SELECT
 	panel_id,
-	message_id,
+	COALESCE(message_id, 0),
 	channel_id, 
 	guild_id,
 	title,
@@ -342,7 +347,7 @@ func (p *PanelTable) GetByGuildWithWelcomeMessage(ctx context.Context, guildId u
 	query := `
 SELECT
 	panels.panel_id,
-	panels.message_id,
+	COALESCE(panels.message_id, 0),
 	panels.channel_id, 
 	panels.guild_id,
 	panels.title,
@@ -482,7 +487,7 @@ INSERT INTO panels(
     "exit_survey_form_id",
 	"pending_category"
 )
-VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22)
+VALUES(NULLIF($1::int8, 0), $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22)
 ON CONFLICT("message_id") DO NOTHING
 RETURNING "panel_id";`
 
@@ -511,6 +516,16 @@ RETURNING "panel_id";`
 		panel.PendingCategory,
 	).Scan(&panelId)
 
+	if err != nil {
+		return 0, err
+	}
+
+	panel.PanelId = panelId
+	if err := recordTemplatePlaceholders(ctx, tx, panel.GuildId, PanelTemplateSource(panelId), panel.Title, panel.Content); err != nil {
+		return 0, err
+	}
+
+	err = publishPanelEvent(ctx, tx, OutboxEventPanelCreated, panel.GuildId, panelId, &panel)
 	return
 }
 
@@ -532,7 +547,7 @@ func (p *PanelTable) Update(ctx context.Context, panel Panel) (err error) {
 func (p *PanelTable) UpdateWithTx(ctx context.Context, tx pgx.Tx, panel Panel) error {
 	query := `
 UPDATE panels
-	SET "message_id" = $2,
+	SET "message_id" = NULLIF($2::int8, 0),
 		"channel_id" = $3,
 		"title" = $4,
 		"content" = $5,
@@ -555,9 +570,11 @@ UPDATE panels
 	    "pending_category" = $22
 	WHERE
 		"panel_id" = $1
+	RETURNING "guild_id"
 ;`
 
-	_, err := tx.Exec(ctx, query,
+	var guildId uint64
+	err := tx.QueryRow(ctx, query,
 		panel.PanelId,
 		panel.MessageId,
 		panel.ChannelId,
@@ -580,31 +597,66 @@ UPDATE panels
 		panel.Disabled,
 		panel.ExitSurveyFormId,
 		panel.PendingCategory,
-	)
+	).Scan(&guildId)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
 
-	return err
+		return err
+	}
+
+	panel.GuildId = guildId
+	if err := recordTemplatePlaceholders(ctx, tx, guildId, PanelTemplateSource(panel.PanelId), panel.Title, panel.Content); err != nil {
+		return err
+	}
+
+	return publishPanelEvent(ctx, tx, OutboxEventPanelUpdated, guildId, panel.PanelId, &panel)
 }
 
 func (p *PanelTable) UpdateMessageId(ctx context.Context, panelId int, messageId uint64) (err error) {
 	query := `
 UPDATE panels
 SET "message_id" = $1
-WHERE "panel_id" = $2;
+WHERE "panel_id" = $2
+RETURNING ` + panelReturningColumns + `;
 `
 
-	_, err = p.Exec(ctx, query, messageId, panelId)
-	return
+	tx, err := p.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	if err := updatePanelsAndPublish(ctx, tx, query, messageId, panelId); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
 }
 
 func (p *PanelTable) EnableAll(ctx context.Context, guildId uint64) (err error) {
 	query := `
 UPDATE panels
 SET "force_disabled" = false
-WHERE "guild_id" = $1;
+WHERE "guild_id" = $1 AND "force_disabled" = true
+RETURNING ` + panelReturningColumns + `;
 `
 
-	_, err = p.Exec(ctx, query, guildId)
-	return
+	tx, err := p.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	if err := updatePanelsAndPublish(ctx, tx, query, guildId); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
 }
 
 func (p *PanelTable) DisableSome(ctx context.Context, guildId uint64, freeLimit int) error {
@@ -619,6 +671,8 @@ func (p *PanelTable) DisableSome(ctx context.Context, guildId uint64, freeLimit
 		return err
 	}
 
+	defer tx.Rollback(ctx)
+
 	var panelCount int
 	{
 		query := `SELECT COUNT(*) FROM panels WHERE guild_id = $1 and "force_disabled" = false;`
@@ -639,22 +693,28 @@ func (p *PanelTable) DisableSome(ctx context.Context, guildId uint64, freeLimit
 		for rows.Next() {
 			var panelId int
 			if err := rows.Scan(&panelId); err != nil {
+				rows.Close()
 				return err
 			}
 
 			toDisable = append(toDisable, panelId)
 		}
 
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
 		// Disable panels
 		if len(toDisable) > 0 {
-			query := `UPDATE panels SET "force_disabled" = true WHERE "panel_id" = ANY($1) AND "guild_id" = $2;`
+			query := `UPDATE panels SET "force_disabled" = true WHERE "panel_id" = ANY($1) AND "guild_id" = $2 RETURNING ` + panelReturningColumns + `;`
 
 			idArray := &pgtype.Int4Array{}
 			if err := idArray.Set(toDisable); err != nil {
 				return err
 			}
 
-			if _, err := tx.Exec(ctx, query, idArray, guildId); err != nil {
+			if err := updatePanelsAndPublish(ctx, tx, query, idArray, guildId); err != nil {
 				return err
 			}
 		}
@@ -663,10 +723,80 @@ func (p *PanelTable) DisableSome(ctx context.Context, guildId uint64, freeLimit
 	return tx.Commit(ctx)
 }
 
+// panelReturningColumns is in the same order as Panel.fieldPtrs
+const panelReturningColumns = `"panel_id", COALESCE("message_id", 0), "channel_id", "guild_id", "title", "content", "colour",
+	"target_category", "emoji_name", "emoji_id", "welcome_message", "default_team", "custom_id", "image_url",
+	"thumbnail_url", "button_style", "button_label", "form_id", "naming_scheme", "force_disabled", "disabled",
+	"exit_survey_form_id", "pending_category"`
+
+// updatePanelsAndPublish runs an UPDATE ... RETURNING panelReturningColumns statement, and publishes an update event
+// for each panel that was modified
+func updatePanelsAndPublish(ctx context.Context, tx pgx.Tx, query string, args ...interface{}) error {
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	var panels []Panel
+	for rows.Next() {
+		var panel Panel
+		if err := rows.Scan(panel.fieldPtrs()...); err != nil {
+			rows.Close()
+			return err
+		}
+
+		panels = append(panels, panel)
+	}
+
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range panels {
+		panel := panels[i]
+		if err := publishPanelEvent(ctx, tx, OutboxEventPanelUpdated, panel.GuildId, panel.PanelId, &panel); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (p *PanelTable) Delete(ctx context.Context, panelId int) (err error) {
-	query := `DELETE FROM panels WHERE "panel_id"=$1;`
-	_, err = p.Exec(ctx, query, panelId)
-	return
+	query := `DELETE FROM panels WHERE "panel_id"=$1 RETURNING "guild_id";`
+
+	tx, err := p.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	var guildId uint64
+	if err := tx.QueryRow(ctx, query, panelId).Scan(&guildId); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+
+		return err
+	}
+
+	if err := publishPanelEvent(ctx, tx, OutboxEventPanelDeleted, guildId, panelId, nil); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func publishPanelEvent(ctx context.Context, tx pgx.Tx, eventType OutboxEventType, guildId uint64, panelId int, panel *Panel) error {
+	payload := PanelEventPayload{
+		GuildId: guildId,
+		PanelId: panelId,
+		Panel:   panel,
+	}
+
+	return publishOutboxEvent(ctx, tx, OutboxAggregatePanel, strconv.Itoa(panelId), &guildId, eventType, payload)
 }
 
 func (p *Panel) fieldPtrs() []interface{} {
@@ -101,6 +101,7 @@ SELECT EXISTS(
 	return
 }
 
+// Deprecated: Use Database.ImportTicketData, which can be safely re-run if an import fails.
 func (p *ParticipantTable) ImportBulk(ctx context.Context, guildId uint64, participantMap map[int][]uint64) (err error) {
 	rows := make([][]interface{}, 0)
 
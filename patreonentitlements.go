@@ -42,8 +42,16 @@ func (e PatreonEntitlements) Schema() string {
 }
 
 func (e *PatreonEntitlements) Insert(ctx context.Context, tx pgx.Tx, entitlementId uuid.UUID, userId uint64) error {
-	_, err := tx.Exec(ctx, patreonEntitlementsInsert, entitlementId, userId)
-	return err
+	res, err := tx.Exec(ctx, patreonEntitlementsInsert, entitlementId, userId)
+	if err != nil {
+		return err
+	}
+
+	if res.RowsAffected() == 0 {
+		return nil
+	}
+
+	return publishEntitlementEventById(ctx, tx, OutboxEventEntitlementUpdated, entitlementId)
 }
 
 func (e *PatreonEntitlements) ListByUser(ctx context.Context, tx pgx.Tx, userId uint64) ([]model.Entitlement, error) {
@@ -75,11 +83,42 @@ func (e *PatreonEntitlements) ListByUser(ctx context.Context, tx pgx.Tx, userId
 }
 
 func (e *PatreonEntitlements) Delete(ctx context.Context, tx pgx.Tx, entitlementId uuid.UUID) error {
-	_, err := tx.Exec(ctx, patreonEntitlementsDelete, entitlementId)
-	return err
+	return deleteEntitlementLinks(ctx, tx, patreonEntitlementsDelete, entitlementId)
 }
 
 func (e *PatreonEntitlements) DeleteByUser(ctx context.Context, tx pgx.Tx, userId uint64) error {
-	_, err := tx.Exec(ctx, patreonEntitlementsDeleteByUser, userId)
-	return err
+	return deleteEntitlementLinks(ctx, tx, patreonEntitlementsDeleteByUser, userId)
+}
+
+// deleteEntitlementLinks runs a DELETE ... RETURNING entitlement_id statement, and publishes an update event for each
+// entitlement that was unlinked
+func deleteEntitlementLinks(ctx context.Context, tx pgx.Tx, query string, args ...interface{}) error {
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	var entitlementIds []uuid.UUID
+	for rows.Next() {
+		var entitlementId uuid.UUID
+		if err := rows.Scan(&entitlementId); err != nil {
+			rows.Close()
+			return err
+		}
+
+		entitlementIds = append(entitlementIds, entitlementId)
+	}
+
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, entitlementId := range entitlementIds {
+		if err := publishEntitlementEventById(ctx, tx, OutboxEventEntitlementUpdated, entitlementId); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
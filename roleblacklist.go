@@ -18,11 +18,31 @@ func newRoleBlacklist(db *pgxpool.Pool) *RoleBlacklist {
 }
 
 func (b RoleBlacklist) Schema() string {
-	return `CREATE TABLE IF NOT EXISTS role_blacklist("guild_id" int8 NOT NULL, "role_id" int8 NOT NULL, PRIMARY KEY("guild_id", "role_id"));`
+	return `
+CREATE TABLE IF NOT EXISTS role_blacklist(
+	"guild_id" int8 NOT NULL,
+	"role_id" int8 NOT NULL,
+	"reason" TEXT,
+	"created_by" int8,
+	"created_at" timestamptz NOT NULL DEFAULT NOW(),
+	"expires_at" timestamptz,
+	PRIMARY KEY("guild_id", "role_id")
+);
+ALTER TABLE role_blacklist ADD COLUMN IF NOT EXISTS "reason" TEXT;
+ALTER TABLE role_blacklist ADD COLUMN IF NOT EXISTS "created_by" int8;
+ALTER TABLE role_blacklist ADD COLUMN IF NOT EXISTS "created_at" timestamptz NOT NULL DEFAULT NOW();
+ALTER TABLE role_blacklist ADD COLUMN IF NOT EXISTS "expires_at" timestamptz;
+CREATE INDEX IF NOT EXISTS role_blacklist_expires_at ON role_blacklist("expires_at") WHERE "expires_at" IS NOT NULL;
+`
 }
 
 func (b *RoleBlacklist) IsBlacklisted(ctx context.Context, guildId, roleId uint64) (blacklisted bool, e error) {
-	query := `SELECT EXISTS(SELECT 1 FROM role_blacklist WHERE "guild_id"=$1 AND "role_id"=$2);`
+	query := `
+SELECT EXISTS(
+	SELECT 1
+	FROM role_blacklist
+	WHERE "guild_id"=$1 AND "role_id"=$2 AND ("expires_at" IS NULL OR "expires_at" > NOW())
+);`
 	if err := b.QueryRow(ctx, query, guildId, roleId).Scan(&blacklisted); err != nil {
 		e = err
 	}
@@ -31,7 +51,12 @@ func (b *RoleBlacklist) IsBlacklisted(ctx context.Context, guildId, roleId uint6
 }
 
 func (b *RoleBlacklist) IsAnyBlacklisted(ctx context.Context, guildId uint64, roles []uint64) (blacklisted bool, e error) {
-	query := `SELECT EXISTS(SELECT 1 FROM role_blacklist WHERE "guild_id"=$1 AND "role_id"=ANY($2));`
+	query := `
+SELECT EXISTS(
+	SELECT 1
+	FROM role_blacklist
+	WHERE "guild_id"=$1 AND "role_id"=ANY($2) AND ("expires_at" IS NULL OR "expires_at" > NOW())
+);`
 
 	array := &pgtype.Int8Array{}
 	if err := array.Set(roles); err != nil {
@@ -46,44 +71,139 @@ func (b *RoleBlacklist) IsAnyBlacklisted(ctx context.Context, guildId uint64, ro
 }
 
 func (b *RoleBlacklist) GetBlacklistedRoles(ctx context.Context, guildId uint64) (roles []uint64, e error) {
-	query := `SELECT "role_id" FROM role_blacklist WHERE "guild_id" = $1;`
+	entries, err := b.GetBlacklistedRoleEntries(ctx, guildId)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		roles = append(roles, entry.Id)
+	}
+
+	return
+}
+
+func (b *RoleBlacklist) GetBlacklistedRoleEntries(ctx context.Context, guildId uint64) (roles []BlacklistEntry, e error) {
+	query := `
+SELECT "role_id", "reason", "created_by", "created_at", "expires_at"
+FROM role_blacklist
+WHERE "guild_id" = $1 AND ("expires_at" IS NULL OR "expires_at" > NOW());`
 
 	rows, err := b.Query(ctx, query, guildId)
-	defer rows.Close()
 	if err != nil {
 		e = err
 		return
 	}
 
+	defer rows.Close()
+
 	for rows.Next() {
-		var roleId uint64
-		if err := rows.Scan(&roleId); err != nil {
+		var entry BlacklistEntry
+		if err := rows.Scan(&entry.Id, &entry.Reason, &entry.CreatedBy, &entry.CreatedAt, &entry.ExpiresAt); err != nil {
 			e = err
 			continue
 		}
 
-		roles = append(roles, roleId)
+		roles = append(roles, entry)
 	}
 
 	return
 }
 
 func (b *RoleBlacklist) GetBlacklistedCount(ctx context.Context, guildId uint64) (count int, err error) {
-	query := `SELECT COUNT(*) FROM role_blacklist WHERE "guild_id" = $1;`
+	query := `SELECT COUNT(*) FROM role_blacklist WHERE "guild_id" = $1 AND ("expires_at" IS NULL OR "expires_at" > NOW());`
 
 	err = b.QueryRow(ctx, query, guildId).Scan(&count)
 	return
 }
 
+// Add permanently blacklists the role, without a reason. An existing blacklist is left unchanged, unless it has expired
+// and not yet been deleted by DeleteExpired, in which case it is replaced.
 func (b *RoleBlacklist) Add(ctx context.Context, guildId, roleId uint64) (err error) {
-	// on conflict, role is already role_blacklist
-	query := `INSERT INTO role_blacklist("guild_id", "role_id") VALUES($1, $2) ON CONFLICT DO NOTHING;`
+	query := `
+WITH added AS (
+	INSERT INTO role_blacklist("guild_id", "role_id") VALUES($1, $2)
+	ON CONFLICT("guild_id", "role_id") DO UPDATE
+	SET "reason" = NULL,
+		"created_by" = NULL,
+		"created_at" = NOW(),
+		"expires_at" = NULL
+	WHERE role_blacklist."expires_at" <= NOW()
+	RETURNING "guild_id", "role_id"
+)
+INSERT INTO blacklist_history("type", "guild_id", "subject_id", "action")
+SELECT 'role', "guild_id", "role_id", 'added'
+FROM added;`
+
 	_, err = b.Exec(ctx, query, guildId, roleId)
 	return
 }
 
+// AddWithMetadata blacklists the role, replacing any existing blacklist, and records it in the role's history.
+func (b *RoleBlacklist) AddWithMetadata(ctx context.Context, guildId, roleId uint64, metadata BlacklistMetadata) (err error) {
+	query := `
+WITH added AS (
+	INSERT INTO role_blacklist("guild_id", "role_id", "reason", "created_by", "created_at", "expires_at")
+	VALUES($1, $2, $3, $4, NOW(), $5)
+	ON CONFLICT("guild_id", "role_id") DO UPDATE
+	SET "reason" = EXCLUDED."reason",
+		"created_by" = EXCLUDED."created_by",
+		"created_at" = EXCLUDED."created_at",
+		"expires_at" = EXCLUDED."expires_at"
+	RETURNING "guild_id", "role_id", "reason", "created_by", "expires_at"
+)
+INSERT INTO blacklist_history("type", "guild_id", "subject_id", "action", "reason", "actor_id", "expires_at")
+SELECT 'role', "guild_id", "role_id", 'added', "reason", "created_by", "expires_at"
+FROM added;`
+
+	_, err = b.Exec(ctx, query, guildId, roleId, metadata.Reason, metadata.CreatedBy, metadata.ExpiresAt)
+	return
+}
+
 func (b *RoleBlacklist) Remove(ctx context.Context, guildId, roleId uint64) (err error) {
-	query := `DELETE FROM role_blacklist WHERE "guild_id"=$1 AND "role_id"=$2;`
-	_, err = b.Exec(ctx, query, guildId, roleId)
+	return b.remove(ctx, guildId, roleId, nil)
+}
+
+// RemoveBy is the same as Remove, but records who removed the blacklist in the role's history.
+func (b *RoleBlacklist) RemoveBy(ctx context.Context, guildId, roleId, removedBy uint64) (err error) {
+	return b.remove(ctx, guildId, roleId, &removedBy)
+}
+
+func (b *RoleBlacklist) remove(ctx context.Context, guildId, roleId uint64, removedBy *uint64) (err error) {
+	query := `
+WITH removed AS (
+	DELETE FROM role_blacklist WHERE "guild_id"=$1 AND "role_id"=$2
+	RETURNING "guild_id", "role_id", "expires_at"
+)
+INSERT INTO blacklist_history("type", "guild_id", "subject_id", "action", "actor_id", "expires_at")
+SELECT
+	'role',
+	"guild_id",
+	"role_id",
+	CASE WHEN "expires_at" <= NOW() THEN 'expired'::blacklist_action ELSE 'removed'::blacklist_action END,
+	CASE WHEN "expires_at" <= NOW() THEN NULL ELSE $3::int8 END,
+	"expires_at"
+FROM removed;`
+
+	_, err = b.Exec(ctx, query, guildId, roleId, removedBy)
 	return
 }
+
+// DeleteExpired removes all expired blacklists, recording their expiry in history, and returns the number removed.
+func (b *RoleBlacklist) DeleteExpired(ctx context.Context) (int64, error) {
+	query := `
+WITH removed AS (
+	DELETE FROM role_blacklist WHERE "expires_at" <= NOW()
+	RETURNING "guild_id", "role_id", "expires_at"
+)
+INSERT INTO blacklist_history("type", "guild_id", "subject_id", "action", "expires_at")
+SELECT 'role', "guild_id", "role_id", 'expired', "expires_at"
+FROM removed;`
+
+	res, err := b.Exec(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected(), nil
+}
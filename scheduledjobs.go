@@ -0,0 +1,376 @@
+package database
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	jsoniter "github.com/json-iterator/go"
+)
+
+// ScheduledJobs is a generic store for actions that should be performed at a later time, optionally on a recurring
+// basis. Jobs are identified by (guild_id, job_type, job_key): scheduling a job with an existing key replaces it, which
+// allows e.g. a reminder to be pushed back each time the user replies.
+//
+// Workers claim due jobs with ClaimDue, and must then call Complete or Fail before the lease expires.
+type ScheduledJobs struct {
+	*pgxpool.Pool
+}
+
+type ScheduledJobType string
+
+const (
+	ScheduledJobReminder    ScheduledJobType = "reminder"
+	ScheduledJobReopen      ScheduledJobType = "reopen"
+	ScheduledJobCloseTicket ScheduledJobType = "close_ticket"
+)
+
+type ScheduledJob struct {
+	Id            int64               `json:"id"`
+	GuildId       uint64              `json:"guild_id,string"`
+	TicketId      *int                `json:"ticket_id"`
+	Type          ScheduledJobType    `json:"type"`
+	Key           string              `json:"key"`
+	Payload       jsoniter.RawMessage `json:"payload"`
+	RunAt         time.Time           `json:"run_at"`
+	RecurInterval *time.Duration      `json:"recur_interval"`
+	Attempts      int                 `json:"attempts"`
+	LastError     *string             `json:"last_error"`
+	DeadAt        *time.Time          `json:"dead_at"`
+	CreatedAt     time.Time           `json:"created_at"`
+	LeaseId       uuid.UUID           `json:"-"` // Only set on jobs returned by ClaimDue
+}
+
+// ReminderJobPayload is used to ping a user if there has been no reply to a ticket. The job should be cancelled, or
+// rescheduled, when a reply is received.
+type ReminderJobPayload struct {
+	UserId  uint64  `json:"user_id,string"`
+	Message *string `json:"message"`
+}
+
+type ReopenJobPayload struct {
+	RequestedBy uint64  `json:"requested_by,string"`
+	Reason      *string `json:"reason"`
+}
+
+type CloseTicketJobPayload struct {
+	RequestedBy uint64  `json:"requested_by,string"`
+	Reason      *string `json:"reason"`
+}
+
+var (
+	//go:embed sql/scheduled_jobs/schema.sql
+	scheduledJobsSchema string
+
+	//go:embed sql/scheduled_jobs/schedule.sql
+	scheduledJobsSchedule string
+
+	//go:embed sql/scheduled_jobs/get.sql
+	scheduledJobsGet string
+
+	//go:embed sql/scheduled_jobs/get_by_ticket.sql
+	scheduledJobsGetByTicket string
+
+	//go:embed sql/scheduled_jobs/cancel.sql
+	scheduledJobsCancel string
+
+	//go:embed sql/scheduled_jobs/cancel_by_ticket.sql
+	scheduledJobsCancelByTicket string
+
+	//go:embed sql/scheduled_jobs/dead_letter_expired.sql
+	scheduledJobsDeadLetterExpired string
+
+	//go:embed sql/scheduled_jobs/claim_due.sql
+	scheduledJobsClaimDue string
+
+	//go:embed sql/scheduled_jobs/complete.sql
+	scheduledJobsComplete string
+
+	//go:embed sql/scheduled_jobs/fail.sql
+	scheduledJobsFail string
+
+	//go:embed sql/scheduled_jobs/list_dead.sql
+	scheduledJobsListDead string
+
+	//go:embed sql/scheduled_jobs/requeue_dead.sql
+	scheduledJobsRequeueDead string
+)
+
+func newScheduledJobs(db *pgxpool.Pool) *ScheduledJobs {
+	return &ScheduledJobs{
+		db,
+	}
+}
+
+func (ScheduledJobs) Schema() string {
+	return scheduledJobsSchema
+}
+
+// TicketJobKey is the conventional key for jobs of which there should only be one per ticket.
+func TicketJobKey(ticketId int) string {
+	return strconv.Itoa(ticketId)
+}
+
+// NewScheduledJob serialises the payload and builds a job ready to be scheduled.
+func NewScheduledJob(guildId uint64, ticketId *int, jobType ScheduledJobType, key string, runAt time.Time, payload any) (ScheduledJob, error) {
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return ScheduledJob{}, err
+	}
+
+	return ScheduledJob{
+		GuildId:  guildId,
+		TicketId: ticketId,
+		Type:     jobType,
+		Key:      key,
+		Payload:  encoded,
+		RunAt:    runAt,
+	}, nil
+}
+
+// DecodeScheduledJobPayload unmarshals the payload of a job into the type the job was scheduled with.
+func DecodeScheduledJobPayload[T any](job ScheduledJob) (payload T, err error) {
+	err = json.Unmarshal(job.Payload, &payload)
+	return
+}
+
+// Schedule creates the job, or replaces the existing job with the same key, returning the job ID.
+func (s *ScheduledJobs) Schedule(ctx context.Context, job ScheduledJob) (int64, error) {
+	tx, err := s.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	id, err := s.ScheduleWithTx(ctx, tx, job)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, tx.Commit(ctx)
+}
+
+func (s *ScheduledJobs) ScheduleWithTx(ctx context.Context, tx pgx.Tx, job ScheduledJob) (id int64, err error) {
+	if job.RecurInterval != nil && *job.RecurInterval <= 0 {
+		return 0, errors.New("recur interval must be positive")
+	}
+
+	payload := string(job.Payload)
+	if len(job.Payload) == 0 {
+		payload = "{}"
+	}
+
+	var createdAt time.Time
+	err = tx.QueryRow(ctx, scheduledJobsSchedule,
+		job.GuildId,
+		job.TicketId,
+		job.Type,
+		job.Key,
+		payload,
+		job.RunAt,
+		job.RecurInterval,
+	).Scan(&id, &createdAt)
+
+	return
+}
+
+func (s *ScheduledJobs) Get(ctx context.Context, guildId uint64, jobType ScheduledJobType, key string) (ScheduledJob, bool, error) {
+	job, err := scanScheduledJob(s.QueryRow(ctx, scheduledJobsGet, guildId, jobType, key))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ScheduledJob{}, false, nil
+		}
+
+		return ScheduledJob{}, false, err
+	}
+
+	return job, true, nil
+}
+
+func (s *ScheduledJobs) GetByTicket(ctx context.Context, guildId uint64, ticketId int) ([]ScheduledJob, error) {
+	return s.queryJobs(ctx, scheduledJobsGetByTicket, guildId, ticketId)
+}
+
+// Cancel removes the job with the given key, returning false if no such job existed.
+func (s *ScheduledJobs) Cancel(ctx context.Context, guildId uint64, jobType ScheduledJobType, key string) (bool, error) {
+	res, err := s.Exec(ctx, scheduledJobsCancel, guildId, jobType, key)
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() > 0, nil
+}
+
+func (s *ScheduledJobs) CancelWithTx(ctx context.Context, tx pgx.Tx, guildId uint64, jobType ScheduledJobType, key string) (bool, error) {
+	res, err := tx.Exec(ctx, scheduledJobsCancel, guildId, jobType, key)
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() > 0, nil
+}
+
+// CancelByTicket removes all jobs for a ticket of the given types, or of any type if none are given.
+func (s *ScheduledJobs) CancelByTicket(ctx context.Context, guildId uint64, ticketId int, jobTypes ...ScheduledJobType) (int64, error) {
+	types, err := scheduledJobTypeArray(jobTypes)
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := s.Exec(ctx, scheduledJobsCancelByTicket, guildId, ticketId, types)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected(), nil
+}
+
+// ClaimDue leases up to limit due jobs of the given types, hiding them from other workers for leaseDuration. Jobs which
+// have already been attempted maxAttempts times are moved to the dead-letter state instead of being claimed again.
+func (s *ScheduledJobs) ClaimDue(
+	ctx context.Context,
+	jobTypes []ScheduledJobType,
+	limit int,
+	leaseDuration time.Duration,
+	maxAttempts int,
+) ([]ScheduledJob, error) {
+	types, err := scheduledJobTypeArray(jobTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.Exec(ctx, scheduledJobsDeadLetterExpired, types, maxAttempts); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.Query(ctx, scheduledJobsClaimDue, types, limit, leaseDuration, maxAttempts)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var jobs []ScheduledJob
+	for rows.Next() {
+		var job ScheduledJob
+		var payload string
+		if err := rows.Scan(
+			&job.Id,
+			&job.GuildId,
+			&job.TicketId,
+			&job.Type,
+			&job.Key,
+			&payload,
+			&job.RunAt,
+			&job.RecurInterval,
+			&job.Attempts,
+			&job.LastError,
+			&job.DeadAt,
+			&job.CreatedAt,
+			&job.LeaseId,
+		); err != nil {
+			return nil, err
+		}
+
+		job.Payload = jsoniter.RawMessage(payload)
+		jobs = append(jobs, job)
+	}
+
+	return jobs, rows.Err()
+}
+
+// Complete marks a claimed job as done. One-off jobs are deleted, while recurring jobs are rescheduled for their next
+// occurrence. Returns false if the lease had expired and the job was claimed by another worker, or if the job was
+// cancelled or rescheduled in the meantime.
+func (s *ScheduledJobs) Complete(ctx context.Context, job ScheduledJob) (bool, error) {
+	var affected int
+	if err := s.QueryRow(ctx, scheduledJobsComplete, job.Id, job.LeaseId).Scan(&affected); err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+// Fail releases the lease on a claimed job, making it available again after retryDelay. If the job has reached
+// maxAttempts, it is moved to the dead-letter state instead.
+func (s *ScheduledJobs) Fail(ctx context.Context, job ScheduledJob, retryDelay time.Duration, maxAttempts int, errorMessage string) error {
+	_, err := s.Exec(ctx, scheduledJobsFail, job.Id, job.LeaseId, retryDelay, maxAttempts, errorMessage)
+	return err
+}
+
+func (s *ScheduledJobs) ListDead(ctx context.Context, limit int) ([]ScheduledJob, error) {
+	return s.queryJobs(ctx, scheduledJobsListDead, limit)
+}
+
+// RequeueDead makes a dead-lettered job due immediately, with its attempt count reset.
+func (s *ScheduledJobs) RequeueDead(ctx context.Context, id int64) (bool, error) {
+	res, err := s.Exec(ctx, scheduledJobsRequeueDead, id)
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() > 0, nil
+}
+
+func (s *ScheduledJobs) queryJobs(ctx context.Context, query string, args ...any) ([]ScheduledJob, error) {
+	rows, err := s.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var jobs []ScheduledJob
+	for rows.Next() {
+		job, err := scanScheduledJob(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		jobs = append(jobs, job)
+	}
+
+	return jobs, rows.Err()
+}
+
+func scanScheduledJob(row pgx.Row) (job ScheduledJob, err error) {
+	var payload string
+	err = row.Scan(
+		&job.Id,
+		&job.GuildId,
+		&job.TicketId,
+		&job.Type,
+		&job.Key,
+		&payload,
+		&job.RunAt,
+		&job.RecurInterval,
+		&job.Attempts,
+		&job.LastError,
+		&job.DeadAt,
+		&job.CreatedAt,
+	)
+
+	job.Payload = jsoniter.RawMessage(payload)
+	return
+}
+
+func scheduledJobTypeArray(jobTypes []ScheduledJobType) (*pgtype.VarcharArray, error) {
+	raw := make([]string, len(jobTypes))
+	for i, jobType := range jobTypes {
+		raw[i] = string(jobType)
+	}
+
+	array := &pgtype.VarcharArray{}
+	if err := array.Set(raw); err != nil {
+		return nil, err
+	}
+
+	return array, nil
+}
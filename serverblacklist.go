@@ -19,11 +19,24 @@ func newServerBlacklist(db *pgxpool.Pool) *ServerBlacklist {
 }
 
 func (b ServerBlacklist) Schema() string {
-	return `CREATE TABLE IF NOT EXISTS server_blacklist("guild_id" int8 NOT NULL UNIQUE, PRIMARY KEY("guild_id"));`
+	return `
+CREATE TABLE IF NOT EXISTS server_blacklist(
+	"guild_id" int8 NOT NULL UNIQUE,
+	"reason" TEXT,
+	"created_by" int8,
+	"created_at" timestamptz NOT NULL DEFAULT NOW(),
+	"expires_at" timestamptz,
+	PRIMARY KEY("guild_id")
+);
+ALTER TABLE server_blacklist ADD COLUMN IF NOT EXISTS "reason" TEXT;
+ALTER TABLE server_blacklist ADD COLUMN IF NOT EXISTS "created_by" int8;
+ALTER TABLE server_blacklist ADD COLUMN IF NOT EXISTS "created_at" timestamptz NOT NULL DEFAULT NOW();
+ALTER TABLE server_blacklist ADD COLUMN IF NOT EXISTS "expires_at" timestamptz;
+`
 }
 
 func (b *ServerBlacklist) IsBlacklisted(ctx context.Context, guildId uint64) (bool, *string, error) {
-	query := `SELECT "reason" FROM server_blacklist WHERE "guild_id" = $1;`
+	query := `SELECT "reason" FROM server_blacklist WHERE "guild_id" = $1 AND ("expires_at" IS NULL OR "expires_at" > NOW());`
 
 	var reason *string
 	if err := b.QueryRow(ctx, query, guildId).Scan(&reason); err != nil {
@@ -38,13 +51,15 @@ func (b *ServerBlacklist) IsBlacklisted(ctx context.Context, guildId uint64) (bo
 }
 
 func (b *ServerBlacklist) ListAll(ctx context.Context) ([]uint64, error) {
-	query := `SELECT "guild_id" FROM server_blacklist;`
+	query := `SELECT "guild_id" FROM server_blacklist WHERE "expires_at" IS NULL OR "expires_at" > NOW();`
 
 	rows, err := b.Query(ctx, query)
 	if err != nil {
 		return nil, err
 	}
 
+	defer rows.Close()
+
 	var guilds []uint64
 	for rows.Next() {
 		var guildId uint64
@@ -58,13 +73,84 @@ func (b *ServerBlacklist) ListAll(ctx context.Context) ([]uint64, error) {
 	return guilds, nil
 }
 
+// Add permanently blacklists the guild. If the guild is already blacklisted, only the reason is updated, and no history
+// is recorded, unless the blacklist has expired and not yet been deleted by DeleteExpired, in which case it is replaced.
 func (b *ServerBlacklist) Add(ctx context.Context, guildId uint64, reason *string) (err error) {
-	query := `INSERT INTO server_blacklist("guild_id", "reason") VALUES($1, $2) ON CONFLICT("guild_id") DO UPDATE SET "reason" = $2`
+	query := `
+WITH expired AS (
+	SELECT 1 FROM server_blacklist WHERE "guild_id" = $1 AND "expires_at" <= NOW()
+), added AS (
+	INSERT INTO server_blacklist("guild_id", "reason") VALUES($1, $2)
+	ON CONFLICT("guild_id") DO UPDATE
+	SET "reason" = EXCLUDED."reason",
+		"created_by" = CASE WHEN EXISTS(SELECT 1 FROM expired) THEN NULL ELSE server_blacklist."created_by" END,
+		"created_at" = CASE WHEN EXISTS(SELECT 1 FROM expired) THEN NOW() ELSE server_blacklist."created_at" END,
+		"expires_at" = CASE WHEN EXISTS(SELECT 1 FROM expired) THEN NULL ELSE server_blacklist."expires_at" END
+	RETURNING "guild_id", "reason", xmax = 0 AS "inserted"
+)
+INSERT INTO blacklist_history("type", "subject_id", "action", "reason")
+SELECT 'server', "guild_id", 'added', "reason"
+FROM added
+WHERE "inserted" OR EXISTS(SELECT 1 FROM expired);`
+
 	_, err = b.Exec(ctx, query, guildId, reason)
 	return
 }
 
+// AddWithMetadata blacklists the guild, replacing any existing blacklist, and records it in the guild's history.
+func (b *ServerBlacklist) AddWithMetadata(ctx context.Context, guildId uint64, metadata BlacklistMetadata) (err error) {
+	query := `
+WITH added AS (
+	INSERT INTO server_blacklist("guild_id", "reason", "created_by", "created_at", "expires_at")
+	VALUES($1, $2, $3, NOW(), $4)
+	ON CONFLICT("guild_id") DO UPDATE
+	SET "reason" = EXCLUDED."reason",
+		"created_by" = EXCLUDED."created_by",
+		"created_at" = EXCLUDED."created_at",
+		"expires_at" = EXCLUDED."expires_at"
+	RETURNING "guild_id", "reason", "created_by", "expires_at"
+)
+INSERT INTO blacklist_history("type", "subject_id", "action", "reason", "actor_id", "expires_at")
+SELECT 'server', "guild_id", 'added', "reason", "created_by", "expires_at"
+FROM added;`
+
+	_, err = b.Exec(ctx, query, guildId, metadata.Reason, metadata.CreatedBy, metadata.ExpiresAt)
+	return
+}
+
 func (b *ServerBlacklist) Delete(ctx context.Context, guildId uint64) (err error) {
-	_, err = b.Exec(ctx, `DELETE FROM server_blacklist WHERE "guild_id" = $1;`, guildId)
+	query := `
+WITH removed AS (
+	DELETE FROM server_blacklist WHERE "guild_id" = $1
+	RETURNING "guild_id", "expires_at"
+)
+INSERT INTO blacklist_history("type", "subject_id", "action", "expires_at")
+SELECT
+	'server',
+	"guild_id",
+	CASE WHEN "expires_at" <= NOW() THEN 'expired'::blacklist_action ELSE 'removed'::blacklist_action END,
+	"expires_at"
+FROM removed;`
+
+	_, err = b.Exec(ctx, query, guildId)
 	return
 }
+
+// DeleteExpired removes all expired blacklists, recording their expiry in history, and returns the number removed.
+func (b *ServerBlacklist) DeleteExpired(ctx context.Context) (int64, error) {
+	query := `
+WITH removed AS (
+	DELETE FROM server_blacklist WHERE "expires_at" <= NOW()
+	RETURNING "guild_id", "expires_at"
+)
+INSERT INTO blacklist_history("type", "subject_id", "action", "expires_at")
+SELECT 'server', "guild_id", 'expired', "expires_at"
+FROM removed;`
+
+	res, err := b.Exec(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected(), nil
+}
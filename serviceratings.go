@@ -2,6 +2,7 @@ package database
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/jackc/pgx/pgtype"
 	"github.com/jackc/pgx/v4"
@@ -96,6 +97,25 @@ WHERE service_ratings.guild_id = $1 AND ticket_claims.user_id = $2;
 	return
 }
 
+// GetAverageClaimedByAttribution is the same as GetAverageClaimedBy, but attributes tickets using the given attribution.
+// With ClaimAttributionTimeWeighted, each rating is weighted by the share of time the user held the ticket.
+func (r *ServiceRatings) GetAverageClaimedByAttribution(ctx context.Context, guildId, userId uint64, attribution ClaimAttribution) (average float32, err error) {
+	weights, err := claimWeightsQuery(attribution)
+	if err != nil {
+		return 0, err
+	}
+
+	// Returns NULL if no tickets claimed
+	var f *float32
+
+	err = r.QueryRow(ctx, fmt.Sprintf(ticketClaimsGetAttributedAverageRating, weights), guildId, userId).Scan(&f)
+	if f != nil {
+		average = *f
+	}
+
+	return
+}
+
 func (r *ServiceRatings) GetMulti(ctx context.Context, guildId uint64, ticketIds []int) (map[int]uint8, error) {
 	query := `SELECT "ticket_id", "rating" from service_ratings WHERE "guild_id" = $1 AND "ticket_id" = ANY($2);`
 
@@ -150,6 +170,7 @@ func (r *ServiceRatings) GetRange(ctx context.Context, guildId uint64, lowerId,
 	return ratings, nil
 }
 
+// Deprecated: Use Database.ImportTicketData, which can be safely re-run if an import fails.
 func (r *ServiceRatings) ImportBulk(ctx context.Context, guildId uint64, ratings map[int]uint8) (err error) {
 	rows := make([][]interface{}, 0)
 
@@ -121,6 +121,21 @@ WHERE "guild_id" = $1;
 }
 
 func (s *SettingsTable) Set(ctx context.Context, guildId uint64, settings Settings) (err error) {
+	tx, err := s.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	if err := s.SetWithTx(ctx, tx, guildId, settings); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (s *SettingsTable) SetWithTx(ctx context.Context, tx pgx.Tx, guildId uint64, settings Settings) (err error) {
 	query := `
 INSERT INTO settings(
 	"guild_id",
@@ -155,7 +170,7 @@ DO UPDATE SET
 ;
 `
 
-	_, err = s.Exec(ctx, query,
+	_, err = tx.Exec(ctx, query,
 		guildId,
 		settings.HideClaimButton,
 		settings.DisableOpenCommand,
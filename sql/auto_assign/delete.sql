@@ -0,0 +1,3 @@
+DELETE
+FROM auto_assign_settings
+WHERE guild_id = $1 AND id = $2;
@@ -0,0 +1,4 @@
+SELECT id, panel_id, team_id, strategy, enabled
+FROM auto_assign_settings
+WHERE guild_id = $1
+ORDER BY id ASC;
@@ -0,0 +1,5 @@
+SELECT user_id, COUNT(*), MAX(assigned_at)
+FROM auto_assign_history
+WHERE guild_id = $1 AND assigned_at >= $2
+GROUP BY user_id
+ORDER BY COUNT(*) DESC, user_id;
@@ -0,0 +1,73 @@
+-- A member is a candidate if they are manually toggled on call, or scheduled by a guild-wide rotation or a rotation for
+-- the team that makes them a candidate, and have not toggled off since their shift started. Members who already have as
+-- many open claims as their claim capacity allows, or who are in $7, are not candidates.
+WITH scheduled (rotation_id, team_id, user_id, is_override, shift_start, shift_end) AS (
+    %s
+), on_call_members AS (
+    SELECT DISTINCT support_team_members.user_id
+    FROM support_team_members
+    WHERE (
+            support_team_members.team_id = $5 OR
+            support_team_members.team_id IN (SELECT team_id FROM panel_teams WHERE panel_id = $4)
+        )
+      AND (
+            EXISTS(
+                SELECT 1
+                FROM on_call
+                WHERE on_call.guild_id = $1 AND on_call.user_id = support_team_members.user_id AND on_call.is_on_call
+            ) OR
+            EXISTS(
+                SELECT 1
+                FROM scheduled
+                WHERE scheduled.user_id = support_team_members.user_id
+                  AND (scheduled.team_id IS NULL OR scheduled.team_id = support_team_members.team_id)
+                  AND NOT EXISTS(
+                    SELECT 1
+                    FROM on_call
+                    WHERE on_call.guild_id = $1
+                      AND on_call.user_id = scheduled.user_id
+                      AND NOT on_call.is_on_call
+                      AND on_call.updated_at >= scheduled.shift_start
+                )
+            )
+        )
+      AND support_team_members.user_id != (SELECT user_id FROM tickets WHERE guild_id = $1 AND id = $3)
+      AND NOT EXISTS (SELECT 1 FROM ticket_claims WHERE guild_id = $1 AND ticket_id = $3)
+), open_claims AS (
+    SELECT ticket_claims.user_id, COUNT(*) AS count
+    FROM ticket_claims
+    INNER JOIN tickets ON tickets.guild_id = ticket_claims.guild_id AND tickets.id = ticket_claims.ticket_id
+    WHERE ticket_claims.guild_id = $1 AND tickets.open
+    GROUP BY ticket_claims.user_id
+), candidates AS (
+    SELECT on_call_members.user_id
+    FROM on_call_members
+    LEFT OUTER JOIN open_claims ON open_claims.user_id = on_call_members.user_id
+    -- The cap is chosen in the same way as in claim_capacity/check.sql
+    LEFT JOIN LATERAL (
+        SELECT claim_capacity.max_open_claims
+        FROM claim_capacity
+        WHERE claim_capacity.guild_id = $1
+          AND (
+                claim_capacity.user_id = on_call_members.user_id OR
+                claim_capacity.team_id IN (SELECT team_id FROM support_team_members WHERE user_id = on_call_members.user_id) OR
+                (claim_capacity.team_id IS NULL AND claim_capacity.user_id IS NULL)
+            )
+        ORDER BY claim_capacity.user_id IS NULL, claim_capacity.team_id IS NULL, claim_capacity.max_open_claims DESC
+        LIMIT 1
+    ) capacity ON true
+    WHERE (capacity.max_open_claims IS NULL OR COALESCE(open_claims.count, 0) < capacity.max_open_claims)
+      AND on_call_members.user_id != ALL($7)
+), last_assigned AS (
+    SELECT user_id, MAX(assigned_at) AS assigned_at
+    FROM auto_assign_history
+    WHERE guild_id = $1
+    GROUP BY user_id
+)
+SELECT candidates.user_id, (SELECT COUNT(*) FROM candidates)
+FROM candidates
+LEFT OUTER JOIN open_claims ON open_claims.user_id = candidates.user_id
+LEFT OUTER JOIN last_assigned ON last_assigned.user_id = candidates.user_id
+LEFT OUTER JOIN auto_assign_state ON auto_assign_state.settings_id = $6
+ORDER BY %s
+LIMIT 1;
@@ -0,0 +1,9 @@
+WITH state AS (
+    INSERT INTO auto_assign_state (settings_id, last_user_id, last_assigned_at)
+    VALUES ($1, $4, NOW())
+    ON CONFLICT (settings_id) DO UPDATE
+        SET last_user_id     = EXCLUDED.last_user_id,
+            last_assigned_at = EXCLUDED.last_assigned_at
+)
+INSERT INTO auto_assign_history (guild_id, ticket_id, settings_id, strategy, user_id, candidate_count)
+VALUES ($2, $3, $1, $5, $4, $6);
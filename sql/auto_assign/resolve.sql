@@ -0,0 +1,10 @@
+-- Settings for the panel itself take priority over the settings of the panel's teams. The row is locked so that
+-- concurrent assignments using the same settings are serialised, keeping round-robin state consistent.
+SELECT id, panel_id, team_id, strategy
+FROM auto_assign_settings
+WHERE guild_id = $1
+  AND enabled
+  AND (panel_id = $2 OR team_id IN (SELECT team_id FROM panel_teams WHERE panel_id = $2))
+ORDER BY panel_id IS NULL, team_id
+LIMIT 1
+FOR UPDATE;
@@ -0,0 +1,44 @@
+CREATE TYPE auto_assign_strategy AS ENUM ('round_robin', 'least_open_claims', 'longest_idle');
+
+CREATE TABLE IF NOT EXISTS auto_assign_settings
+(
+    "id"       SERIAL               NOT NULL,
+    "guild_id" int8                 NOT NULL,
+    "panel_id" int4                 NULL,
+    "team_id"  int4                 NULL,
+    "strategy" auto_assign_strategy NOT NULL,
+    "enabled"  bool                 NOT NULL DEFAULT true,
+    FOREIGN KEY ("panel_id") REFERENCES panels ("panel_id") ON DELETE CASCADE ON UPDATE CASCADE,
+    FOREIGN KEY ("team_id") REFERENCES support_team ("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    CHECK (("panel_id" IS NULL) != ("team_id" IS NULL)),
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS auto_assign_settings_panel_id ON auto_assign_settings ("panel_id") WHERE "panel_id" IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS auto_assign_settings_team_id ON auto_assign_settings ("team_id") WHERE "team_id" IS NOT NULL;
+CREATE INDEX IF NOT EXISTS auto_assign_settings_guild_id ON auto_assign_settings ("guild_id");
+
+CREATE TABLE IF NOT EXISTS auto_assign_state
+(
+    "settings_id"      int4        NOT NULL,
+    "last_user_id"     int8        NOT NULL,
+    "last_assigned_at" timestamptz NOT NULL,
+    FOREIGN KEY ("settings_id") REFERENCES auto_assign_settings ("id") ON DELETE CASCADE,
+    PRIMARY KEY ("settings_id")
+);
+
+CREATE TABLE IF NOT EXISTS auto_assign_history
+(
+    "id"              BIGSERIAL            NOT NULL,
+    "guild_id"        int8                 NOT NULL,
+    "ticket_id"       int4                 NOT NULL,
+    "settings_id"     int4                 NULL,
+    "strategy"        auto_assign_strategy NOT NULL,
+    "user_id"         int8                 NOT NULL,
+    "candidate_count" int4                 NOT NULL,
+    "assigned_at"     timestamptz          NOT NULL DEFAULT NOW(),
+    FOREIGN KEY ("guild_id", "ticket_id") REFERENCES tickets ("guild_id", "id") ON DELETE CASCADE,
+    FOREIGN KEY ("settings_id") REFERENCES auto_assign_settings ("id") ON DELETE SET NULL,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS auto_assign_history_guild_id_assigned_at ON auto_assign_history ("guild_id", "assigned_at");
+CREATE INDEX IF NOT EXISTS auto_assign_history_guild_id_user_id ON auto_assign_history ("guild_id", "user_id", "assigned_at");
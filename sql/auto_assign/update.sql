@@ -0,0 +1,13 @@
+WITH scope AS (
+    SELECT $3::int4 IS NULL OR EXISTS(SELECT 1 FROM panels WHERE panel_id = $3 AND guild_id = $1) AS valid_panel,
+           $4::int4 IS NULL OR EXISTS(SELECT 1 FROM support_team WHERE id = $4 AND guild_id = $1) AS valid_team
+), updated AS (
+    UPDATE auto_assign_settings
+    SET panel_id = $3,
+        team_id  = $4,
+        strategy = $5,
+        enabled  = $6
+    WHERE guild_id = $1 AND id = $2 AND (SELECT valid_panel AND valid_team FROM scope)
+)
+SELECT valid_panel, valid_team
+FROM scope;
@@ -0,0 +1,3 @@
+SELECT form_id::text AS value, title AS name
+FROM forms
+WHERE guild_id = $1
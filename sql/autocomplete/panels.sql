@@ -0,0 +1,3 @@
+SELECT panel_id::text AS value, title AS name
+FROM panels
+WHERE guild_id = $1
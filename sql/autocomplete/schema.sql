@@ -0,0 +1,7 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS tags_tag_id_trgm ON tags USING gin ("tag_id" gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS tag_aliases_alias_trgm ON tag_aliases USING gin ("alias" gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS panels_title_trgm ON panels USING gin ("title" gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS support_team_name_trgm ON support_team USING gin ("name" gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS forms_title_trgm ON forms USING gin ("title" gin_trgm_ops);
@@ -0,0 +1,19 @@
+-- $2 is the input, and $3 is the input with LIKE wildcards escaped. Candidates match if the input appears anywhere in
+-- the name, or if the input is similar to a word in the name. Names starting with the input are ranked first, then by
+-- similarity. Each value is returned at most once, by its best ranked name.
+SELECT value, name, score
+FROM (
+    SELECT DISTINCT ON (ranked.value) ranked.value, ranked.name, ranked.is_prefix, ranked.score
+    FROM (
+        SELECT candidates.value,
+               candidates.name,
+               candidates.name ILIKE $3 || '%%' AS is_prefix,
+               word_similarity($2, candidates.name) AS score
+        FROM (%s) candidates
+        WHERE candidates.name ILIKE '%%' || $3 || '%%'
+           OR $2 <%% candidates.name
+    ) ranked
+    ORDER BY ranked.value, ranked.is_prefix DESC, ranked.score DESC, ranked.name
+) best
+ORDER BY is_prefix DESC, score DESC, name
+LIMIT $4
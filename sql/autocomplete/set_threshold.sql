@@ -0,0 +1,2 @@
+-- Only applies until the end of the transaction
+SELECT set_config('pg_trgm.word_similarity_threshold', $1::text, true);
@@ -0,0 +1,3 @@
+SELECT id::text AS value, name
+FROM support_team
+WHERE guild_id = $1
@@ -0,0 +1,7 @@
+SELECT tag_id AS value, tag_id AS name
+FROM tags
+WHERE guild_id = $1
+UNION ALL
+SELECT tag_id, alias
+FROM tag_aliases
+WHERE guild_id = $1
@@ -0,0 +1,29 @@
+-- The same as tag_scopes/get_starting_with.sql: tags without any scopes are visible everywhere
+SELECT names.tag_id AS value, names.name
+FROM (
+    SELECT tag_id, tag_id AS name
+    FROM tags
+    WHERE guild_id = $1
+    UNION ALL
+    SELECT tag_id, alias
+    FROM tag_aliases
+    WHERE guild_id = $1
+) names
+WHERE NOT EXISTS(
+    SELECT 1
+    FROM tag_scopes
+    WHERE tag_scopes.guild_id = $1 AND tag_scopes.tag_id = names.tag_id
+) OR EXISTS(
+    SELECT 1
+    FROM tag_scopes
+    WHERE tag_scopes.guild_id = $1
+      AND tag_scopes.tag_id = names.tag_id
+      AND (
+            tag_scopes.panel_id = $5::int4 OR
+            tag_scopes.team_id IN (
+                SELECT team_id FROM support_team_members WHERE user_id = $6
+                UNION
+                SELECT team_id FROM support_team_roles WHERE role_id = ANY($7::int8[])
+            )
+        )
+)
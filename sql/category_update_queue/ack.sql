@@ -0,0 +1,2 @@
+DELETE FROM category_update_queue
+WHERE guild_id = $1 AND ticket_id = $2 AND lease_id = $3;
@@ -0,0 +1,8 @@
+UPDATE category_update_queue
+SET dead_at = NOW(),
+    lease_id = NULL,
+    locked_until = NULL,
+    last_error = COALESCE(last_error, 'lease expired')
+WHERE dead_at IS NULL
+  AND attempts >= $1
+  AND (locked_until IS NULL OR locked_until < NOW());
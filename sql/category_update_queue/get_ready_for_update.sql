@@ -1,6 +1,8 @@
 WITH cte AS (
     DELETE FROM category_update_queue
         WHERE status_changed_at < NOW() - $1::INTERVAL
+          AND dead_at IS NULL
+          AND (locked_until IS NULL OR locked_until < NOW())
         RETURNING guild_id, ticket_id, new_status
 )
 SELECT cte.guild_id, cte.ticket_id, cte.new_status, tickets.channel_id, tickets.panel_id
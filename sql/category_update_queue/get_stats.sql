@@ -0,0 +1,7 @@
+SELECT
+    COUNT(*) FILTER (WHERE dead_at IS NULL AND NOT (lease_id IS NOT NULL AND locked_until >= NOW())) AS pending,
+    COUNT(*) FILTER (WHERE dead_at IS NULL AND lease_id IS NOT NULL AND locked_until >= NOW()) AS leased,
+    COUNT(*) FILTER (WHERE dead_at IS NOT NULL) AS dead,
+    COUNT(*) FILTER (WHERE dead_at IS NULL AND status_changed_at < NOW() - $1::INTERVAL AND (locked_until IS NULL OR locked_until < NOW())) AS ready,
+    NOW() - MIN(status_changed_at) FILTER (WHERE dead_at IS NULL) AS oldest_age
+FROM category_update_queue;
@@ -0,0 +1,23 @@
+WITH ready AS (
+    SELECT guild_id, ticket_id
+    FROM category_update_queue
+    WHERE dead_at IS NULL
+      AND status_changed_at < NOW() - $1::INTERVAL
+      AND (locked_until IS NULL OR locked_until < NOW())
+      AND attempts < $4
+    ORDER BY status_changed_at ASC
+    LIMIT $2
+    FOR UPDATE SKIP LOCKED
+), leased AS (
+    UPDATE category_update_queue
+    SET lease_id = gen_random_uuid(),
+        locked_until = NOW() + $3::INTERVAL,
+        attempts = category_update_queue.attempts + 1
+    FROM ready
+    WHERE category_update_queue.guild_id = ready.guild_id AND category_update_queue.ticket_id = ready.ticket_id
+    RETURNING category_update_queue.guild_id, category_update_queue.ticket_id, category_update_queue.new_status,
+        category_update_queue.lease_id, category_update_queue.attempts
+)
+SELECT leased.guild_id, leased.ticket_id, leased.new_status, tickets.channel_id, tickets.panel_id, leased.lease_id, leased.attempts
+FROM leased
+INNER JOIN tickets ON leased.guild_id = tickets.guild_id AND leased.ticket_id = tickets.id;
@@ -0,0 +1,5 @@
+SELECT guild_id, ticket_id, new_status, status_changed_at, attempts, last_error, dead_at
+FROM category_update_queue
+WHERE dead_at IS NOT NULL
+ORDER BY dead_at DESC
+LIMIT $1;
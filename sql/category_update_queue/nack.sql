@@ -0,0 +1,6 @@
+UPDATE category_update_queue
+SET lease_id = NULL,
+    locked_until = CASE WHEN attempts >= $5 THEN NULL ELSE NOW() + $4::INTERVAL END,
+    dead_at = CASE WHEN attempts >= $5 THEN NOW() ELSE NULL END,
+    last_error = $6
+WHERE guild_id = $1 AND ticket_id = $2 AND lease_id = $3;
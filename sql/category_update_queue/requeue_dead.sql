@@ -0,0 +1,7 @@
+UPDATE category_update_queue
+SET dead_at = NULL,
+    attempts = 0,
+    last_error = NULL,
+    lease_id = NULL,
+    locked_until = NULL
+WHERE guild_id = $1 AND ticket_id = $2 AND dead_at IS NOT NULL;
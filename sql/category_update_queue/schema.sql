@@ -5,6 +5,19 @@ CREATE TABLE IF NOT EXISTS category_update_queue (
     ticket_id INT8 NOT NULL,
     new_status ticket_status NOT NULL,
     status_changed_at TIMESTAMPTZ NOT NULL,
+    lease_id UUID,
+    locked_until TIMESTAMPTZ,
+    attempts INT4 NOT NULL DEFAULT 0,
+    last_error TEXT,
+    dead_at TIMESTAMPTZ,
     PRIMARY KEY (guild_id, ticket_id),
     FOREIGN KEY (guild_id, ticket_id) REFERENCES tickets(guild_id, id) ON DELETE CASCADE
 );
+
+ALTER TABLE category_update_queue ADD COLUMN IF NOT EXISTS lease_id UUID;
+ALTER TABLE category_update_queue ADD COLUMN IF NOT EXISTS locked_until TIMESTAMPTZ;
+ALTER TABLE category_update_queue ADD COLUMN IF NOT EXISTS attempts INT4 NOT NULL DEFAULT 0;
+ALTER TABLE category_update_queue ADD COLUMN IF NOT EXISTS last_error TEXT;
+ALTER TABLE category_update_queue ADD COLUMN IF NOT EXISTS dead_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS category_update_queue_ready_idx ON category_update_queue (status_changed_at) WHERE dead_at IS NULL;
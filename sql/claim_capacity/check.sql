@@ -0,0 +1,23 @@
+-- Where a member is in multiple teams with caps, the most permissive applies. The ticket being claimed is not counted,
+-- so that re-claiming a ticket does not count towards the cap twice.
+SELECT (
+           SELECT max_open_claims
+           FROM claim_capacity
+           WHERE guild_id = $1
+             AND (
+                   user_id = $2 OR
+                   team_id IN (SELECT team_id FROM support_team_members WHERE user_id = $2) OR
+                   (team_id IS NULL AND user_id IS NULL)
+               )
+           ORDER BY user_id IS NULL, team_id IS NULL, max_open_claims DESC
+           LIMIT 1
+       ),
+       (
+           SELECT COUNT(*)
+           FROM ticket_claims
+           INNER JOIN tickets ON tickets.guild_id = ticket_claims.guild_id AND tickets.id = ticket_claims.ticket_id
+           WHERE ticket_claims.guild_id = $1
+             AND ticket_claims.user_id = $2
+             AND ticket_claims.ticket_id != $3
+             AND tickets.open
+       );
@@ -0,0 +1,4 @@
+INSERT INTO claim_capacity (guild_id, team_id, user_id, max_open_claims)
+SELECT $1, $2, $3, $4
+WHERE $2::int4 IS NULL OR EXISTS(SELECT 1 FROM support_team WHERE id = $2 AND guild_id = $1)
+RETURNING id;
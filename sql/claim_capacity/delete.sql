@@ -0,0 +1,3 @@
+DELETE
+FROM claim_capacity
+WHERE guild_id = $1 AND id = $2;
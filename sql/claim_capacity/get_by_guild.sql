@@ -0,0 +1,4 @@
+SELECT id, team_id, user_id, max_open_claims
+FROM claim_capacity
+WHERE guild_id = $1
+ORDER BY id ASC;
@@ -0,0 +1,47 @@
+WITH on_call_users (user_id) AS (
+    %s
+), open_claims AS (
+    SELECT ticket_claims.user_id, COUNT(*) AS count
+    FROM ticket_claims
+    INNER JOIN tickets ON tickets.guild_id = ticket_claims.guild_id AND tickets.id = ticket_claims.ticket_id
+    WHERE ticket_claims.guild_id = $1 AND tickets.open
+    GROUP BY ticket_claims.user_id
+), staff AS (
+    SELECT support_team_members.user_id
+    FROM support_team_members
+    INNER JOIN support_team ON support_team.id = support_team_members.team_id
+    WHERE support_team.guild_id = $1
+    UNION
+    SELECT user_id
+    FROM permissions
+    WHERE guild_id = $1 AND (support OR admin)
+    UNION
+    SELECT user_id
+    FROM open_claims
+), teams AS (
+    SELECT support_team_members.user_id, ARRAY_AGG(support_team_members.team_id ORDER BY support_team_members.team_id) AS team_ids
+    FROM support_team_members
+    INNER JOIN support_team ON support_team.id = support_team_members.team_id
+    WHERE support_team.guild_id = $1
+    GROUP BY support_team_members.user_id
+)
+SELECT staff.user_id,
+       COALESCE(teams.team_ids, '{}'),
+       COALESCE(open_claims.count, 0),
+       (
+           SELECT max_open_claims
+           FROM claim_capacity
+           WHERE guild_id = $1
+             AND (
+                   user_id = staff.user_id OR
+                   team_id = ANY(teams.team_ids) OR
+                   (team_id IS NULL AND user_id IS NULL)
+               )
+           ORDER BY user_id IS NULL, team_id IS NULL, max_open_claims DESC
+           LIMIT 1
+       ),
+       staff.user_id IN (SELECT user_id FROM on_call_users)
+FROM staff
+LEFT OUTER JOIN open_claims ON open_claims.user_id = staff.user_id
+LEFT OUTER JOIN teams ON teams.user_id = staff.user_id
+ORDER BY COALESCE(open_claims.count, 0) DESC, staff.user_id;
@@ -0,0 +1,2 @@
+-- Serialises claims for the same member until the end of the transaction
+SELECT pg_advisory_xact_lock(hashtextextended('claim_capacity:' || $1::int8::text || ':' || $2::int8::text, 0));
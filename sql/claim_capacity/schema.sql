@@ -0,0 +1,18 @@
+-- A cap applies to a single member, to each member of a support team, or, if neither is set, to every member of the
+-- guild. A team cap limits each member of the team individually, not the team's total open claims. A member's own cap
+-- takes priority over their teams' caps, which take priority over the guild-wide cap.
+CREATE TABLE IF NOT EXISTS claim_capacity
+(
+    "id"              SERIAL NOT NULL,
+    "guild_id"        int8   NOT NULL,
+    "team_id"         int4   NULL,
+    "user_id"         int8   NULL,
+    "max_open_claims" int4   NOT NULL,
+    FOREIGN KEY ("team_id") REFERENCES support_team ("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    CHECK ("team_id" IS NULL OR "user_id" IS NULL),
+    CHECK ("max_open_claims" >= 0),
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS claim_capacity_guild_id_user_id ON claim_capacity ("guild_id", "user_id") WHERE "user_id" IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS claim_capacity_team_id ON claim_capacity ("team_id") WHERE "team_id" IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS claim_capacity_guild_id ON claim_capacity ("guild_id") WHERE "team_id" IS NULL AND "user_id" IS NULL;
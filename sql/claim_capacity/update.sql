@@ -0,0 +1,11 @@
+WITH team AS (
+    SELECT $3::int4 IS NULL OR EXISTS(SELECT 1 FROM support_team WHERE id = $3 AND guild_id = $1) AS valid
+), updated AS (
+    UPDATE claim_capacity
+    SET team_id         = $3,
+        user_id         = $4,
+        max_open_claims = $5
+    WHERE guild_id = $1 AND id = $2 AND (SELECT valid FROM team)
+)
+SELECT valid
+FROM team;
@@ -1,2 +1,3 @@
 DELETE FROM entitlements
-WHERE "id" = $1;
\ No newline at end of file
+WHERE "id" = $1
+RETURNING "id", "guild_id", "user_id", "sku_id", "source", "expires_at";
\ No newline at end of file
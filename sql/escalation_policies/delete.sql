@@ -0,0 +1,3 @@
+DELETE
+FROM escalation_policies
+WHERE guild_id = $1 AND panel_id = $2;
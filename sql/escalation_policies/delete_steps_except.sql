@@ -0,0 +1,3 @@
+DELETE
+FROM escalation_steps
+WHERE policy_id = $1 AND NOT (id = ANY ($2));
@@ -0,0 +1,12 @@
+SELECT escalation_policies.id,
+       escalation_policies.panel_id,
+       escalation_policies.enabled,
+       escalation_steps.id,
+       escalation_steps.delay,
+       escalation_steps.action,
+       escalation_steps.team_id,
+       escalation_steps.priority_increase
+FROM escalation_policies
+LEFT OUTER JOIN escalation_steps ON escalation_steps.policy_id = escalation_policies.id
+WHERE escalation_policies.guild_id = $1
+ORDER BY escalation_policies.id ASC, escalation_steps.position ASC;
@@ -0,0 +1,38 @@
+-- A ticket is waiting for a staff reply from when it is opened until the first response, and afterwards whenever the
+-- last message was sent by a non-staff user. As the last message time is updated on each message, further messages
+-- from the user push back escalation steps that have not yet fired.
+SELECT tickets.guild_id,
+       tickets.id,
+       tickets.panel_id,
+       tickets.channel_id,
+       escalation_policies.id,
+       escalation_steps.id,
+       escalation_steps.delay,
+       escalation_steps.action,
+       escalation_steps.team_id,
+       escalation_steps.priority_increase,
+       waiting.since
+FROM tickets
+INNER JOIN escalation_policies ON escalation_policies.panel_id = tickets.panel_id AND escalation_policies.enabled
+INNER JOIN escalation_steps ON escalation_steps.policy_id = escalation_policies.id
+LEFT OUTER JOIN first_response_time
+    ON first_response_time.guild_id = tickets.guild_id AND first_response_time.ticket_id = tickets.id
+LEFT OUTER JOIN ticket_last_message
+    ON ticket_last_message.guild_id = tickets.guild_id AND ticket_last_message.ticket_id = tickets.id
+CROSS JOIN LATERAL (
+    SELECT CASE
+               WHEN first_response_time.ticket_id IS NULL THEN tickets.open_time
+               WHEN NOT ticket_last_message.user_is_staff THEN ticket_last_message.last_message_time
+           END AS since
+) waiting
+WHERE tickets.open
+  AND waiting.since + escalation_steps.delay <= NOW()
+  AND NOT EXISTS(
+    SELECT 1
+    FROM ticket_escalations
+    WHERE ticket_escalations.guild_id = tickets.guild_id
+      AND ticket_escalations.ticket_id = tickets.id
+      AND ticket_escalations.step_id = escalation_steps.id
+)
+ORDER BY waiting.since + escalation_steps.delay ASC
+LIMIT $1;
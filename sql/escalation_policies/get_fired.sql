@@ -0,0 +1,4 @@
+SELECT step_id, fired_at
+FROM ticket_escalations
+WHERE guild_id = $1 AND ticket_id = $2
+ORDER BY fired_at ASC;
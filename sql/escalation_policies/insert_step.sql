@@ -0,0 +1,10 @@
+-- The step's team must belong to the policy's guild
+INSERT INTO escalation_steps (policy_id, position, delay, action, team_id, priority_increase)
+SELECT $1, $2, $3, $4, $5, $6
+WHERE $5::int4 IS NULL OR EXISTS(
+    SELECT 1
+    FROM support_team
+    INNER JOIN escalation_policies ON escalation_policies.guild_id = support_team.guild_id
+    WHERE support_team.id = $5 AND escalation_policies.id = $1
+)
+RETURNING id;
@@ -0,0 +1,3 @@
+INSERT INTO ticket_escalations (guild_id, ticket_id, step_id)
+VALUES ($1, $2, $3)
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,43 @@
+CREATE TYPE escalation_action AS ENUM ('notify_team', 'mention_on_call', 'bump_priority');
+
+CREATE TABLE IF NOT EXISTS escalation_policies
+(
+    "id"       SERIAL NOT NULL,
+    "guild_id" int8   NOT NULL,
+    "panel_id" int4   NOT NULL UNIQUE,
+    "enabled"  bool   NOT NULL DEFAULT true,
+    FOREIGN KEY ("panel_id") REFERENCES panels ("panel_id") ON DELETE CASCADE ON UPDATE CASCADE,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS escalation_policies_guild_id ON escalation_policies ("guild_id");
+
+-- The delay is measured from when the ticket started waiting for a staff reply. team_id is only set for notify_team
+-- steps, and priority_increase for bump_priority steps. The position constraint is deferred so that steps can be
+-- reordered in place.
+CREATE TABLE IF NOT EXISTS escalation_steps
+(
+    "id"                SERIAL            NOT NULL,
+    "policy_id"         int4              NOT NULL,
+    "position"          int4              NOT NULL,
+    "delay"             interval          NOT NULL,
+    "action"            escalation_action NOT NULL,
+    "team_id"           int4              NULL,
+    "priority_increase" int4              NULL,
+    FOREIGN KEY ("policy_id") REFERENCES escalation_policies ("id") ON DELETE CASCADE,
+    FOREIGN KEY ("team_id") REFERENCES support_team ("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    CHECK (("action" = 'notify_team') = ("team_id" IS NOT NULL)),
+    CHECK (("action" = 'bump_priority') = ("priority_increase" IS NOT NULL)),
+    UNIQUE ("policy_id", "position") DEFERRABLE INITIALLY DEFERRED,
+    PRIMARY KEY ("id")
+);
+
+CREATE TABLE IF NOT EXISTS ticket_escalations
+(
+    "guild_id"  int8        NOT NULL,
+    "ticket_id" int4        NOT NULL,
+    "step_id"   int4        NOT NULL,
+    "fired_at"  timestamptz NOT NULL DEFAULT NOW(),
+    FOREIGN KEY ("guild_id", "ticket_id") REFERENCES tickets ("guild_id", "id") ON DELETE CASCADE,
+    FOREIGN KEY ("step_id") REFERENCES escalation_steps ("id") ON DELETE CASCADE,
+    PRIMARY KEY ("guild_id", "ticket_id", "step_id")
+);
@@ -0,0 +1,8 @@
+INSERT INTO escalation_policies (guild_id, panel_id, enabled)
+SELECT guild_id, panel_id, $3
+FROM panels
+WHERE panel_id = $2 AND guild_id = $1
+ON CONFLICT (panel_id) DO UPDATE
+    SET enabled = EXCLUDED.enabled
+    WHERE escalation_policies.guild_id = EXCLUDED.guild_id
+RETURNING id;
@@ -0,0 +1,21 @@
+-- Steps are updated in place, so that steps which have already fired for a ticket are not fired again. The step's team
+-- must belong to the policy's guild.
+WITH team AS (
+    SELECT $6::int4 IS NULL OR EXISTS(
+        SELECT 1
+        FROM support_team
+        INNER JOIN escalation_policies ON escalation_policies.guild_id = support_team.guild_id
+        WHERE support_team.id = $6 AND escalation_policies.id = $1
+    ) AS valid
+), updated AS (
+    UPDATE escalation_steps
+    SET position          = $3,
+        delay             = $4,
+        action            = $5,
+        team_id           = $6,
+        priority_increase = $7
+    WHERE policy_id = $1 AND id = $2 AND (SELECT valid FROM team)
+    RETURNING id
+)
+SELECT valid, EXISTS(SELECT 1 FROM updated)
+FROM team;
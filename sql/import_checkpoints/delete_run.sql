@@ -0,0 +1,2 @@
+DELETE FROM import_checkpoints
+WHERE guild_id = $1 AND run_id = $2;
@@ -0,0 +1,3 @@
+SELECT entity_type, row_count, completed_at
+FROM import_checkpoints
+WHERE guild_id = $1 AND run_id = $2;
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS import_checkpoints (
+    guild_id INT8 NOT NULL,
+    run_id INT4 NOT NULL,
+    entity_type VARCHAR(64) NOT NULL,
+    row_count INT4 NOT NULL,
+    completed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (guild_id, run_id, entity_type)
+);
@@ -0,0 +1,5 @@
+INSERT INTO import_checkpoints (guild_id, run_id, entity_type, row_count)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (guild_id, run_id, entity_type) DO UPDATE
+SET row_count = EXCLUDED.row_count,
+    completed_at = NOW();
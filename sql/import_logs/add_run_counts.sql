@@ -0,0 +1,5 @@
+UPDATE import_runs
+SET rows_imported = rows_imported + $3,
+    rows_skipped = rows_skipped + $4,
+    error_count = error_count + $5
+WHERE guild_id = $1 AND run_id = $2;
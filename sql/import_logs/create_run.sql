@@ -0,0 +1,2 @@
+INSERT INTO import_runs (guild_id, run_id, run_type, state, last_log_id)
+VALUES ($1, $2, $3, 'running', 1);
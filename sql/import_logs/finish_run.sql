@@ -0,0 +1,3 @@
+UPDATE import_runs
+SET state = $3, finished_at = NOW()
+WHERE guild_id = $1 AND run_id = $2 AND state = 'running';
@@ -0,0 +1,3 @@
+SELECT run_id, run_type, state, started_at, finished_at, last_log_id, rows_imported, rows_skipped, error_count
+FROM import_runs
+WHERE guild_id = $1 AND run_id = $2;
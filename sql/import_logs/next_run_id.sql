@@ -0,0 +1,4 @@
+INSERT INTO import_run_counters (guild_id, last_run_id)
+VALUES ($1, COALESCE((SELECT MAX(run_id) FROM import_logs WHERE guild_id = $1), 0) + 1)
+ON CONFLICT (guild_id) DO UPDATE SET last_run_id = import_run_counters.last_run_id + 1
+RETURNING last_run_id;
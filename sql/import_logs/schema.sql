@@ -26,4 +26,31 @@ CREATE TRIGGER before_insert_import_logs
 BEFORE INSERT ON import_logs
 FOR EACH ROW
 WHEN (NEW.run_log_id IS NULL)
-EXECUTE FUNCTION set_run_log_id();
\ No newline at end of file
+EXECUTE FUNCTION set_run_log_id();
+
+-- Run IDs are allocated from a per-guild counter, and log IDs from a per-run counter on import_runs, so that concurrent
+-- importers cannot be allocated the same ID. The counters are seeded from import_logs for runs created before they
+-- existed.
+CREATE TYPE import_run_state AS ENUM ('running', 'succeeded', 'failed', 'cancelled');
+
+CREATE TABLE IF NOT EXISTS import_run_counters (
+    guild_id BIGINT NOT NULL,
+    last_run_id INT NOT NULL,
+    PRIMARY KEY (guild_id)
+);
+
+CREATE TABLE IF NOT EXISTS import_runs (
+    guild_id BIGINT NOT NULL,
+    run_id INT NOT NULL,
+    run_type VARCHAR(255) NOT NULL DEFAULT 'DATA',
+    state import_run_state NOT NULL DEFAULT 'running',
+    started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMPTZ NULL,
+    last_log_id INT NOT NULL DEFAULT 0,
+    rows_imported INT NOT NULL DEFAULT 0,
+    rows_skipped INT NOT NULL DEFAULT 0,
+    error_count INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (guild_id, run_id)
+);
+
+CREATE INDEX IF NOT EXISTS import_runs_guild_started_at ON import_runs (guild_id, started_at DESC);
@@ -1,4 +1,12 @@
-INSERT INTO import_logs 
-    (guild_id, log_type, run_id, run_type, entity_type, message)
-VALUES
-    ($1, $2, $3, $4, $5, $6)
\ No newline at end of file
+-- Locks the run's counter row until the transaction commits, so that concurrent writers to the same run are serialised
+-- rather than racing for the next log ID.
+WITH next_log AS (
+    INSERT INTO import_runs (guild_id, run_id, run_type, last_log_id)
+    VALUES ($1, $3, $4, COALESCE((SELECT MAX(run_log_id) FROM import_logs WHERE guild_id = $1 AND run_id = $3), 0) + 1)
+    ON CONFLICT (guild_id, run_id) DO UPDATE SET last_log_id = import_runs.last_log_id + 1
+    RETURNING last_log_id
+)
+INSERT INTO import_logs
+    (guild_id, log_type, run_id, run_log_id, run_type, entity_type, message)
+SELECT $1, $2, $3, next_log.last_log_id, $4, $5, $6
+FROM next_log;
@@ -1,4 +1,4 @@
-CREATE TYPE mapping_area AS ENUM ('ticket', 'form', 'form_input', 'panel');
+CREATE TYPE mapping_area AS ENUM ('ticket', 'form', 'form_input', 'panel', 'embed', 'multi_panel', 'support_team', 'tag_category');
 
 CREATE TABLE IF NOT EXISTS import_mapping
 (
@@ -6,5 +6,12 @@ CREATE TABLE IF NOT EXISTS import_mapping
     area mapping_area NOT NULL,
     source_id int4 NOT NULL,
     target_id int4 NOT NULL,
+    run_id int4 NULL,
+    source varchar(255) NULL, -- The ImportSource the entity was imported from, null for guild config and Tickets imports
     UNIQUE NULLS NOT DISTINCT (guild_id, area, source_id, target_id)
-);
\ No newline at end of file
+);
+
+ALTER TABLE import_mapping ADD COLUMN IF NOT EXISTS run_id int4 NULL;
+ALTER TABLE import_mapping ADD COLUMN IF NOT EXISTS source varchar(255) NULL;
+CREATE INDEX IF NOT EXISTS import_mapping_lookup ON import_mapping (guild_id, area, source_id);
+CREATE INDEX IF NOT EXISTS import_mapping_run ON import_mapping (guild_id, run_id) WHERE run_id IS NOT NULL;
@@ -0,0 +1,2 @@
+DELETE FROM import_mapping
+WHERE guild_id = $1 AND run_id = $2;
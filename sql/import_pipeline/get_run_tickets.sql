@@ -0,0 +1,15 @@
+-- Tickets that are also the target of a mapping from another run, or from a legacy import, were not created by this
+-- run, and so are left in place
+SELECT run_mapping.target_id
+FROM import_mapping run_mapping
+WHERE run_mapping.guild_id = $1
+  AND run_mapping.area = 'ticket'
+  AND run_mapping.run_id = $2
+  AND NOT EXISTS(
+    SELECT 1
+    FROM import_mapping other
+    WHERE other.guild_id = $1
+      AND other.area = 'ticket'
+      AND other.target_id = run_mapping.target_id
+      AND other.run_id IS DISTINCT FROM $2
+);
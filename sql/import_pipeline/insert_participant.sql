@@ -0,0 +1,9 @@
+INSERT INTO participant (guild_id, ticket_id, user_id)
+SELECT $1, ticket_mapping.target_id, staged.user_id
+FROM import_staging_participant staged
+INNER JOIN import_mapping ticket_mapping
+    ON ticket_mapping.guild_id = $1 AND ticket_mapping.area = 'ticket' AND ticket_mapping.run_id = $2
+        AND ticket_mapping.source_id = staged.ticket_id
+INNER JOIN tickets
+    ON tickets.guild_id = $1 AND tickets.id = ticket_mapping.target_id
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,41 @@
+-- Panel IDs are translated through the panel mappings of the source the tickets were parsed by ($3, empty for a
+-- Tickets export), and dropped if the panel was not imported. A config imported more than once has a mapping per
+-- import, in which case the most recently imported panel is used. Channel IDs already used by another ticket, or by
+-- another ticket in the same import, are dropped, as they must be unique. Target IDs were allocated in the same
+-- transaction, so a conflict means the ID was taken concurrently, and fails the import rather than attaching the
+-- imported rows to another ticket.
+INSERT INTO tickets (id, guild_id, channel_id, user_id, open, open_time, welcome_message_id, panel_id, has_transcript,
+                     close_time, is_thread, join_message_id, notes_thread_id, status)
+SELECT
+    ticket_mapping.target_id,
+    $1,
+    CASE
+        WHEN EXISTS(SELECT 1 FROM tickets WHERE tickets.channel_id = staged.channel_id) THEN NULL
+        WHEN EXISTS(SELECT 1 FROM import_staging_tickets other WHERE other.channel_id = staged.channel_id AND other.id < staged.id) THEN NULL
+        ELSE staged.channel_id
+    END,
+    staged.user_id,
+    staged.open,
+    staged.open_time,
+    staged.welcome_message_id,
+    panel_mapping.target_id,
+    staged.has_transcript,
+    staged.close_time,
+    staged.is_thread,
+    staged.join_message_id,
+    staged.notes_thread_id,
+    'CLOSED'
+FROM import_staging_tickets staged
+INNER JOIN import_mapping ticket_mapping
+    ON ticket_mapping.guild_id = $1 AND ticket_mapping.area = 'ticket' AND ticket_mapping.run_id = $2
+        AND ticket_mapping.source_id = staged.id
+LEFT JOIN LATERAL (
+    SELECT import_mapping.target_id
+    FROM import_mapping
+    WHERE import_mapping.guild_id = $1
+      AND import_mapping.area = 'panel'
+      AND import_mapping.source_id = staged.panel_id
+      AND import_mapping.source IS NOT DISTINCT FROM NULLIF($3::varchar, '')
+    ORDER BY import_mapping.target_id DESC
+    LIMIT 1
+) panel_mapping ON true;
@@ -0,0 +1,2 @@
+-- Serialises ticket ID allocation for imports into the same guild until the end of the transaction
+SELECT pg_advisory_xact_lock(hashtextextended('import_tickets:' || $1::int8::text, 0));
@@ -0,0 +1,34 @@
+-- Allocate target IDs for tickets that have not already been mapped by this run. Mappings are scoped to the run, so
+-- that a later import which reuses a source ID gets a new ticket, rather than attaching its rows to the earlier one.
+-- The source ID is kept where it is not already in use in the target guild, otherwise a new ID is allocated above both
+-- the highest existing and highest staged ID, so that kept and allocated IDs can never collide. The caller must hold
+-- the guild's import lock, so that concurrent runs cannot allocate the same IDs.
+WITH base AS (
+    SELECT GREATEST(
+        (SELECT COALESCE(MAX(id), 0) FROM tickets WHERE guild_id = $1),
+        (SELECT COALESCE(MAX(id), 0) FROM import_staging_tickets)
+    ) AS max_id
+), unmapped AS (
+    SELECT staged.id, EXISTS(SELECT 1 FROM tickets WHERE tickets.guild_id = $1 AND tickets.id = staged.id) AS taken
+    FROM import_staging_tickets staged
+    WHERE NOT EXISTS(
+        SELECT 1
+        FROM import_mapping
+        WHERE import_mapping.guild_id = $1
+          AND import_mapping.area = 'ticket'
+          AND import_mapping.run_id = $2
+          AND import_mapping.source_id = staged.id
+    )
+)
+INSERT INTO import_mapping (guild_id, area, source_id, target_id, run_id)
+SELECT
+    $1,
+    'ticket',
+    unmapped.id,
+    CASE
+        WHEN unmapped.taken THEN base.max_id + ROW_NUMBER() OVER (PARTITION BY unmapped.taken ORDER BY unmapped.id)
+        ELSE unmapped.id
+    END,
+    $2
+FROM unmapped
+CROSS JOIN base;
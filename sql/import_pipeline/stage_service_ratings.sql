@@ -0,0 +1,4 @@
+CREATE TEMP TABLE import_staging_service_ratings (
+    ticket_id INT4 NOT NULL,
+    rating INT2 NOT NULL
+) ON COMMIT DROP;
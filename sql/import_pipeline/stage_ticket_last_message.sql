@@ -0,0 +1,7 @@
+CREATE TEMP TABLE import_staging_ticket_last_message (
+    ticket_id INT4 NOT NULL,
+    last_message_id INT8,
+    last_message_time TIMESTAMPTZ,
+    user_id INT8,
+    user_is_staff BOOL
+) ON COMMIT DROP;
@@ -0,0 +1,4 @@
+CREATE TEMP TABLE import_staging_ticket_members (
+    ticket_id INT4 NOT NULL,
+    user_id INT8 NOT NULL
+) ON COMMIT DROP;
@@ -0,0 +1,14 @@
+CREATE TEMP TABLE import_staging_tickets (
+    id INT4 NOT NULL PRIMARY KEY,
+    channel_id INT8,
+    user_id INT8 NOT NULL,
+    open BOOL NOT NULL,
+    open_time TIMESTAMPTZ NOT NULL,
+    welcome_message_id INT8,
+    panel_id INT4,
+    has_transcript BOOL NOT NULL,
+    close_time TIMESTAMPTZ,
+    is_thread BOOL NOT NULL,
+    join_message_id INT8,
+    notes_thread_id INT8
+) ON COMMIT DROP;
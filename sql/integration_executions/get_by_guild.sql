@@ -0,0 +1,19 @@
+-- Only executions of integrations that are still active in the guild are returned. Paginated by ID, newest first. $3
+-- optionally filters by integration, and $4 is the ID to return executions before.
+SELECT executions.id,
+       executions.integration_id,
+       executions.guild_id,
+       executions.ticket_id,
+       executions.status_code,
+       executions.latency_ms,
+       executions.response,
+       executions.error,
+       executions.executed_at
+FROM integration_executions executions
+INNER JOIN custom_integration_guilds guilds
+           ON guilds.integration_id = executions.integration_id AND guilds.guild_id = executions.guild_id
+WHERE executions.guild_id = $1
+  AND ($3::int4 IS NULL OR executions.integration_id = $3)
+  AND ($4::int8 IS NULL OR executions.id < $4)
+ORDER BY executions.id DESC
+LIMIT $2;
@@ -0,0 +1,12 @@
+-- An execution failed if no response was received, an error was recorded, or the status code was not 2xx
+SELECT executions.integration_id,
+       COUNT(*),
+       COUNT(*) FILTER (WHERE executions.error IS NOT NULL OR executions.status_code IS NULL OR executions.status_code NOT BETWEEN 200 AND 299),
+       AVG(executions.latency_ms)::int4,
+       MAX(executions.executed_at) FILTER (WHERE executions.error IS NOT NULL OR executions.status_code IS NULL OR executions.status_code NOT BETWEEN 200 AND 299)
+FROM integration_executions executions
+INNER JOIN custom_integration_guilds guilds
+           ON guilds.integration_id = executions.integration_id AND guilds.guild_id = executions.guild_id
+WHERE executions.guild_id = $1 AND executions.executed_at >= $2
+GROUP BY executions.integration_id
+ORDER BY executions.integration_id;
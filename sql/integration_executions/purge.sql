@@ -0,0 +1,8 @@
+DELETE
+FROM integration_executions
+WHERE id IN (
+    SELECT id
+    FROM integration_executions
+    WHERE executed_at < NOW() - $1::INTERVAL
+    LIMIT $2
+);
@@ -0,0 +1,4 @@
+-- The response and error are truncated, as they are only kept for debugging
+INSERT INTO integration_executions (integration_id, guild_id, ticket_id, status_code, latency_ms, response, error)
+VALUES ($1, $2, $3, $4, $5, LEFT($6, 4096), LEFT($7, 1024))
+RETURNING id, executed_at;
@@ -0,0 +1,19 @@
+-- status_code is null if no response was received, e.g. on a timeout. Rows are kept for the retention period, after which
+-- they are deleted by IntegrationExecutions.Purge.
+CREATE TABLE IF NOT EXISTS integration_executions
+(
+    "id"             BIGSERIAL   NOT NULL,
+    "integration_id" int4        NOT NULL,
+    "guild_id"       int8        NOT NULL,
+    "ticket_id"      int4        NULL,
+    "status_code"    int2        NULL,
+    "latency_ms"     int4        NOT NULL,
+    "response"       text        NULL,
+    "error"          text        NULL,
+    "executed_at"    timestamptz NOT NULL DEFAULT NOW(),
+    FOREIGN KEY ("integration_id") REFERENCES custom_integrations ("id") ON DELETE CASCADE,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS integration_executions_integration_id_id ON integration_executions ("integration_id", "id");
+CREATE INDEX IF NOT EXISTS integration_executions_guild_id_id ON integration_executions ("guild_id", "id");
+CREATE INDEX IF NOT EXISTS integration_executions_executed_at ON integration_executions ("executed_at");
@@ -1,3 +1,4 @@
 DELETE FROM
 legacy_premium_entitlement_guilds
-WHERE "user_id" = $1 AND "guild_id" = $2;
\ No newline at end of file
+WHERE "user_id" = $1 AND "guild_id" = $2
+RETURNING "entitlement_id";
\ No newline at end of file
@@ -1,2 +1,3 @@
 DELETE FROM legacy_premium_entitlements
-WHERE "user_id" = $1;
\ No newline at end of file
+WHERE "user_id" = $1
+RETURNING "user_id", "sku_id", "expires_at";
\ No newline at end of file
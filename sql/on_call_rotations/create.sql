@@ -0,0 +1,4 @@
+INSERT INTO on_call_rotations (guild_id, team_id, name, start_time, shift_length)
+SELECT $1, $2, $3, $4, $5
+WHERE $2::int4 IS NULL OR EXISTS(SELECT 1 FROM support_team WHERE id = $2 AND guild_id = $1)
+RETURNING id;
@@ -0,0 +1,5 @@
+INSERT INTO on_call_overrides (rotation_id, user_id, starts_at, ends_at)
+SELECT id, $3, $4, $5
+FROM on_call_rotations
+WHERE guild_id = $1 AND id = $2
+RETURNING id;
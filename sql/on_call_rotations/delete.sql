@@ -0,0 +1,3 @@
+DELETE
+FROM on_call_rotations
+WHERE guild_id = $1 AND id = $2;
@@ -0,0 +1,6 @@
+DELETE
+FROM on_call_rotation_members
+USING on_call_rotations
+WHERE on_call_rotation_members.rotation_id = on_call_rotations.id
+  AND on_call_rotations.guild_id = $1
+  AND on_call_rotation_members.rotation_id = $2;
@@ -0,0 +1,4 @@
+SELECT id, team_id, name, start_time, shift_length
+FROM on_call_rotations
+WHERE guild_id = $1
+ORDER BY id ASC;
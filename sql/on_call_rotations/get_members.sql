@@ -0,0 +1,5 @@
+SELECT on_call_rotation_members.user_id
+FROM on_call_rotation_members
+INNER JOIN on_call_rotations ON on_call_rotations.id = on_call_rotation_members.rotation_id
+WHERE on_call_rotations.guild_id = $1 AND on_call_rotation_members.rotation_id = $2
+ORDER BY on_call_rotation_members.position ASC;
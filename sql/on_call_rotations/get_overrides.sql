@@ -0,0 +1,8 @@
+SELECT on_call_overrides.id, on_call_overrides.user_id, on_call_overrides.starts_at, on_call_overrides.ends_at
+FROM on_call_overrides
+INNER JOIN on_call_rotations ON on_call_rotations.id = on_call_overrides.rotation_id
+WHERE on_call_rotations.guild_id = $1
+  AND on_call_overrides.rotation_id = $2
+  AND on_call_overrides.ends_at > $3
+  AND on_call_overrides.starts_at < $4
+ORDER BY on_call_overrides.starts_at ASC, on_call_overrides.id ASC;
@@ -0,0 +1,21 @@
+-- A manual toggle on keeps a user on call regardless of the schedule. A manual toggle off takes a user off call until
+-- the end of the shift that they were scheduled for when they toggled. If $3 is set, team rotations for other teams
+-- are ignored.
+WITH scheduled (rotation_id, team_id, user_id, is_override, shift_start, shift_end) AS (
+    %s
+)
+SELECT user_id
+FROM on_call
+WHERE guild_id = $1 AND is_on_call
+UNION
+SELECT scheduled.user_id
+FROM scheduled
+WHERE ($3::int4 IS NULL OR scheduled.team_id IS NULL OR scheduled.team_id = $3)
+  AND NOT EXISTS(
+    SELECT 1
+    FROM on_call
+    WHERE on_call.guild_id = $1
+      AND on_call.user_id = scheduled.user_id
+      AND NOT on_call.is_on_call
+      AND on_call.updated_at >= scheduled.shift_start
+);
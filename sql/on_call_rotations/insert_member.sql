@@ -0,0 +1,4 @@
+INSERT INTO on_call_rotation_members (rotation_id, user_id, position)
+SELECT id, $3, $4
+FROM on_call_rotations
+WHERE guild_id = $1 AND id = $2;
@@ -0,0 +1,21 @@
+-- See get_users_on_call.sql
+WITH scheduled (rotation_id, team_id, user_id, is_override, shift_start, shift_end) AS (
+    %s
+)
+SELECT EXISTS(
+    SELECT 1
+    FROM on_call
+    WHERE guild_id = $1 AND user_id = $3 AND is_on_call
+) OR EXISTS(
+    SELECT 1
+    FROM scheduled
+    WHERE scheduled.user_id = $3
+      AND NOT EXISTS(
+        SELECT 1
+        FROM on_call
+        WHERE on_call.guild_id = $1
+          AND on_call.user_id = scheduled.user_id
+          AND NOT on_call.is_on_call
+          AND on_call.updated_at >= scheduled.shift_start
+    )
+);
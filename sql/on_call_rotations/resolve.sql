@@ -0,0 +1,45 @@
+WITH members AS (
+    SELECT rotation_id,
+           user_id,
+           ROW_NUMBER() OVER (PARTITION BY rotation_id ORDER BY position) - 1 AS member_index,
+           COUNT(*) OVER (PARTITION BY rotation_id)                           AS member_count
+    FROM on_call_rotation_members
+), shifts AS (
+    SELECT id                                                                                       AS rotation_id,
+           EXTRACT(EPOCH FROM start_time)                                                           AS start_epoch,
+           EXTRACT(EPOCH FROM shift_length)                                                         AS length_epoch,
+           FLOOR((EXTRACT(EPOCH FROM $2::timestamptz) - EXTRACT(EPOCH FROM start_time)) /
+                 EXTRACT(EPOCH FROM shift_length))::int8                                            AS shift
+    FROM on_call_rotations
+    WHERE guild_id = $1 AND start_time <= $2::timestamptz
+), scheduled AS (
+    SELECT shifts.rotation_id,
+           members.user_id,
+           TO_TIMESTAMP(shifts.start_epoch + shifts.shift * shifts.length_epoch)       AS shift_start,
+           TO_TIMESTAMP(shifts.start_epoch + (shifts.shift + 1) * shifts.length_epoch) AS shift_end
+    FROM shifts
+    INNER JOIN members
+        ON members.rotation_id = shifts.rotation_id AND members.member_index = shifts.shift % members.member_count
+), overrides AS (
+    SELECT DISTINCT ON (on_call_overrides.rotation_id) on_call_overrides.rotation_id,
+                                                       on_call_overrides.user_id,
+                                                       on_call_overrides.starts_at,
+                                                       on_call_overrides.ends_at
+    FROM on_call_overrides
+    INNER JOIN on_call_rotations ON on_call_rotations.id = on_call_overrides.rotation_id
+    WHERE on_call_rotations.guild_id = $1
+      AND on_call_overrides.starts_at <= $2::timestamptz
+      AND on_call_overrides.ends_at > $2::timestamptz
+    ORDER BY on_call_overrides.rotation_id, on_call_overrides.id DESC
+)
+SELECT on_call_rotations.id,
+       on_call_rotations.team_id,
+       COALESCE(overrides.user_id, scheduled.user_id),
+       overrides.user_id IS NOT NULL,
+       COALESCE(overrides.starts_at, scheduled.shift_start),
+       COALESCE(overrides.ends_at, scheduled.shift_end)
+FROM on_call_rotations
+LEFT OUTER JOIN scheduled ON scheduled.rotation_id = on_call_rotations.id
+LEFT OUTER JOIN overrides ON overrides.rotation_id = on_call_rotations.id
+WHERE on_call_rotations.guild_id = $1
+  AND (overrides.user_id IS NOT NULL OR scheduled.user_id IS NOT NULL)
@@ -0,0 +1,40 @@
+-- Shifts are a fixed length of time, counted from start_time, so handoffs happen at the same UTC time of day and move by
+-- an hour in local time when daylight saving time starts or ends. Each shift is assigned to the next member in order of
+-- position. A rotation with a team_id only puts its members on call for that team.
+CREATE TABLE IF NOT EXISTS on_call_rotations
+(
+    "id"           SERIAL      NOT NULL,
+    "guild_id"     int8        NOT NULL,
+    "team_id"      int4        NULL,
+    "name"         varchar(32) NOT NULL,
+    "start_time"   timestamptz NOT NULL,
+    "shift_length" interval    NOT NULL,
+    FOREIGN KEY ("team_id") REFERENCES support_team ("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    CHECK ("shift_length" >= interval '1 hour'),
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS on_call_rotations_guild_id ON on_call_rotations ("guild_id");
+
+CREATE TABLE IF NOT EXISTS on_call_rotation_members
+(
+    "rotation_id" int4 NOT NULL,
+    "user_id"     int8 NOT NULL,
+    "position"    int4 NOT NULL,
+    FOREIGN KEY ("rotation_id") REFERENCES on_call_rotations ("id") ON DELETE CASCADE,
+    UNIQUE ("rotation_id", "position"),
+    PRIMARY KEY ("rotation_id", "user_id")
+);
+
+-- An override replaces the scheduled member of a rotation for a period of time, e.g. to cover a shift
+CREATE TABLE IF NOT EXISTS on_call_overrides
+(
+    "id"          SERIAL      NOT NULL,
+    "rotation_id" int4        NOT NULL,
+    "user_id"     int8        NOT NULL,
+    "starts_at"   timestamptz NOT NULL,
+    "ends_at"     timestamptz NOT NULL,
+    FOREIGN KEY ("rotation_id") REFERENCES on_call_rotations ("id") ON DELETE CASCADE,
+    CHECK ("ends_at" > "starts_at"),
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS on_call_overrides_rotation_id_ends_at ON on_call_overrides ("rotation_id", "ends_at");
@@ -0,0 +1,12 @@
+WITH team AS (
+    SELECT $3::int4 IS NULL OR EXISTS(SELECT 1 FROM support_team WHERE id = $3 AND guild_id = $1) AS valid
+), updated AS (
+    UPDATE on_call_rotations
+    SET team_id      = $3,
+        name         = $4,
+        start_time   = $5,
+        shift_length = $6
+    WHERE guild_id = $1 AND id = $2 AND (SELECT valid FROM team)
+)
+SELECT valid
+FROM team;
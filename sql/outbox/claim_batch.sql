@@ -0,0 +1,18 @@
+WITH batch AS (
+    SELECT id
+    FROM outbox
+    WHERE delivered_at IS NULL
+      AND failed_at IS NULL
+      AND available_at <= NOW()
+      AND (locked_until IS NULL OR locked_until < NOW())
+    ORDER BY id ASC
+    LIMIT $1
+    FOR UPDATE SKIP LOCKED
+)
+UPDATE outbox
+SET locked_until = NOW() + $2::INTERVAL,
+    lease_id     = $3::uuid,
+    attempts     = outbox.attempts + 1
+FROM batch
+WHERE outbox.id = batch.id
+RETURNING outbox.id, outbox.aggregate_type, outbox.aggregate_id, outbox.guild_id, outbox.event_type, outbox.payload, outbox.created_at, outbox.attempts;
@@ -0,0 +1,3 @@
+SELECT COUNT(*)
+FROM outbox
+WHERE delivered_at IS NULL AND failed_at IS NULL;
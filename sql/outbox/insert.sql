@@ -0,0 +1,3 @@
+INSERT INTO outbox (aggregate_type, aggregate_id, guild_id, event_type, payload)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, created_at;
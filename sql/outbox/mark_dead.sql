@@ -0,0 +1,7 @@
+UPDATE outbox
+SET locked_until = NULL,
+    lease_id     = NULL,
+    failed_at    = NOW(),
+    last_error   = $2
+WHERE id = $1
+  AND lease_id = $3;
@@ -0,0 +1,7 @@
+UPDATE outbox
+SET delivered_at = NOW(),
+    locked_until = NULL,
+    lease_id     = NULL,
+    last_error   = NULL
+WHERE id = ANY($1)
+  AND lease_id = $2;
@@ -0,0 +1,7 @@
+UPDATE outbox
+SET locked_until = NULL,
+    lease_id     = NULL,
+    available_at = NOW() + $2::INTERVAL,
+    last_error   = $3
+WHERE id = $1
+  AND lease_id = $4;
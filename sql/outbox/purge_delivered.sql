@@ -0,0 +1,2 @@
+DELETE FROM outbox
+WHERE delivered_at < NOW() - $1::INTERVAL;
@@ -0,0 +1,6 @@
+UPDATE outbox
+SET locked_until = NOW() + $2::INTERVAL
+WHERE lease_id = $1
+  AND locked_until >= NOW()
+  AND delivered_at IS NULL
+  AND failed_at IS NULL;
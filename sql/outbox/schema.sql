@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS outbox
+(
+    id             BIGSERIAL   NOT NULL,
+    aggregate_type VARCHAR(32) NOT NULL,
+    aggregate_id   VARCHAR(64) NOT NULL,
+    guild_id       int8,
+    event_type     VARCHAR(64) NOT NULL,
+    payload        JSONB       NOT NULL DEFAULT '{}'::JSONB,
+    created_at     timestamptz NOT NULL DEFAULT NOW(),
+    available_at   timestamptz NOT NULL DEFAULT NOW(),
+    locked_until   timestamptz,
+    lease_id       uuid,
+    attempts       int4        NOT NULL DEFAULT 0,
+    last_error     TEXT,
+    delivered_at   timestamptz,
+    failed_at      timestamptz,
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS outbox_pending_idx ON outbox (available_at, id) WHERE delivered_at IS NULL AND failed_at IS NULL;
+CREATE INDEX IF NOT EXISTS outbox_delivered_at_idx ON outbox (delivered_at) WHERE delivered_at IS NOT NULL;
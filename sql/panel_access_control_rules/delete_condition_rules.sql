@@ -0,0 +1,3 @@
+DELETE
+FROM panel_access_condition_rules
+WHERE panel_id = $1;
\ No newline at end of file
@@ -0,0 +1,4 @@
+SELECT position, action, conditions
+FROM panel_access_condition_rules
+WHERE panel_id = $1
+ORDER BY position ASC;
\ No newline at end of file
@@ -0,0 +1,2 @@
+INSERT INTO panel_access_condition_rules (panel_id, position, action, conditions)
+VALUES ($1, $2, $3, $4);
\ No newline at end of file
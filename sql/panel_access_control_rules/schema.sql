@@ -10,3 +10,13 @@ CREATE TABLE IF NOT EXISTS panel_access_control_rules
     PRIMARY KEY ("panel_id", "role_id")
 );
 CREATE INDEX IF NOT EXISTS panel_access_control_rules_panel_id ON panel_access_control_rules ("panel_id");
+
+CREATE TABLE IF NOT EXISTS panel_access_condition_rules
+(
+    "panel_id"   int        NOT NULL,
+    "position"   int        NOT NULL,
+    "action"     varchar(5) NOT NULL,
+    "conditions" jsonb      NOT NULL DEFAULT '[]',
+    FOREIGN KEY ("panel_id") REFERENCES panels ("panel_id") ON DELETE CASCADE ON UPDATE CASCADE,
+    PRIMARY KEY ("panel_id", "position")
+);
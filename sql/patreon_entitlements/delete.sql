@@ -1,2 +1,3 @@
 DELETE FROM patreon_entitlements
-WHERE "entitlement_id" = $1;
\ No newline at end of file
+WHERE "entitlement_id" = $1
+RETURNING "entitlement_id";
\ No newline at end of file
@@ -1,2 +1,3 @@
 DELETE FROM patreon_entitlements
-WHERE "user_id" = $1;
\ No newline at end of file
+WHERE "user_id" = $1
+RETURNING "entitlement_id";
\ No newline at end of file
@@ -0,0 +1,2 @@
+DELETE FROM scheduled_jobs
+WHERE guild_id = $1 AND job_type = $2 AND job_key = $3;
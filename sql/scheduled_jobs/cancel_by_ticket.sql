@@ -0,0 +1,4 @@
+DELETE FROM scheduled_jobs
+WHERE guild_id = $1
+  AND ticket_id = $2
+  AND (CARDINALITY($3::VARCHAR[]) = 0 OR job_type = ANY($3::VARCHAR[]));
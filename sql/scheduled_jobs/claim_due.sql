@@ -0,0 +1,22 @@
+WITH due AS (
+    SELECT id
+    FROM scheduled_jobs
+    WHERE dead_at IS NULL
+      AND job_type = ANY($1::VARCHAR[])
+      AND run_at <= NOW()
+      AND (locked_until IS NULL OR locked_until < NOW())
+      AND attempts < $4
+    ORDER BY run_at ASC
+    LIMIT $2
+    FOR UPDATE SKIP LOCKED
+)
+UPDATE scheduled_jobs
+SET lease_id     = gen_random_uuid(),
+    locked_until = NOW() + $3::INTERVAL,
+    attempts     = scheduled_jobs.attempts + 1
+FROM due
+WHERE scheduled_jobs.id = due.id
+RETURNING scheduled_jobs.id, scheduled_jobs.guild_id, scheduled_jobs.ticket_id, scheduled_jobs.job_type,
+    scheduled_jobs.job_key, scheduled_jobs.payload, scheduled_jobs.run_at, scheduled_jobs.recur_interval,
+    scheduled_jobs.attempts, scheduled_jobs.last_error, scheduled_jobs.dead_at, scheduled_jobs.created_at,
+    scheduled_jobs.lease_id;
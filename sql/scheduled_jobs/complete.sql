@@ -0,0 +1,17 @@
+-- Recurring jobs are moved to their next occurrence after now, skipping any runs that were missed, rather than being
+-- deleted.
+WITH rescheduled AS (
+    UPDATE scheduled_jobs
+    SET run_at       = run_at + recur_interval * GREATEST(1, CEIL(EXTRACT(EPOCH FROM NOW() - run_at) / EXTRACT(EPOCH FROM recur_interval))),
+        lease_id     = NULL,
+        locked_until = NULL,
+        attempts     = 0,
+        last_error   = NULL
+    WHERE id = $1 AND lease_id = $2 AND recur_interval IS NOT NULL
+    RETURNING id
+), deleted AS (
+    DELETE FROM scheduled_jobs
+    WHERE id = $1 AND lease_id = $2 AND recur_interval IS NULL
+    RETURNING id
+)
+SELECT (SELECT COUNT(*) FROM rescheduled) + (SELECT COUNT(*) FROM deleted);
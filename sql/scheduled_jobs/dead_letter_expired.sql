@@ -0,0 +1,9 @@
+UPDATE scheduled_jobs
+SET dead_at      = NOW(),
+    lease_id     = NULL,
+    locked_until = NULL,
+    last_error   = COALESCE(last_error, 'lease expired')
+WHERE dead_at IS NULL
+  AND job_type = ANY($1::VARCHAR[])
+  AND attempts >= $2
+  AND (locked_until IS NULL OR locked_until < NOW());
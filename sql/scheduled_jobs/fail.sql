@@ -0,0 +1,6 @@
+UPDATE scheduled_jobs
+SET lease_id     = NULL,
+    locked_until = CASE WHEN attempts >= $4 THEN NULL ELSE NOW() + $3::INTERVAL END,
+    dead_at      = CASE WHEN attempts >= $4 THEN NOW() ELSE NULL END,
+    last_error   = $5
+WHERE id = $1 AND lease_id = $2;
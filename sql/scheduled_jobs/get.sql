@@ -0,0 +1,3 @@
+SELECT id, guild_id, ticket_id, job_type, job_key, payload, run_at, recur_interval, attempts, last_error, dead_at, created_at
+FROM scheduled_jobs
+WHERE guild_id = $1 AND job_type = $2 AND job_key = $3;
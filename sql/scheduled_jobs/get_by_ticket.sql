@@ -0,0 +1,4 @@
+SELECT id, guild_id, ticket_id, job_type, job_key, payload, run_at, recur_interval, attempts, last_error, dead_at, created_at
+FROM scheduled_jobs
+WHERE guild_id = $1 AND ticket_id = $2
+ORDER BY run_at ASC;
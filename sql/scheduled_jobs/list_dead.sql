@@ -0,0 +1,5 @@
+SELECT id, guild_id, ticket_id, job_type, job_key, payload, run_at, recur_interval, attempts, last_error, dead_at, created_at
+FROM scheduled_jobs
+WHERE dead_at IS NOT NULL
+ORDER BY dead_at DESC
+LIMIT $1;
@@ -0,0 +1,8 @@
+UPDATE scheduled_jobs
+SET dead_at      = NULL,
+    attempts     = 0,
+    last_error   = NULL,
+    lease_id     = NULL,
+    locked_until = NULL,
+    run_at       = NOW()
+WHERE id = $1 AND dead_at IS NOT NULL;
@@ -0,0 +1,13 @@
+INSERT INTO scheduled_jobs (guild_id, ticket_id, job_type, job_key, payload, run_at, recur_interval)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (guild_id, job_type, job_key) DO UPDATE
+SET ticket_id      = EXCLUDED.ticket_id,
+    payload        = EXCLUDED.payload,
+    run_at         = EXCLUDED.run_at,
+    recur_interval = EXCLUDED.recur_interval,
+    lease_id       = NULL,
+    locked_until   = NULL,
+    attempts       = 0,
+    last_error     = NULL,
+    dead_at        = NULL
+RETURNING id, created_at;
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS scheduled_jobs (
+    id BIGSERIAL PRIMARY KEY,
+    guild_id INT8 NOT NULL,
+    ticket_id INT4 NULL,
+    job_type VARCHAR(64) NOT NULL,
+    job_key VARCHAR(255) NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}'::JSONB,
+    run_at TIMESTAMPTZ NOT NULL,
+    recur_interval INTERVAL NULL,
+    lease_id UUID NULL,
+    locked_until TIMESTAMPTZ NULL,
+    attempts INT4 NOT NULL DEFAULT 0,
+    last_error TEXT NULL,
+    dead_at TIMESTAMPTZ NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (guild_id, job_type, job_key),
+    FOREIGN KEY (guild_id, ticket_id) REFERENCES tickets(guild_id, id) ON DELETE CASCADE,
+    CHECK (recur_interval IS NULL OR recur_interval > '0'::INTERVAL)
+);
+
+CREATE INDEX IF NOT EXISTS scheduled_jobs_due_idx ON scheduled_jobs (job_type, run_at) WHERE dead_at IS NULL;
+CREATE INDEX IF NOT EXISTS scheduled_jobs_ticket_idx ON scheduled_jobs (guild_id, ticket_id) WHERE ticket_id IS NOT NULL;
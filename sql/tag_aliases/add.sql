@@ -0,0 +1,5 @@
+INSERT INTO tag_aliases (guild_id, alias, tag_id)
+SELECT $1, LOWER($2), tags.tag_id
+FROM tags
+WHERE tags.guild_id = $1 AND tags.tag_id = LOWER($3)
+ON CONFLICT (guild_id, alias) DO UPDATE SET tag_id = excluded.tag_id;
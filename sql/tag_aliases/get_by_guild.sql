@@ -0,0 +1,3 @@
+SELECT alias, tag_id
+FROM tag_aliases
+WHERE guild_id = $1;
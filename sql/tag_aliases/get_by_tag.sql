@@ -0,0 +1,4 @@
+SELECT alias
+FROM tag_aliases
+WHERE guild_id = $1 AND tag_id = LOWER($2)
+ORDER BY alias;
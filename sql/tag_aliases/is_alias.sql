@@ -0,0 +1 @@
+SELECT EXISTS(SELECT 1 FROM tag_aliases WHERE guild_id = $1 AND alias = LOWER($2));
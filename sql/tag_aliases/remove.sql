@@ -0,0 +1,2 @@
+DELETE FROM tag_aliases
+WHERE guild_id = $1 AND alias = LOWER($2);
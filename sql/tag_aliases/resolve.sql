@@ -0,0 +1,8 @@
+SELECT tag_id
+FROM tags
+WHERE guild_id = $1 AND tag_id = LOWER($2)
+UNION ALL
+SELECT tag_id
+FROM tag_aliases
+WHERE guild_id = $1 AND alias = LOWER($2)
+LIMIT 1;
@@ -0,0 +1,10 @@
+-- Aliases share the namespace of tag IDs: TagAliases.Add and TagsTable.Set check that an alias is not also a tag ID
+CREATE TABLE IF NOT EXISTS tag_aliases
+(
+    "guild_id" int8        NOT NULL,
+    "alias"    varchar(16) NOT NULL,
+    "tag_id"   varchar(16) NOT NULL,
+    FOREIGN KEY ("guild_id", "tag_id") REFERENCES tags ("guild_id", "tag_id") ON DELETE CASCADE ON UPDATE CASCADE,
+    PRIMARY KEY ("guild_id", "alias")
+);
+CREATE INDEX IF NOT EXISTS tag_aliases_guild_id_tag_id ON tag_aliases ("guild_id", "tag_id");
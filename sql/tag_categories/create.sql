@@ -0,0 +1,3 @@
+INSERT INTO tag_categories (guild_id, name)
+VALUES ($1, $2)
+RETURNING id;
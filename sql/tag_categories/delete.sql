@@ -0,0 +1,2 @@
+DELETE FROM tag_categories
+WHERE guild_id = $1 AND id = $2;
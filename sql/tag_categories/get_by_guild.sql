@@ -0,0 +1,4 @@
+SELECT id, name
+FROM tag_categories
+WHERE guild_id = $1
+ORDER BY name;
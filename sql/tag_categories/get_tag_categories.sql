@@ -0,0 +1,3 @@
+SELECT tag_id, category_id
+FROM tags
+WHERE guild_id = $1 AND category_id IS NOT NULL;
@@ -0,0 +1,3 @@
+UPDATE tag_categories
+SET name = $3
+WHERE guild_id = $1 AND id = $2;
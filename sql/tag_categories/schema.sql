@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS tag_categories
+(
+    "id"       SERIAL      NOT NULL,
+    "guild_id" int8        NOT NULL,
+    "name"     varchar(32) NOT NULL,
+    UNIQUE ("guild_id", "name"),
+    PRIMARY KEY ("id")
+);
+
+-- Tags are uncategorised if their category is deleted
+ALTER TABLE tags ADD COLUMN IF NOT EXISTS "category_id" int4 DEFAULT NULL REFERENCES tag_categories ("id") ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS tags_category_id ON tags ("category_id") WHERE "category_id" IS NOT NULL;
@@ -0,0 +1,6 @@
+-- Does nothing if the category does not belong to the guild
+UPDATE tags
+SET category_id = $3
+WHERE guild_id = $1
+  AND tag_id = LOWER($2)
+  AND ($3::int4 IS NULL OR EXISTS(SELECT 1 FROM tag_categories WHERE guild_id = $1 AND id = $3));
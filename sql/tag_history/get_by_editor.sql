@@ -0,0 +1,5 @@
+SELECT tag_id, version, action, content, embed, edited_by, edited_at
+FROM tag_history
+WHERE guild_id = $1 AND edited_by = $2
+ORDER BY edited_at DESC
+LIMIT $3;
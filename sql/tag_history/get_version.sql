@@ -0,0 +1,3 @@
+SELECT version, action, content, embed, edited_by, edited_at
+FROM tag_history
+WHERE guild_id = $1 AND tag_id = LOWER($2) AND version = $3;
@@ -0,0 +1,29 @@
+-- A version is only recorded if the content or embed changed, so that e.g. registering the tag's application command
+-- does not create a new version. If no action is given, it is inferred from the previous version.
+INSERT INTO tag_history (guild_id, tag_id, version, action, content, embed, edited_by)
+SELECT $1,
+       LOWER($2),
+       COALESCE(latest.version, 0) + 1,
+       COALESCE(
+               $3::tag_history_action,
+               CASE
+                   WHEN latest.version IS NULL OR latest.action = 'deleted' THEN 'created'::tag_history_action
+                   ELSE 'updated'::tag_history_action
+                   END
+       ),
+       $4,
+       $5::jsonb,
+       $6::int8
+FROM (SELECT 1) AS one
+LEFT OUTER JOIN LATERAL (
+    SELECT version, action, content, embed
+    FROM tag_history
+    WHERE guild_id = $1 AND tag_id = LOWER($2)
+    ORDER BY version DESC
+    LIMIT 1
+) latest ON true
+WHERE latest.version IS NULL
+   OR $3::tag_history_action = 'deleted'
+   OR latest.action = 'deleted'
+   OR latest.content IS DISTINCT FROM $4
+   OR latest.embed IS DISTINCT FROM $5::jsonb;
@@ -0,0 +1,16 @@
+CREATE TYPE tag_history_action AS ENUM ('created', 'updated', 'deleted', 'reverted');
+
+-- Each version is a snapshot of the tag after the change. Deleted versions have no content or embed.
+CREATE TABLE IF NOT EXISTS tag_history
+(
+    "guild_id"  int8               NOT NULL,
+    "tag_id"    varchar(16)        NOT NULL,
+    "version"   int4               NOT NULL,
+    "action"    tag_history_action NOT NULL,
+    "content"   text               NULL,
+    "embed"     jsonb              NULL,
+    "edited_by" int8               NULL,
+    "edited_at" timestamptz        NOT NULL DEFAULT NOW(),
+    PRIMARY KEY ("guild_id", "tag_id", "version")
+);
+CREATE INDEX IF NOT EXISTS tag_history_guild_id_edited_by ON tag_history ("guild_id", "edited_by");
@@ -0,0 +1,2 @@
+DELETE FROM tag_scopes
+WHERE guild_id = $1 AND tag_id = LOWER($2);
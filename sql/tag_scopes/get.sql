@@ -0,0 +1,3 @@
+SELECT panel_id, team_id
+FROM tag_scopes
+WHERE guild_id = $1 AND tag_id = LOWER($2);
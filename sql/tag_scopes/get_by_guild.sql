@@ -0,0 +1,3 @@
+SELECT tag_id, panel_id, team_id
+FROM tag_scopes
+WHERE guild_id = $1;
@@ -0,0 +1,40 @@
+-- Matches both tag IDs and aliases. Each tag is returned at most once, by its ID if it matches, else by its first
+-- matching alias.
+SELECT name
+FROM (
+    SELECT DISTINCT ON (names.tag_id) names.name
+    FROM (
+        SELECT tag_id, tag_id AS name, true AS canonical
+        FROM tags
+        WHERE guild_id = $1
+        UNION ALL
+        SELECT tag_id, alias, false
+        FROM tag_aliases
+        WHERE guild_id = $1
+    ) names
+    WHERE names.name LIKE LOWER($2) || '%'
+      AND (
+            NOT EXISTS(
+                SELECT 1
+                FROM tag_scopes
+                WHERE tag_scopes.guild_id = $1 AND tag_scopes.tag_id = names.tag_id
+            ) OR
+            EXISTS(
+                SELECT 1
+                FROM tag_scopes
+                WHERE tag_scopes.guild_id = $1
+                  AND tag_scopes.tag_id = names.tag_id
+                  AND (
+                        tag_scopes.panel_id = $3::int4 OR
+                        tag_scopes.team_id IN (
+                            SELECT team_id FROM support_team_members WHERE user_id = $4
+                            UNION
+                            SELECT team_id FROM support_team_roles WHERE role_id = ANY($5::int8[])
+                        )
+                    )
+            )
+        )
+    ORDER BY names.tag_id, names.canonical DESC, names.name
+) matched
+ORDER BY name
+LIMIT $6;
@@ -0,0 +1,13 @@
+-- The panel or team must belong to the tag's guild
+WITH scope AS (
+    SELECT $3::int4 IS NULL OR EXISTS(SELECT 1 FROM panels WHERE panel_id = $3 AND guild_id = $1) AS valid_panel,
+           $4::int4 IS NULL OR EXISTS(SELECT 1 FROM support_team WHERE id = $4 AND guild_id = $1) AS valid_team
+), inserted AS (
+    INSERT INTO tag_scopes (guild_id, tag_id, panel_id, team_id)
+    SELECT $1, LOWER($2), $3, $4
+    FROM scope
+    WHERE scope.valid_panel AND scope.valid_team
+    ON CONFLICT DO NOTHING
+)
+SELECT valid_panel, valid_team
+FROM scope;
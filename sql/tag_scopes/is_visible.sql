@@ -0,0 +1,28 @@
+-- Returns false if the tag does not exist
+SELECT EXISTS(
+    SELECT 1
+    FROM tags
+    WHERE tags.guild_id = $1
+      AND tags.tag_id = LOWER($2)
+      AND (
+            NOT EXISTS(
+                SELECT 1
+                FROM tag_scopes
+                WHERE tag_scopes.guild_id = $1 AND tag_scopes.tag_id = tags.tag_id
+            ) OR
+            EXISTS(
+                SELECT 1
+                FROM tag_scopes
+                WHERE tag_scopes.guild_id = $1
+                  AND tag_scopes.tag_id = tags.tag_id
+                  AND (
+                        tag_scopes.panel_id = $3::int4 OR
+                        tag_scopes.team_id IN (
+                            SELECT team_id FROM support_team_members WHERE user_id = $4
+                            UNION
+                            SELECT team_id FROM support_team_roles WHERE role_id = ANY($5::int8[])
+                        )
+                    )
+            )
+        )
+);
@@ -0,0 +1,16 @@
+-- A tag without any scopes is visible everywhere. Otherwise, it is only visible in tickets opened from one of its panels,
+-- and to members of one of its support teams.
+CREATE TABLE IF NOT EXISTS tag_scopes
+(
+    "guild_id" int8        NOT NULL,
+    "tag_id"   varchar(16) NOT NULL,
+    "panel_id" int4        NULL,
+    "team_id"  int4        NULL,
+    FOREIGN KEY ("guild_id", "tag_id") REFERENCES tags ("guild_id", "tag_id") ON DELETE CASCADE ON UPDATE CASCADE,
+    FOREIGN KEY ("panel_id") REFERENCES panels ("panel_id") ON DELETE CASCADE ON UPDATE CASCADE,
+    FOREIGN KEY ("team_id") REFERENCES support_team ("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    CHECK (num_nonnulls("panel_id", "team_id") = 1)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS tag_scopes_target ON tag_scopes ("guild_id", "tag_id", COALESCE("panel_id", 0), COALESCE("team_id", 0));
+CREATE INDEX IF NOT EXISTS tag_scopes_panel_id ON tag_scopes ("panel_id") WHERE "panel_id" IS NOT NULL;
+CREATE INDEX IF NOT EXISTS tag_scopes_team_id ON tag_scopes ("team_id") WHERE "team_id" IS NOT NULL;
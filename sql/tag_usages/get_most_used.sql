@@ -0,0 +1,7 @@
+SELECT tags.tag_id, COUNT(tag_usages.id), MAX(tag_usages.used_at)
+FROM tags
+INNER JOIN tag_usages ON tag_usages.guild_id = tags.guild_id AND tag_usages.tag_id = tags.tag_id
+WHERE tags.guild_id = $1 AND tag_usages.used_at >= $2
+GROUP BY tags.tag_id
+ORDER BY COUNT(tag_usages.id) DESC, tags.tag_id ASC
+LIMIT $3;
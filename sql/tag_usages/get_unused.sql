@@ -0,0 +1,11 @@
+-- Also returns when each tag was last used, if ever
+SELECT tags.tag_id,
+       (SELECT MAX(used_at) FROM tag_usages WHERE tag_usages.guild_id = tags.guild_id AND tag_usages.tag_id = tags.tag_id)
+FROM tags
+WHERE tags.guild_id = $1
+  AND NOT EXISTS(
+    SELECT 1
+    FROM tag_usages
+    WHERE tag_usages.guild_id = tags.guild_id AND tag_usages.tag_id = tags.tag_id AND tag_usages.used_at >= $2
+)
+ORDER BY tags.tag_id ASC;
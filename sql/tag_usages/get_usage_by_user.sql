@@ -0,0 +1,5 @@
+SELECT user_id, COUNT(*), COUNT(DISTINCT tag_id), MAX(used_at)
+FROM tag_usages
+WHERE guild_id = $1 AND used_at >= $2
+GROUP BY user_id
+ORDER BY COUNT(*) DESC, user_id ASC;
@@ -0,0 +1,5 @@
+SELECT tag_id, COUNT(*), MAX(used_at)
+FROM tag_usages
+WHERE guild_id = $1 AND user_id = $2 AND used_at >= $3
+GROUP BY tag_id
+ORDER BY COUNT(*) DESC, tag_id ASC;
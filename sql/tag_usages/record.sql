@@ -0,0 +1,9 @@
+-- The tag may be referred to by one of its aliases, in which case the use is recorded against the tag itself
+INSERT INTO tag_usages (guild_id, tag_id, ticket_id, user_id)
+VALUES ($1,
+        COALESCE(
+            (SELECT tag_id FROM tag_aliases WHERE guild_id = $1 AND alias = LOWER($2)),
+            LOWER($2)
+        ),
+        $3,
+        $4);
@@ -0,0 +1,13 @@
+-- Usages are kept when a tag is deleted, so that a tag recreated with the same ID keeps its history
+CREATE TABLE IF NOT EXISTS tag_usages
+(
+    "id"        BIGSERIAL   NOT NULL,
+    "guild_id"  int8        NOT NULL,
+    "tag_id"    varchar(16) NOT NULL,
+    "ticket_id" int4        NULL,
+    "user_id"   int8        NOT NULL,
+    "used_at"   timestamptz NOT NULL DEFAULT NOW(),
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS tag_usages_guild_id_tag_id_used_at ON tag_usages ("guild_id", "tag_id", "used_at");
+CREATE INDEX IF NOT EXISTS tag_usages_guild_id_user_id_used_at ON tag_usages ("guild_id", "user_id", "used_at");
@@ -0,0 +1,8 @@
+SELECT placeholder
+FROM template_placeholder_refs
+WHERE guild_id = $1
+  AND source_type = $2
+  AND tag_id IS NOT DISTINCT FROM LOWER($3)
+  AND panel_id IS NOT DISTINCT FROM $4::int4
+  AND embed_id IS NOT DISTINCT FROM $5::int4
+ORDER BY placeholder;
@@ -0,0 +1,6 @@
+-- Templates in the guild that reference any of the integration's placeholders
+SELECT refs.source_type, refs.tag_id, refs.panel_id, refs.embed_id, refs.placeholder
+FROM template_placeholder_refs refs
+INNER JOIN custom_integration_placeholders placeholders ON placeholders.name = refs.placeholder
+WHERE refs.guild_id = $1 AND placeholders.integration_id = $2
+ORDER BY refs.source_type, refs.tag_id, refs.panel_id, refs.embed_id, refs.placeholder;
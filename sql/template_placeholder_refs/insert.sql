@@ -0,0 +1,3 @@
+INSERT INTO template_placeholder_refs (guild_id, source_type, tag_id, panel_id, embed_id, placeholder)
+VALUES ($1, $2, LOWER($3), $4, $5, $6)
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,83 @@
+CREATE TYPE template_source_type AS ENUM ('tag', 'panel', 'embed', 'welcome_message');
+
+-- The placeholders referenced by each template. Exactly the ID column for the source type is set: welcome messages are
+-- per guild, so have none.
+CREATE TABLE IF NOT EXISTS template_placeholder_refs
+(
+    "guild_id"    int8                 NOT NULL,
+    "source_type" template_source_type NOT NULL,
+    "tag_id"      varchar(16)          NULL,
+    "panel_id"    int4                 NULL,
+    "embed_id"    int4                 NULL,
+    "placeholder" varchar(32)          NOT NULL,
+    FOREIGN KEY ("guild_id", "tag_id") REFERENCES tags ("guild_id", "tag_id") ON DELETE CASCADE ON UPDATE CASCADE,
+    FOREIGN KEY ("panel_id") REFERENCES panels ("panel_id") ON DELETE CASCADE ON UPDATE CASCADE,
+    FOREIGN KEY ("embed_id") REFERENCES embeds ("id") ON DELETE CASCADE,
+    CHECK (
+        ("source_type" = 'tag') = ("tag_id" IS NOT NULL) AND
+        ("source_type" = 'panel') = ("panel_id" IS NOT NULL) AND
+        ("source_type" = 'embed') = ("embed_id" IS NOT NULL)
+    )
+);
+CREATE UNIQUE INDEX IF NOT EXISTS template_placeholder_refs_source ON template_placeholder_refs ("guild_id", "source_type", COALESCE("tag_id", ''), COALESCE("panel_id", 0), COALESCE("embed_id", 0), "placeholder");
+CREATE INDEX IF NOT EXISTS template_placeholder_refs_guild_id_placeholder ON template_placeholder_refs ("guild_id", "placeholder");
+CREATE INDEX IF NOT EXISTS template_placeholder_refs_panel_id ON template_placeholder_refs ("panel_id") WHERE "panel_id" IS NOT NULL;
+CREATE INDEX IF NOT EXISTS template_placeholder_refs_embed_id ON template_placeholder_refs ("embed_id") WHERE "embed_id" IS NOT NULL;
+
+-- Backfill the refs of templates saved before refs were recorded. The placeholders are not validated, as they were
+-- accepted when the templates were saved. Templates are joined by newlines, which cannot appear in a placeholder, so
+-- that a placeholder cannot span two templates. Only runs while no refs exist, so that it is skipped once populated.
+INSERT INTO template_placeholder_refs (guild_id, source_type, tag_id, panel_id, embed_id, placeholder)
+SELECT DISTINCT templates.guild_id,
+                templates.source_type::template_source_type,
+                templates.tag_id,
+                templates.panel_id,
+                templates.embed_id,
+                (REGEXP_MATCHES(templates.template, '%([a-zA-Z][a-zA-Z0-9_]{0,31})%', 'g'))[1]
+FROM (
+    SELECT tags.guild_id,
+           'tag'          AS source_type,
+           tags.tag_id,
+           NULL::int4     AS panel_id,
+           NULL::int4     AS embed_id,
+           CONCAT_WS(E'\n',
+                     tags.content,
+                     tags.embed ->> 'title',
+                     tags.embed ->> 'description',
+                     tags.embed ->> 'author_name',
+                     tags.embed ->> 'footer_text',
+                     (
+                         SELECT STRING_AGG(CONCAT_WS(E'\n', field ->> 'name', field ->> 'value'), E'\n')
+                         FROM JSONB_ARRAY_ELEMENTS(
+                             CASE WHEN JSONB_TYPEOF(tags.embed -> 'fields') = 'array' THEN tags.embed -> 'fields' ELSE '[]' END
+                         ) field
+                     )
+           )              AS template
+    FROM tags
+    UNION ALL
+    SELECT panels.guild_id, 'panel', NULL, panels.panel_id, NULL, CONCAT_WS(E'\n', panels.title, panels.content)
+    FROM panels
+    UNION ALL
+    SELECT embeds.guild_id,
+           'embed',
+           NULL,
+           NULL,
+           embeds.id,
+           CONCAT_WS(E'\n',
+                     embeds.title,
+                     embeds.description,
+                     embeds.author_name,
+                     embeds.footer_text,
+                     (
+                         SELECT STRING_AGG(CONCAT_WS(E'\n', embed_fields.name, embed_fields.value), E'\n')
+                         FROM embed_fields
+                         WHERE embed_fields.embed_id = embeds.id
+                     )
+           )
+    FROM embeds
+    UNION ALL
+    SELECT welcome_messages.guild_id, 'welcome_message', NULL, NULL, NULL, welcome_messages.welcome_message
+    FROM welcome_messages
+) templates
+WHERE NOT EXISTS(SELECT 1 FROM template_placeholder_refs)
+ON CONFLICT DO NOTHING;
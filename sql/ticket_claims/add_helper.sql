@@ -0,0 +1,11 @@
+-- The primary claimer cannot also be a helper
+WITH added AS (
+    INSERT INTO ticket_claim_helpers (guild_id, ticket_id, user_id)
+    SELECT $1, $2, $3
+    WHERE NOT EXISTS(SELECT 1 FROM ticket_claims WHERE guild_id = $1 AND ticket_id = $2 AND user_id = $3)
+    ON CONFLICT DO NOTHING
+    RETURNING user_id
+)
+INSERT INTO ticket_claim_events (guild_id, ticket_id, event_type, user_id, actor_id)
+SELECT $1, $2, 'helper_added', added.user_id, $4::int8
+FROM added;
@@ -0,0 +1,9 @@
+WITH removed AS (
+    DELETE
+    FROM ticket_claims
+    WHERE guild_id = $1 AND ticket_id = $2
+    RETURNING user_id
+)
+INSERT INTO ticket_claim_events (guild_id, ticket_id, event_type, user_id, actor_id)
+SELECT $1, $2, 'unclaimed', removed.user_id, $3::int8
+FROM removed;
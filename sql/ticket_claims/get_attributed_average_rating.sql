@@ -0,0 +1,6 @@
+WITH weights (ticket_id, weight) AS (
+    %s
+)
+SELECT (SUM(weights.weight * service_ratings.rating) / NULLIF(SUM(weights.weight), 0))::float4
+FROM weights
+INNER JOIN service_ratings ON service_ratings.guild_id = $1 AND service_ratings.ticket_id = weights.ticket_id;
@@ -0,0 +1,5 @@
+WITH weights (ticket_id, weight) AS (
+    %s
+)
+SELECT COALESCE(SUM(weight), 0)
+FROM weights;
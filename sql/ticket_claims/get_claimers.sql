@@ -0,0 +1,18 @@
+-- The primary claimer's claim time is unknown if they claimed the ticket before claim events were recorded
+SELECT ticket_claims.user_id,
+       true,
+       (
+           SELECT MAX(created_at)
+           FROM ticket_claim_events
+           WHERE ticket_claim_events.guild_id = ticket_claims.guild_id
+             AND ticket_claim_events.ticket_id = ticket_claims.ticket_id
+             AND ticket_claim_events.user_id = ticket_claims.user_id
+             AND ticket_claim_events.event_type IN ('claimed', 'reassigned')
+       )
+FROM ticket_claims
+WHERE ticket_claims.guild_id = $1 AND ticket_claims.ticket_id = $2
+UNION ALL
+SELECT user_id, false, added_at
+FROM ticket_claim_helpers
+WHERE guild_id = $1 AND ticket_id = $2
+ORDER BY 2 DESC, 3 ASC;
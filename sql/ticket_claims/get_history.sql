@@ -0,0 +1,4 @@
+SELECT event_type, user_id, previous_user_id, actor_id, created_at
+FROM ticket_claim_events
+WHERE guild_id = $1 AND ticket_id = $2
+ORDER BY created_at ASC, id ASC;
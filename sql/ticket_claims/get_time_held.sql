@@ -0,0 +1,7 @@
+WITH held AS (
+    %s
+)
+SELECT user_id, seconds
+FROM held
+WHERE ticket_id = $2
+ORDER BY seconds DESC;
@@ -0,0 +1,19 @@
+-- Tickets claimed before claim events were recorded are attributed entirely to the primary claimer
+WITH held AS (
+    %s
+), shares AS (
+    SELECT ticket_id, user_id, seconds / NULLIF(SUM(seconds) OVER (PARTITION BY ticket_id), 0) AS weight
+    FROM held
+)
+SELECT ticket_id, user_id, weight
+FROM shares
+WHERE weight IS NOT NULL
+UNION ALL
+SELECT ticket_id, user_id, 1::float8
+FROM ticket_claims
+WHERE guild_id = $1
+  AND NOT EXISTS(
+    SELECT 1
+    FROM ticket_claim_events
+    WHERE ticket_claim_events.guild_id = $1 AND ticket_claim_events.ticket_id = ticket_claims.ticket_id
+)
@@ -0,0 +1,31 @@
+-- Pairs each event that starts a user holding a claim with the next event for that user, which ends it. Time is only
+-- counted while the ticket is open.
+WITH boundaries AS (
+    SELECT ticket_id,
+           user_id,
+           id,
+           created_at,
+           event_type IN ('claimed', 'reassigned', 'helper_added') AS is_start
+    FROM ticket_claim_events
+    WHERE guild_id = $1
+    UNION ALL
+    SELECT ticket_id, previous_user_id, id, created_at, false
+    FROM ticket_claim_events
+    WHERE guild_id = $1 AND event_type = 'reassigned' AND previous_user_id IS NOT NULL
+), spans AS (
+    SELECT ticket_id,
+           user_id,
+           is_start,
+           created_at                                                                                  AS started_at,
+           LEAD(created_at) OVER (PARTITION BY ticket_id, user_id ORDER BY created_at, is_start, id) AS ended_at
+    FROM boundaries
+)
+SELECT spans.ticket_id,
+       spans.user_id,
+       SUM(GREATEST(EXTRACT(EPOCH FROM
+                            LEAST(COALESCE(spans.ended_at, NOW()), COALESCE(tickets.close_time, NOW())) -
+                            spans.started_at), 0))::float8 AS seconds
+FROM spans
+INNER JOIN tickets ON tickets.guild_id = $1 AND tickets.id = spans.ticket_id
+WHERE spans.is_start
+GROUP BY spans.ticket_id, spans.user_id
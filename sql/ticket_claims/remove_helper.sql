@@ -0,0 +1,9 @@
+WITH removed AS (
+    DELETE
+    FROM ticket_claim_helpers
+    WHERE guild_id = $1 AND ticket_id = $2 AND user_id = $3
+    RETURNING user_id
+)
+INSERT INTO ticket_claim_events (guild_id, ticket_id, event_type, user_id, actor_id)
+SELECT $1, $2, 'helper_removed', removed.user_id, $4::int8
+FROM removed;
@@ -0,0 +1,29 @@
+-- If the new claimer was a helper, they are promoted, ending their time held as a helper
+WITH previous AS (
+    SELECT user_id
+    FROM ticket_claims
+    WHERE guild_id = $1 AND ticket_id = $2
+    FOR UPDATE
+), claimed AS (
+    INSERT INTO ticket_claims (guild_id, ticket_id, user_id)
+    VALUES ($1, $2, $3)
+    ON CONFLICT (guild_id, ticket_id) DO UPDATE SET user_id = $3
+), promoted AS (
+    DELETE
+    FROM ticket_claim_helpers
+    WHERE guild_id = $1 AND ticket_id = $2 AND user_id = $3
+    RETURNING user_id
+)
+INSERT INTO ticket_claim_events (guild_id, ticket_id, event_type, user_id, previous_user_id, actor_id)
+SELECT $1, $2, 'helper_removed', promoted.user_id, NULL, $4::int8
+FROM promoted
+UNION ALL
+SELECT $1,
+       $2,
+       CASE WHEN previous.user_id IS NULL THEN 'claimed' ELSE 'reassigned' END::claim_event_type,
+       $3,
+       previous.user_id,
+       $4::int8
+FROM (SELECT 1) AS one
+LEFT OUTER JOIN previous ON true
+WHERE previous.user_id IS DISTINCT FROM $3;
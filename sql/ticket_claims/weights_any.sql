@@ -0,0 +1,11 @@
+SELECT ticket_id, 1::float8 AS weight
+FROM ticket_claims
+WHERE guild_id = $1 AND user_id = $2
+UNION
+SELECT ticket_id, 1
+FROM ticket_claim_helpers
+WHERE guild_id = $1 AND user_id = $2
+UNION
+SELECT ticket_id, 1
+FROM ticket_claim_events
+WHERE guild_id = $1 AND user_id = $2 AND event_type IN ('claimed', 'reassigned', 'helper_added')
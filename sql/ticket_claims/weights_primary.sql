@@ -0,0 +1,3 @@
+SELECT ticket_id, 1::float8 AS weight
+FROM ticket_claims
+WHERE guild_id = $1 AND user_id = $2
@@ -0,0 +1,37 @@
+SELECT
+    base.id,
+    base.user_id,
+    base.channel_id,
+    base.panel_id,
+    base.status,
+    base.open,
+    base.open_time,
+    base.close_time,
+    base.has_transcript,
+    base.is_thread,
+    close_reason.close_reason,
+    close_reason.closed_by,
+    COALESCE(close_reason.auto_closed, false),
+    service_ratings.rating,
+    ticket_claims.user_id,
+    first_response_time.user_id,
+    first_response_time.response_time,
+    exit_survey.responses::text
+FROM (%[1]s) base
+LEFT JOIN close_reason
+    ON close_reason.guild_id = base.guild_id AND close_reason.ticket_id = base.id
+LEFT JOIN service_ratings
+    ON service_ratings.guild_id = base.guild_id AND service_ratings.ticket_id = base.id
+LEFT JOIN ticket_claims
+    ON ticket_claims.guild_id = base.guild_id AND ticket_claims.ticket_id = base.id
+LEFT JOIN first_response_time
+    ON first_response_time.guild_id = base.guild_id AND first_response_time.ticket_id = base.id
+LEFT JOIN LATERAL (
+    SELECT jsonb_object_agg(COALESCE(form_input.label, exit_survey_responses.question_id::text), exit_survey_responses.response) AS responses
+    FROM exit_survey_responses
+    LEFT JOIN form_input ON form_input.id = exit_survey_responses.question_id
+    WHERE exit_survey_responses.guild_id = base.guild_id
+        AND exit_survey_responses.ticket_id = base.id
+        AND exit_survey_responses.question_id IS NOT NULL
+) exit_survey ON true
+ORDER BY base.id %[2]s
@@ -0,0 +1,12 @@
+WITH scope AS (
+    SELECT $2::int4 IS NULL OR EXISTS(SELECT 1 FROM panels WHERE panel_id = $2 AND guild_id = $1) AS valid_panel,
+           $3::int4 IS NULL OR EXISTS(SELECT 1 FROM support_team WHERE id = $3 AND guild_id = $1) AS valid_team
+), inserted AS (
+    INSERT INTO ticket_open_limits (guild_id, panel_id, team_id, role_id, limit_type, max_count, period, per_user)
+    SELECT $1, $2, $3, $4, $5, $6, $7, $8
+    FROM scope
+    WHERE scope.valid_panel AND scope.valid_team
+    RETURNING id
+)
+SELECT scope.valid_panel, scope.valid_team, (SELECT id FROM inserted)
+FROM scope;
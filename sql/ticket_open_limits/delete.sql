@@ -0,0 +1,2 @@
+DELETE FROM ticket_open_limits
+WHERE guild_id = $1 AND id = $2;
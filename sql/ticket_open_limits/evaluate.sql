@@ -0,0 +1,67 @@
+-- $1 = guild ID, $2 = panel ID, $3 = user ID, $4 = role IDs
+WITH rules AS (
+    SELECT id, panel_id, team_id, role_id, limit_type, max_count, period, per_user
+    FROM ticket_open_limits
+    WHERE guild_id = $1
+        AND (panel_id IS NULL OR panel_id = $2::int4)
+        AND (team_id IS NULL OR team_id IN (SELECT panel_teams.team_id FROM panel_teams WHERE panel_teams.panel_id = $2::int4))
+        AND (role_id IS NULL OR role_id = ANY($4::int8[]))
+    UNION ALL
+    -- The legacy per-guild limit applies until it is replaced by a guild-wide per-user max_open rule
+    SELECT
+        0,
+        NULL,
+        NULL,
+        NULL,
+        'max_open'::ticket_open_limit_type,
+        COALESCE((SELECT ticket_limit."limit"::int4 FROM ticket_limit WHERE ticket_limit.guild_id = $1), 5),
+        NULL::interval,
+        true
+    WHERE NOT EXISTS(
+        SELECT 1
+        FROM ticket_open_limits
+        WHERE guild_id = $1
+            AND panel_id IS NULL AND team_id IS NULL AND role_id IS NULL
+            AND limit_type = 'max_open' AND per_user
+    )
+)
+SELECT
+    rules.id,
+    rules.panel_id,
+    rules.team_id,
+    rules.role_id,
+    rules.limit_type,
+    rules.max_count,
+    rules.period,
+    rules.per_user,
+    usage.count,
+    usage.count >= COALESCE(rules.max_count, 1) AS blocked,
+    retry.retry_at
+FROM rules
+CROSS JOIN LATERAL (
+    SELECT
+        CASE
+            WHEN rules.limit_type = 'max_open' THEN COUNT(*) FILTER (WHERE scoped.open)
+            ELSE COUNT(*) FILTER (WHERE scoped.open_time > NOW() - rules.period)
+        END AS count
+    FROM tickets scoped
+    WHERE scoped.guild_id = $1
+        AND (NOT rules.per_user OR scoped.user_id = $3)
+        AND (rules.panel_id IS NULL OR scoped.panel_id = rules.panel_id)
+        AND (rules.team_id IS NULL OR scoped.panel_id IN (SELECT panel_teams.panel_id FROM panel_teams WHERE panel_teams.team_id = rules.team_id))
+) usage
+-- Once the max_count-th most recent ticket leaves the window, there is room for another ticket. A cooldown is a window
+-- with a max_count of 1.
+LEFT JOIN LATERAL (
+    SELECT scoped.open_time + rules.period AS retry_at
+    FROM tickets scoped
+    WHERE rules.limit_type <> 'max_open'
+        AND scoped.guild_id = $1
+        AND (NOT rules.per_user OR scoped.user_id = $3)
+        AND (rules.panel_id IS NULL OR scoped.panel_id = rules.panel_id)
+        AND (rules.team_id IS NULL OR scoped.panel_id IN (SELECT panel_teams.panel_id FROM panel_teams WHERE panel_teams.team_id = rules.team_id))
+        AND scoped.open_time > NOW() - rules.period
+    ORDER BY scoped.open_time DESC
+    OFFSET COALESCE(rules.max_count, 1) - 1
+    LIMIT 1
+) retry ON true;
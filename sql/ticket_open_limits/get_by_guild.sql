@@ -0,0 +1,4 @@
+SELECT id, guild_id, panel_id, team_id, role_id, limit_type, max_count, period, per_user
+FROM ticket_open_limits
+WHERE guild_id = $1
+ORDER BY id;
@@ -0,0 +1,28 @@
+CREATE TYPE ticket_open_limit_type AS ENUM ('max_open', 'cooldown', 'window_cap');
+
+-- A rule applies to the whole guild, or to tickets opened from a panel, from a panel assigned to a support team, or by a
+-- user with a role.
+CREATE TABLE IF NOT EXISTS ticket_open_limits
+(
+    id         SERIAL                 NOT NULL,
+    guild_id   int8                   NOT NULL,
+    panel_id   int4                   NULL,
+    team_id    int4                   NULL,
+    role_id    int8                   NULL,
+    limit_type ticket_open_limit_type NOT NULL,
+    max_count  int4                   NULL,
+    period     interval               NULL,
+    per_user   bool                   NOT NULL DEFAULT true,
+    PRIMARY KEY (id),
+    FOREIGN KEY (panel_id) REFERENCES panels (panel_id) ON DELETE CASCADE,
+    FOREIGN KEY (team_id) REFERENCES support_team (id) ON DELETE CASCADE,
+    CHECK (num_nonnulls(panel_id, team_id, role_id) <= 1),
+    CHECK (
+        (limit_type = 'max_open' AND max_count > 0 AND period IS NULL) OR
+        (limit_type = 'cooldown' AND max_count IS NULL AND period > '0'::interval) OR
+        (limit_type = 'window_cap' AND max_count > 0 AND period > '0'::interval)
+    )
+);
+
+CREATE INDEX IF NOT EXISTS ticket_open_limits_guild_id ON ticket_open_limits (guild_id);
+CREATE INDEX IF NOT EXISTS tickets_guild_id_user_id_open_time ON tickets (guild_id, user_id, open_time);
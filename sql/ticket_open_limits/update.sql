@@ -0,0 +1,10 @@
+WITH scope AS (
+    SELECT $3::int4 IS NULL OR EXISTS(SELECT 1 FROM panels WHERE panel_id = $3 AND guild_id = $1) AS valid_panel,
+           $4::int4 IS NULL OR EXISTS(SELECT 1 FROM support_team WHERE id = $4 AND guild_id = $1) AS valid_team
+), updated AS (
+    UPDATE ticket_open_limits
+    SET panel_id = $3, team_id = $4, role_id = $5, limit_type = $6, max_count = $7, period = $8, per_user = $9
+    WHERE guild_id = $1 AND id = $2 AND (SELECT valid_panel AND valid_team FROM scope)
+)
+SELECT valid_panel, valid_team
+FROM scope;
@@ -0,0 +1,3 @@
+SELECT priority
+FROM ticket_priority
+WHERE guild_id = $1 AND ticket_id = $2;
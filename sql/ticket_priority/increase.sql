@@ -0,0 +1,6 @@
+INSERT INTO ticket_priority (guild_id, ticket_id, priority, updated_at)
+VALUES ($1, $2, $3, NOW())
+ON CONFLICT (guild_id, ticket_id) DO UPDATE
+    SET priority   = ticket_priority.priority + EXCLUDED.priority,
+        updated_at = EXCLUDED.updated_at
+RETURNING priority;
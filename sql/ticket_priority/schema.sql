@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS ticket_priority
+(
+    "guild_id"   int8        NOT NULL,
+    "ticket_id"  int4        NOT NULL,
+    "priority"   int4        NOT NULL DEFAULT 0,
+    "updated_at" timestamptz NOT NULL DEFAULT NOW(),
+    FOREIGN KEY ("guild_id", "ticket_id") REFERENCES tickets ("guild_id", "id") ON DELETE CASCADE,
+    PRIMARY KEY ("guild_id", "ticket_id")
+);
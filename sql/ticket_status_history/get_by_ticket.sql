@@ -0,0 +1,4 @@
+SELECT status, changed_at
+FROM ticket_status_history
+WHERE guild_id = $1 AND ticket_id = $2
+ORDER BY changed_at ASC, id ASC;
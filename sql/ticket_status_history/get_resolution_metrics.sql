@@ -0,0 +1,66 @@
+WITH closed_tickets AS (
+    SELECT
+        tickets.guild_id,
+        tickets.id,
+        tickets.panel_id,
+        tickets.open_time,
+        tickets.close_time,
+        close_reason.closed_by,
+        COALESCE(close_reason.auto_closed, false) AS auto_closed
+    FROM tickets
+    LEFT JOIN close_reason ON close_reason.guild_id = tickets.guild_id AND close_reason.ticket_id = tickets.id
+    WHERE tickets.guild_id = $1
+      AND NOT tickets.open
+      AND tickets.close_time IS NOT NULL
+      AND ($2::INTERVAL IS NULL OR tickets.close_time > NOW() - $2::INTERVAL)
+), transitions AS (
+    SELECT
+        ticket_status_history.ticket_id,
+        ticket_status_history.status,
+        ticket_status_history.changed_at,
+        LEAD(ticket_status_history.changed_at) OVER w AS next_changed_at,
+        LAG(ticket_status_history.status) OVER w AS previous_status,
+        ROW_NUMBER() OVER w AS position
+    FROM ticket_status_history
+    INNER JOIN closed_tickets ON closed_tickets.id = ticket_status_history.ticket_id
+    WHERE ticket_status_history.guild_id = $1
+    WINDOW w AS (PARTITION BY ticket_status_history.ticket_id ORDER BY ticket_status_history.changed_at, ticket_status_history.id)
+), history AS (
+    SELECT
+        ticket_id,
+        -- History is only complete for tickets that were opened after it started being recorded
+        BOOL_OR(position = 1 AND status = 'OPEN') AS complete,
+        COALESCE(SUM(COALESCE(next_changed_at, NOW()) - changed_at) FILTER (WHERE status = 'OPEN'), '0'::INTERVAL) AS time_open,
+        COALESCE(SUM(COALESCE(next_changed_at, NOW()) - changed_at) FILTER (WHERE status = 'PENDING'), '0'::INTERVAL) AS time_pending,
+        COUNT(*) FILTER (WHERE previous_status = 'CLOSED') AS reopens
+    FROM transitions
+    GROUP BY ticket_id
+), resolved AS (
+    SELECT
+        closed_tickets.*,
+        -- Time spent closed between a close and a reopen does not count towards the resolution time
+        CASE
+            WHEN history.complete THEN history.time_open + history.time_pending
+            ELSE closed_tickets.close_time - closed_tickets.open_time
+        END AS time_to_close,
+        CASE WHEN history.complete THEN history.time_open END AS time_open,
+        CASE WHEN history.complete THEN history.time_pending END AS time_pending,
+        COALESCE(history.reopens, 0) AS reopens
+    FROM closed_tickets
+    LEFT JOIN history ON history.ticket_id = closed_tickets.id
+)
+SELECT
+    %[1]s AS group_key,
+    COUNT(*) AS ticket_count,
+    COUNT(*) FILTER (WHERE resolved.auto_closed) AS auto_closed_count,
+    AVG(resolved.time_to_close) AS average_time_to_close,
+    PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY resolved.time_to_close) AS median_time_to_close,
+    AVG(resolved.time_to_close) FILTER (WHERE NOT resolved.auto_closed) AS average_time_to_close_staff,
+    COALESCE(SUM(resolved.reopens), 0)::INT4 AS reopens,
+    COUNT(*) FILTER (WHERE resolved.reopens > 0) AS reopened_ticket_count,
+    AVG(resolved.time_open) AS average_time_open,
+    AVG(resolved.time_pending) AS average_time_pending
+FROM resolved
+%[2]s
+GROUP BY group_key
+ORDER BY ticket_count DESC, group_key ASC NULLS LAST;
@@ -0,0 +1,10 @@
+-- Consecutive duplicate statuses are not recorded, e.g. SetStatus(OPEN) after a reopen
+INSERT INTO ticket_status_history (guild_id, ticket_id, status, changed_at)
+SELECT $1::INT8, $2::INT4, $3::ticket_status, NOW()
+WHERE COALESCE((
+    SELECT status
+    FROM ticket_status_history
+    WHERE guild_id = $1 AND ticket_id = $2
+    ORDER BY changed_at DESC, id DESC
+    LIMIT 1
+) <> $3, TRUE);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS ticket_status_history (
+    id BIGSERIAL PRIMARY KEY,
+    guild_id INT8 NOT NULL,
+    ticket_id INT4 NOT NULL,
+    status ticket_status NOT NULL,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (guild_id, ticket_id) REFERENCES tickets(guild_id, id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS ticket_status_history_ticket_idx ON ticket_status_history (guild_id, ticket_id, changed_at);
@@ -0,0 +1,3 @@
+DELETE
+FROM ticket_subscriptions
+WHERE guild_id = $1 AND user_id = $2 AND id = $3;
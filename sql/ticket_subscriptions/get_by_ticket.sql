@@ -0,0 +1,4 @@
+SELECT id, user_id, notify_message, notify_status_change, notify_close
+FROM ticket_subscriptions
+WHERE guild_id = $1 AND ticket_id = $2
+ORDER BY id ASC;
@@ -0,0 +1,66 @@
+-- Each user's most specific subscription applies: a ticket subscription overrides a panel subscription, which overrides
+-- a team subscription. Subscribers are only notified while they can still access the ticket: admins, support users if
+-- the ticket is handled by the default team, and members of the teams assigned to the ticket's panel. Access can also be
+-- granted by roles, which are not stored, so subscribers without direct access are returned with the roles that would
+-- grant it.
+WITH ticket AS (
+    SELECT tickets.panel_id, COALESCE(panels.default_team, true) AS default_team
+    FROM tickets
+    LEFT OUTER JOIN panels ON panels.panel_id = tickets.panel_id
+    WHERE tickets.guild_id = $1 AND tickets.id = $2
+), matched AS (
+    SELECT DISTINCT ON (ticket_subscriptions.user_id) ticket_subscriptions.user_id,
+                                                      ticket_subscriptions.%s AS notify
+    FROM ticket_subscriptions
+    CROSS JOIN ticket
+    WHERE ticket_subscriptions.guild_id = $1
+      AND (
+            ticket_subscriptions.ticket_id = $2 OR
+            ticket_subscriptions.panel_id = ticket.panel_id OR
+            ticket_subscriptions.team_id IN (SELECT team_id FROM panel_teams WHERE panel_teams.panel_id = ticket.panel_id)
+        )
+    ORDER BY ticket_subscriptions.user_id,
+             ticket_subscriptions.ticket_id IS NULL,
+             ticket_subscriptions.panel_id IS NULL
+), direct AS (
+    SELECT matched.user_id,
+           EXISTS(
+               SELECT 1
+               FROM permissions
+               WHERE permissions.guild_id = $1
+                 AND permissions.user_id = matched.user_id
+                 AND (permissions.admin OR (permissions.support AND ticket.default_team))
+           ) OR
+           EXISTS(
+               SELECT 1
+               FROM support_team_members
+               INNER JOIN panel_teams ON panel_teams.team_id = support_team_members.team_id
+               WHERE panel_teams.panel_id = ticket.panel_id
+                 AND support_team_members.user_id = matched.user_id
+           ) AS has_access
+    FROM matched
+    CROSS JOIN ticket
+    WHERE matched.notify
+      AND matched.user_id IS DISTINCT FROM $3::int8
+), access_roles AS (
+    SELECT ARRAY_AGG(DISTINCT roles.role_id) AS role_ids
+    FROM (
+        SELECT role_permissions.role_id
+        FROM role_permissions
+        CROSS JOIN ticket
+        WHERE role_permissions.guild_id = $1
+          AND (role_permissions.admin OR (role_permissions.support AND ticket.default_team))
+        UNION
+        SELECT support_team_roles.role_id
+        FROM support_team_roles
+        INNER JOIN panel_teams ON panel_teams.team_id = support_team_roles.team_id
+        CROSS JOIN ticket
+        WHERE panel_teams.panel_id = ticket.panel_id
+    ) roles
+)
+SELECT direct.user_id,
+       direct.has_access,
+       CASE WHEN direct.has_access THEN NULL ELSE access_roles.role_ids END
+FROM direct
+CROSS JOIN access_roles
+WHERE direct.has_access OR access_roles.role_ids IS NOT NULL;
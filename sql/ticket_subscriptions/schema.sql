@@ -0,0 +1,23 @@
+-- A subscription is to exactly one of a ticket, the tickets opened from a panel, or the tickets opened from the panels
+-- assigned to a support team.
+CREATE TABLE IF NOT EXISTS ticket_subscriptions
+(
+    "id"                   SERIAL NOT NULL,
+    "guild_id"             int8   NOT NULL,
+    "user_id"              int8   NOT NULL,
+    "ticket_id"            int4   NULL,
+    "panel_id"             int4   NULL,
+    "team_id"              int4   NULL,
+    "notify_message"       bool   NOT NULL DEFAULT true,
+    "notify_status_change" bool   NOT NULL DEFAULT true,
+    "notify_close"         bool   NOT NULL DEFAULT true,
+    FOREIGN KEY ("guild_id", "ticket_id") REFERENCES tickets ("guild_id", "id") ON DELETE CASCADE,
+    FOREIGN KEY ("panel_id") REFERENCES panels ("panel_id") ON DELETE CASCADE ON UPDATE CASCADE,
+    FOREIGN KEY ("team_id") REFERENCES support_team ("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    CHECK (num_nonnulls("ticket_id", "panel_id", "team_id") = 1),
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS ticket_subscriptions_target ON ticket_subscriptions ("guild_id", "user_id", COALESCE("ticket_id", 0), COALESCE("panel_id", 0), COALESCE("team_id", 0));
+CREATE INDEX IF NOT EXISTS ticket_subscriptions_ticket_id ON ticket_subscriptions ("guild_id", "ticket_id") WHERE "ticket_id" IS NOT NULL;
+CREATE INDEX IF NOT EXISTS ticket_subscriptions_panel_id ON ticket_subscriptions ("panel_id") WHERE "panel_id" IS NOT NULL;
+CREATE INDEX IF NOT EXISTS ticket_subscriptions_team_id ON ticket_subscriptions ("team_id") WHERE "team_id" IS NOT NULL;
@@ -0,0 +1,8 @@
+INSERT INTO ticket_subscriptions (guild_id, user_id, ticket_id, panel_id, team_id, notify_message, notify_status_change,
+                                  notify_close)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT (guild_id, user_id, COALESCE(ticket_id, 0), COALESCE(panel_id, 0), COALESCE(team_id, 0)) DO UPDATE
+    SET notify_message       = EXCLUDED.notify_message,
+        notify_status_change = EXCLUDED.notify_status_change,
+        notify_close         = EXCLUDED.notify_close
+RETURNING id;
@@ -0,0 +1,189 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// StaffLeaderboard aggregates the per-user statistics from ticket_claims, participant, first_response_time,
+// service_ratings and close_reason, so that a leaderboard can be built without querying each staff member separately.
+// It has no tables of its own.
+type StaffLeaderboard struct {
+	*pgxpool.Pool
+}
+
+type StaffLeaderboardSort string
+
+const (
+	StaffLeaderboardSortClaims          StaffLeaderboardSort = "claims"
+	StaffLeaderboardSortParticipations  StaffLeaderboardSort = "participations"
+	StaffLeaderboardSortAverageResponse StaffLeaderboardSort = "average_response"
+	StaffLeaderboardSortMedianResponse  StaffLeaderboardSort = "median_response"
+	StaffLeaderboardSortAverageRating   StaffLeaderboardSort = "average_rating"
+	StaffLeaderboardSortTicketsClosed   StaffLeaderboardSort = "tickets_closed"
+)
+
+// Response times are sorted ascending, as a lower response time is better
+var staffLeaderboardOrderBy = map[StaffLeaderboardSort]string{
+	StaffLeaderboardSortClaims:          "claims DESC",
+	StaffLeaderboardSortParticipations:  "participations DESC",
+	StaffLeaderboardSortAverageResponse: "average_response ASC NULLS LAST",
+	StaffLeaderboardSortMedianResponse:  "median_response ASC NULLS LAST",
+	StaffLeaderboardSortAverageRating:   "average_rating DESC NULLS LAST",
+	StaffLeaderboardSortTicketsClosed:   "tickets_closed DESC",
+}
+
+type StaffLeaderboardEntry struct {
+	UserId          uint64         `json:"user_id,string"`
+	Claims          int            `json:"claims"`
+	Participations  int            `json:"participations"`
+	AverageResponse *time.Duration `json:"average_response"`
+	MedianResponse  *time.Duration `json:"median_response"`
+	AverageRating   *float32       `json:"average_rating"`
+	RatingCount     int            `json:"rating_count"`
+	TicketsClosed   int            `json:"tickets_closed"`
+}
+
+func newStaffLeaderboard(db *pgxpool.Pool) *StaffLeaderboard {
+	return &StaffLeaderboard{
+		db,
+	}
+}
+
+// GetStaffLeaderboard returns statistics for each user with support or admin permissions, or who is a member of a
+// support team, in the guild. If teamId is not nil, only members of that support team are included. Only tickets
+// opened within the interval are counted; a zero interval includes all time. Ratings are attributed to claimers using
+// the given attribution, and with ClaimAttributionTimeWeighted, each rating is weighted by the share of time the user
+// held the ticket. Members of support teams who are only granted access through a role cannot be resolved from the
+// database, and so are not included.
+func (l *StaffLeaderboard) GetStaffLeaderboard(
+	ctx context.Context,
+	guildId uint64,
+	teamId *int,
+	interval time.Duration,
+	sortBy StaffLeaderboardSort,
+	attribution ClaimAttribution,
+	limit int,
+) ([]StaffLeaderboardEntry, error) {
+	orderBy, ok := staffLeaderboardOrderBy[sortBy]
+	if !ok {
+		return nil, fmt.Errorf("invalid staff leaderboard sort: %s", sortBy)
+	}
+
+	ratingWeights, err := guildClaimWeightsQuery(attribution)
+	if err != nil {
+		return nil, err
+	}
+
+	parsedInterval := pgtype.Interval{Status: pgtype.Null}
+	if interval > 0 {
+		if parsedInterval, err = toInterval(interval); err != nil {
+			return nil, err
+		}
+	}
+
+	query := fmt.Sprintf(`
+WITH staff AS (
+	SELECT permissions.user_id
+	FROM permissions
+	WHERE permissions.guild_id = $1 AND (permissions.support OR permissions.admin) AND $2::int4 IS NULL
+	UNION
+	SELECT support_team_members.user_id
+	FROM support_team_members
+	INNER JOIN support_team ON support_team.id = support_team_members.team_id
+	WHERE support_team.guild_id = $1 AND ($2::int4 IS NULL OR support_team.id = $2)
+), ticket_range AS (
+	SELECT tickets.id
+	FROM tickets
+	WHERE tickets.guild_id = $1 AND ($3::interval IS NULL OR tickets.open_time > NOW() - $3::interval)
+), claims AS (
+	SELECT ticket_claims.user_id, COUNT(*) AS count
+	FROM ticket_claims
+	INNER JOIN ticket_range ON ticket_range.id = ticket_claims.ticket_id
+	WHERE ticket_claims.guild_id = $1
+	GROUP BY ticket_claims.user_id
+), participations AS (
+	SELECT participant.user_id, COUNT(*) AS count
+	FROM participant
+	INNER JOIN ticket_range ON ticket_range.id = participant.ticket_id
+	WHERE participant.guild_id = $1
+	GROUP BY participant.user_id
+), responses AS (
+	SELECT
+		first_response_time.user_id,
+		AVG(first_response_time.response_time) AS average,
+		PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY first_response_time.response_time) AS median
+	FROM first_response_time
+	INNER JOIN ticket_range ON ticket_range.id = first_response_time.ticket_id
+	WHERE first_response_time.guild_id = $1
+	GROUP BY first_response_time.user_id
+), rating_weights (ticket_id, user_id, weight) AS (
+	%s
+), ratings AS (
+	SELECT
+		rating_weights.user_id,
+		(SUM(rating_weights.weight * service_ratings.rating) / NULLIF(SUM(rating_weights.weight), 0))::float4 AS average,
+		COUNT(*) AS count
+	FROM rating_weights
+	INNER JOIN service_ratings
+		ON service_ratings.guild_id = $1 AND service_ratings.ticket_id = rating_weights.ticket_id
+	INNER JOIN ticket_range ON ticket_range.id = rating_weights.ticket_id
+	GROUP BY rating_weights.user_id
+), closed AS (
+	SELECT close_reason.closed_by AS user_id, COUNT(*) AS count
+	FROM close_reason
+	INNER JOIN ticket_range ON ticket_range.id = close_reason.ticket_id
+	WHERE close_reason.guild_id = $1 AND close_reason.closed_by IS NOT NULL
+	GROUP BY close_reason.closed_by
+)
+SELECT
+	staff.user_id,
+	COALESCE(claims.count, 0) AS claims,
+	COALESCE(participations.count, 0) AS participations,
+	responses.average AS average_response,
+	responses.median AS median_response,
+	ratings.average AS average_rating,
+	COALESCE(ratings.count, 0) AS rating_count,
+	COALESCE(closed.count, 0) AS tickets_closed
+FROM staff
+LEFT JOIN claims ON claims.user_id = staff.user_id
+LEFT JOIN participations ON participations.user_id = staff.user_id
+LEFT JOIN responses ON responses.user_id = staff.user_id
+LEFT JOIN ratings ON ratings.user_id = staff.user_id
+LEFT JOIN closed ON closed.user_id = staff.user_id
+ORDER BY %s, staff.user_id ASC
+LIMIT $4;
+`, ratingWeights, orderBy)
+
+	rows, err := l.Query(ctx, query, guildId, teamId, parsedInterval, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var entries []StaffLeaderboardEntry
+	for rows.Next() {
+		var entry StaffLeaderboardEntry
+		if err := rows.Scan(
+			&entry.UserId,
+			&entry.Claims,
+			&entry.Participations,
+			&entry.AverageResponse,
+			&entry.MedianResponse,
+			&entry.AverageRating,
+			&entry.RatingCount,
+			&entry.TicketsClosed,
+		); err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
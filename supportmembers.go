@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
@@ -46,8 +47,23 @@ func (s *SupportTeamMembersTable) Get(ctx context.Context, teamId int) (members
 }
 
 func (s *SupportTeamMembersTable) Add(ctx context.Context, teamId int, userId uint64) (err error) {
+	tx, err := s.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	if err := s.AddWithTx(ctx, tx, teamId, userId); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (s *SupportTeamMembersTable) AddWithTx(ctx context.Context, tx pgx.Tx, teamId int, userId uint64) (err error) {
 	query := `INSERT INTO support_team_members("team_id", "user_id") VALUES($1, $2) ON CONFLICT (team_id, user_id) DO NOTHING;`
-	_, err = s.Exec(ctx, query, teamId, userId)
+	_, err = tx.Exec(ctx, query, teamId, userId)
 	return
 }
 
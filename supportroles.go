@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
@@ -46,8 +47,23 @@ func (s *SupportTeamRolesTable) Get(ctx context.Context, teamId int) (roles []ui
 }
 
 func (s *SupportTeamRolesTable) Add(ctx context.Context, teamId int, roleId uint64) (err error) {
+	tx, err := s.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	if err := s.AddWithTx(ctx, tx, teamId, roleId); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (s *SupportTeamRolesTable) AddWithTx(ctx context.Context, tx pgx.Tx, teamId int, roleId uint64) (err error) {
 	query := `INSERT INTO support_team_roles("team_id", "role_id") VALUES($1, $2) ON CONFLICT (team_id, role_id) DO NOTHING;`
-	_, err = s.Exec(ctx, query, teamId, roleId)
+	_, err = tx.Exec(ctx, query, teamId, roleId)
 	return
 }
 
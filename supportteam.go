@@ -9,6 +9,8 @@ import (
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
+var ErrSupportTeamNotFound = errors.New("support team not found")
+
 type SupportTeamTable struct {
 	*pgxpool.Pool
 }
@@ -229,7 +231,24 @@ WHERE support_team.guild_id = $1;
 }
 
 func (s *SupportTeamTable) Create(ctx context.Context, guildId uint64, name string) (id int, err error) {
-	err = s.QueryRow(ctx, `INSERT INTO support_team("guild_id", "name") VALUES($1, $2) RETURNING "id";`, guildId, name).Scan(&id)
+	tx, err := s.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	id, err = s.CreateWithTx(ctx, tx, guildId, name)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit(ctx)
+	return
+}
+
+func (s *SupportTeamTable) CreateWithTx(ctx context.Context, tx pgx.Tx, guildId uint64, name string) (id int, err error) {
+	err = tx.QueryRow(ctx, `INSERT INTO support_team("guild_id", "name") VALUES($1, $2) RETURNING "id";`, guildId, name).Scan(&id)
 	return
 }
 
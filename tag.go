@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 
+	"github.com/jackc/pgtype"
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 )
@@ -16,6 +17,11 @@ type Tag struct {
 	ApplicationCommandId *uint64
 }
 
+var (
+	ErrTagVersionNotFound = errors.New("tag version not found")
+	ErrTagNameTaken       = errors.New("tag name is already taken by another tag or alias")
+)
+
 type TagsTable struct {
 	*pgxpool.Pool
 	repository *Database
@@ -41,17 +47,22 @@ CREATE INDEX IF NOT EXISTS tags_guild_id_idx ON tags("guild_id");
 `
 }
 
+// Exists returns true if the name is either a tag ID or an alias
 func (t *TagsTable) Exists(ctx context.Context, guildId uint64, tagId string) (exists bool, err error) {
-	query := `SELECT EXISTS(SELECT 1 FROM tags WHERE "guild_id" = $1 AND LOWER("tag_id") = LOWER($2));`
+	query := `
+SELECT EXISTS(SELECT 1 FROM tags WHERE "guild_id" = $1 AND LOWER("tag_id") = LOWER($2))
+	OR EXISTS(SELECT 1 FROM tag_aliases WHERE "guild_id" = $1 AND "alias" = LOWER($2));`
 	err = t.QueryRow(ctx, query, guildId, tagId).Scan(&exists)
 	return
 }
 
+// Get looks up the tag by its ID or an alias. The returned tag's Id is always the tag ID.
 func (t *TagsTable) Get(ctx context.Context, guildId uint64, tagId string) (Tag, bool, error) {
 	query := `
 SELECT LOWER(tag_id), "guild_id", "content", "embed", "application_command_id"
 FROM tags
-WHERE "guild_id" = $1 AND LOWER("tag_id") = LOWER($2);
+WHERE "guild_id" = $1
+	AND LOWER("tag_id") = COALESCE((SELECT "tag_id" FROM tag_aliases WHERE "guild_id" = $1 AND "alias" = LOWER($2)), LOWER($2));
 `
 
 	var tag Tag
@@ -168,28 +179,97 @@ func (t *TagsTable) GetTagCount(ctx context.Context, guildId uint64) (count int,
 	return
 }
 
-func (t *TagsTable) GetStartingWith(ctx context.Context, guildId uint64, prefix string, limit int) (tagIds []string, e error) {
-	query := `SELECT LOWER("tag_id") FROM tags WHERE "guild_id"=$1 AND "tag_id" LIKE $2 || '%' LIMIT $3;`
-	rows, err := t.Query(ctx, query, guildId, prefix, limit)
-	defer rows.Close()
-	if err != nil && err != pgx.ErrNoRows {
-		e = err
-		return
+// GetStartingWith returns the tag IDs and aliases starting with the prefix, for autocomplete, regardless of TagScopes.
+// Each tag is returned at most once, by its ID if it matches, else by its first matching alias.
+func (t *TagsTable) GetStartingWith(ctx context.Context, guildId uint64, prefix string, limit int) ([]string, error) {
+	query := `
+SELECT name
+FROM (
+	SELECT DISTINCT ON (names.tag_id) names.name
+	FROM (
+		SELECT "tag_id", "tag_id" AS name, true AS canonical FROM tags WHERE "guild_id" = $1
+		UNION ALL
+		SELECT "tag_id", "alias", false FROM tag_aliases WHERE "guild_id" = $1
+	) names
+	WHERE names.name LIKE LOWER($2) || '%'
+	ORDER BY names.tag_id, names.canonical DESC, names.name
+) matched
+ORDER BY name
+LIMIT $3;`
+
+	return t.queryNames(ctx, query, guildId, prefix, limit)
+}
+
+// GetStartingWithForViewer is the same as GetStartingWith, but only returns tags that are visible to the viewer
+// according to TagScopes.
+func (t *TagsTable) GetStartingWithForViewer(ctx context.Context, guildId uint64, prefix string, viewer TagViewer, limit int) ([]string, error) {
+	roleIds := &pgtype.Int8Array{}
+	if err := roleIds.Set(viewer.RoleIds); err != nil {
+		return nil, err
 	}
 
+	return t.queryNames(ctx, tagScopesGetStartingWith, guildId, prefix, viewer.PanelId, viewer.UserId, roleIds, limit)
+}
+
+func (t *TagsTable) queryNames(ctx context.Context, query string, args ...interface{}) ([]string, error) {
+	rows, err := t.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var names []string
 	for rows.Next() {
-		var id string
-		if err := rows.Scan(&id); err != nil {
+		var name string
+		if err := rows.Scan(&name); err != nil {
 			return nil, err
 		}
 
-		tagIds = append(tagIds, id)
+		names = append(names, name)
 	}
 
-	return
+	return names, rows.Err()
 }
 
 func (t *TagsTable) Set(ctx context.Context, tag Tag) error {
+	tx, err := t.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	if err := t.SetWithTx(ctx, tx, tag); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// SetBy is the same as Set, but records the user who made the change in the tag's history
+func (t *TagsTable) SetBy(ctx context.Context, tag Tag, editedBy uint64) error {
+	tx, err := t.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	if err := t.set(ctx, tx, tag, nil, &editedBy); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (t *TagsTable) SetWithTx(ctx context.Context, tx pgx.Tx, tag Tag) error {
+	return t.set(ctx, tx, tag, nil, nil)
+}
+
+// set records a new version in the tag's history if the content or embed changed. If action is nil, it is inferred.
+// Returns ErrTagNameTaken if the tag ID is already an alias.
+func (t *TagsTable) set(ctx context.Context, tx pgx.Tx, tag Tag, action *TagHistoryAction, editedBy *uint64) error {
 	query := `
 INSERT INTO tags("tag_id", "guild_id", "content", "embed", "application_command_id")
 VALUES(LOWER($1), $2, $3, $4, $5)
@@ -206,15 +286,109 @@ UPDATE SET "content" = $3, "embed" = $4, "application_command_id" = $5;`
 		embedRaw = &tmp
 	}
 
-	_, err := t.Exec(ctx, query, tag.Id, tag.GuildId, tag.Content, embedRaw, tag.ApplicationCommandId)
+	var isAlias bool
+	if err := tx.QueryRow(ctx, tagAliasesIsAlias, tag.GuildId, tag.Id).Scan(&isAlias); err != nil {
+		return err
+	}
+
+	if isAlias {
+		return ErrTagNameTaken
+	}
+
+	if _, err := tx.Exec(ctx, query, tag.Id, tag.GuildId, tag.Content, embedRaw, tag.ApplicationCommandId); err != nil {
+		return err
+	}
+
+	templates := EmbedTemplates(tag.Embed)
+	if tag.Content != nil {
+		templates = append(templates, *tag.Content)
+	}
+
+	if err := recordTemplatePlaceholders(ctx, tx, tag.GuildId, TagTemplateSource(tag.Id), templates...); err != nil {
+		return err
+	}
+
+	_, err := tx.Exec(ctx, tagHistoryRecord, tag.GuildId, tag.Id, action, tag.Content, embedRaw, editedBy)
 	return err
 }
 
-func (t *TagsTable) Delete(ctx context.Context, guildId uint64, tagId string) (err error) {
+func (t *TagsTable) Delete(ctx context.Context, guildId uint64, tagId string) error {
+	return t.delete(ctx, guildId, tagId, nil)
+}
+
+// DeleteBy is the same as Delete, but records the user who deleted the tag in the tag's history
+func (t *TagsTable) DeleteBy(ctx context.Context, guildId uint64, tagId string, deletedBy uint64) error {
+	return t.delete(ctx, guildId, tagId, &deletedBy)
+}
+
+func (t *TagsTable) delete(ctx context.Context, guildId uint64, tagId string, deletedBy *uint64) error {
 	query := `
 DELETE FROM tags 
 WHERE "guild_id" = $1 AND "tag_id" = LOWER($2);`
 
-	_, err = t.Exec(ctx, query, guildId, tagId)
-	return
+	tx, err := t.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	res, err := tx.Exec(ctx, query, guildId, tagId)
+	if err != nil {
+		return err
+	}
+
+	if res.RowsAffected() > 0 {
+		action := TagHistoryActionDeleted
+		if _, err := tx.Exec(ctx, tagHistoryRecord, guildId, tagId, &action, nil, nil, deletedBy); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Revert restores the content and embed of a previous version of the tag, recreating the tag if it has since been
+// deleted. A recreated tag has no application command, which must be registered again. Returns ErrTagVersionNotFound if
+// the version does not exist, or is a deleted version.
+func (t *TagsTable) Revert(ctx context.Context, guildId uint64, tagId string, version int, revertedBy uint64) error {
+	tx, err := t.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	target, err := scanTagVersion(tx.QueryRow(ctx, tagHistoryGetVersion, guildId, tagId, version))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrTagVersionNotFound
+		}
+
+		return err
+	}
+
+	if target.Action == TagHistoryActionDeleted {
+		return ErrTagVersionNotFound
+	}
+
+	// Keep the application command of the current tag, if it still exists
+	var applicationCommandId *uint64
+	query := `SELECT "application_command_id" FROM tags WHERE "guild_id" = $1 AND "tag_id" = LOWER($2) FOR UPDATE;`
+	if err := tx.QueryRow(ctx, query, guildId, tagId).Scan(&applicationCommandId); err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+
+	action := TagHistoryActionReverted
+	if err := t.set(ctx, tx, Tag{
+		Id:                   tagId,
+		GuildId:              guildId,
+		Content:              target.Content,
+		Embed:                target.Embed,
+		ApplicationCommandId: applicationCommandId,
+	}, &action, &revertedBy); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
 }
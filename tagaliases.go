@@ -0,0 +1,148 @@
+package database
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// TagAliases are alternative names for a tag. Aliases are resolved by TagsTable.Get and TagsTable.GetStartingWith, and
+// are deleted with their tag.
+type TagAliases struct {
+	*pgxpool.Pool
+}
+
+var (
+	//go:embed sql/tag_aliases/schema.sql
+	tagAliasesSchema string
+
+	//go:embed sql/tag_aliases/add.sql
+	tagAliasesAdd string
+
+	//go:embed sql/tag_aliases/remove.sql
+	tagAliasesRemove string
+
+	//go:embed sql/tag_aliases/get_by_tag.sql
+	tagAliasesGetByTag string
+
+	//go:embed sql/tag_aliases/get_by_guild.sql
+	tagAliasesGetByGuild string
+
+	//go:embed sql/tag_aliases/resolve.sql
+	tagAliasesResolve string
+
+	//go:embed sql/tag_aliases/is_alias.sql
+	tagAliasesIsAlias string
+)
+
+func newTagAliases(db *pgxpool.Pool) *TagAliases {
+	return &TagAliases{
+		db,
+	}
+}
+
+func (TagAliases) Schema() string {
+	return tagAliasesSchema
+}
+
+// Add points the alias at the tag, replacing the alias' existing target if it already exists. Returns false if the tag
+// does not exist, or ErrTagNameTaken if the alias is already a tag ID.
+func (a *TagAliases) Add(ctx context.Context, guildId uint64, alias, tagId string) (bool, error) {
+	tx, err := a.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	added, err := a.AddWithTx(ctx, tx, guildId, alias, tagId)
+	if err != nil {
+		return false, err
+	}
+
+	return added, tx.Commit(ctx)
+}
+
+func (a *TagAliases) AddWithTx(ctx context.Context, tx pgx.Tx, guildId uint64, alias, tagId string) (bool, error) {
+	var isTag bool
+	query := `SELECT EXISTS(SELECT 1 FROM tags WHERE "guild_id" = $1 AND "tag_id" = LOWER($2));`
+	if err := tx.QueryRow(ctx, query, guildId, alias).Scan(&isTag); err != nil {
+		return false, err
+	}
+
+	if isTag {
+		return false, ErrTagNameTaken
+	}
+
+	res, err := tx.Exec(ctx, tagAliasesAdd, guildId, alias, tagId)
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() > 0, nil
+}
+
+func (a *TagAliases) Remove(ctx context.Context, guildId uint64, alias string) (err error) {
+	_, err = a.Exec(ctx, tagAliasesRemove, guildId, alias)
+	return
+}
+
+func (a *TagAliases) GetByTag(ctx context.Context, guildId uint64, tagId string) ([]string, error) {
+	rows, err := a.Query(ctx, tagAliasesGetByTag, guildId, tagId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var aliases []string
+	for rows.Next() {
+		var alias string
+		if err := rows.Scan(&alias); err != nil {
+			return nil, err
+		}
+
+		aliases = append(aliases, alias)
+	}
+
+	return aliases, rows.Err()
+}
+
+// GetByGuild returns a map[alias]tag_id
+func (a *TagAliases) GetByGuild(ctx context.Context, guildId uint64) (map[string]string, error) {
+	rows, err := a.Query(ctx, tagAliasesGetByGuild, guildId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	aliases := make(map[string]string)
+	for rows.Next() {
+		var alias, tagId string
+		if err := rows.Scan(&alias, &tagId); err != nil {
+			return nil, err
+		}
+
+		aliases[alias] = tagId
+	}
+
+	return aliases, rows.Err()
+}
+
+// Resolve returns the ID of the tag with the given ID or alias
+func (a *TagAliases) Resolve(ctx context.Context, guildId uint64, name string) (string, bool, error) {
+	var tagId string
+	if err := a.QueryRow(ctx, tagAliasesResolve, guildId, name).Scan(&tagId); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", false, nil
+		}
+
+		return "", false, err
+	}
+
+	return tagId, true, nil
+}
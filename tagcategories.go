@@ -0,0 +1,132 @@
+package database
+
+import (
+	"context"
+	_ "embed"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// TagCategories group a guild's tags for organisation. Each tag belongs to at most one category.
+type TagCategories struct {
+	*pgxpool.Pool
+}
+
+type TagCategory struct {
+	Id      int    `json:"id"`
+	GuildId uint64 `json:"guild_id,string"`
+	Name    string `json:"name"`
+}
+
+var (
+	//go:embed sql/tag_categories/schema.sql
+	tagCategoriesSchema string
+
+	//go:embed sql/tag_categories/get_by_guild.sql
+	tagCategoriesGetByGuild string
+
+	//go:embed sql/tag_categories/create.sql
+	tagCategoriesCreate string
+
+	//go:embed sql/tag_categories/rename.sql
+	tagCategoriesRename string
+
+	//go:embed sql/tag_categories/delete.sql
+	tagCategoriesDelete string
+
+	//go:embed sql/tag_categories/set_tag_category.sql
+	tagCategoriesSetTagCategory string
+
+	//go:embed sql/tag_categories/get_tag_categories.sql
+	tagCategoriesGetTagCategories string
+)
+
+func newTagCategories(db *pgxpool.Pool) *TagCategories {
+	return &TagCategories{
+		db,
+	}
+}
+
+func (TagCategories) Schema() string {
+	return tagCategoriesSchema
+}
+
+func (c *TagCategories) GetByGuild(ctx context.Context, guildId uint64) ([]TagCategory, error) {
+	rows, err := c.Query(ctx, tagCategoriesGetByGuild, guildId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var categories []TagCategory
+	for rows.Next() {
+		category := TagCategory{
+			GuildId: guildId,
+		}
+
+		if err := rows.Scan(&category.Id, &category.Name); err != nil {
+			return nil, err
+		}
+
+		categories = append(categories, category)
+	}
+
+	return categories, rows.Err()
+}
+
+func (c *TagCategories) Create(ctx context.Context, guildId uint64, name string) (id int, err error) {
+	err = c.QueryRow(ctx, tagCategoriesCreate, guildId, name).Scan(&id)
+	return
+}
+
+func (c *TagCategories) CreateWithTx(ctx context.Context, tx pgx.Tx, guildId uint64, name string) (id int, err error) {
+	err = tx.QueryRow(ctx, tagCategoriesCreate, guildId, name).Scan(&id)
+	return
+}
+
+func (c *TagCategories) Rename(ctx context.Context, guildId uint64, id int, name string) (err error) {
+	_, err = c.Exec(ctx, tagCategoriesRename, guildId, id, name)
+	return
+}
+
+// Delete removes the category. Its tags are not deleted, and become uncategorised.
+func (c *TagCategories) Delete(ctx context.Context, guildId uint64, id int) (err error) {
+	_, err = c.Exec(ctx, tagCategoriesDelete, guildId, id)
+	return
+}
+
+// SetTagCategory moves the tag into the category, or out of any category if categoryId is nil
+func (c *TagCategories) SetTagCategory(ctx context.Context, guildId uint64, tagId string, categoryId *int) (err error) {
+	_, err = c.Exec(ctx, tagCategoriesSetTagCategory, guildId, tagId, categoryId)
+	return
+}
+
+func (c *TagCategories) SetTagCategoryWithTx(ctx context.Context, tx pgx.Tx, guildId uint64, tagId string, categoryId *int) (err error) {
+	_, err = tx.Exec(ctx, tagCategoriesSetTagCategory, guildId, tagId, categoryId)
+	return
+}
+
+// GetTagCategories returns a map[tag_id]category_id, omitting uncategorised tags
+func (c *TagCategories) GetTagCategories(ctx context.Context, guildId uint64) (map[string]int, error) {
+	rows, err := c.Query(ctx, tagCategoriesGetTagCategories, guildId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	categories := make(map[string]int)
+	for rows.Next() {
+		var tagId string
+		var categoryId int
+		if err := rows.Scan(&tagId, &categoryId); err != nil {
+			return nil, err
+		}
+
+		categories[tagId] = categoryId
+	}
+
+	return categories, rows.Err()
+}
@@ -0,0 +1,161 @@
+package database
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// TagHistory records a version of a tag each time its content or embed is changed, or it is deleted. It is written to by
+// TagsTable, and should not need to be written to directly.
+type TagHistory struct {
+	*pgxpool.Pool
+}
+
+type TagHistoryAction string
+
+const (
+	TagHistoryActionCreated  TagHistoryAction = "created"
+	TagHistoryActionUpdated  TagHistoryAction = "updated"
+	TagHistoryActionDeleted  TagHistoryAction = "deleted"
+	TagHistoryActionReverted TagHistoryAction = "reverted"
+)
+
+// TagVersion is a snapshot of the tag after the change. Content and Embed are nil for deleted versions.
+type TagVersion struct {
+	TagId    string                 `json:"tag_id"`
+	Version  int                    `json:"version"`
+	Action   TagHistoryAction       `json:"action"`
+	Content  *string                `json:"content"`
+	Embed    *CustomEmbedWithFields `json:"embed"`
+	EditedBy *uint64                `json:"edited_by,string"` // Nil if the change was not made by a user, e.g. an import
+	EditedAt time.Time              `json:"edited_at"`
+}
+
+var (
+	//go:embed sql/tag_history/schema.sql
+	tagHistorySchema string
+
+	//go:embed sql/tag_history/record.sql
+	tagHistoryRecord string
+
+	//go:embed sql/tag_history/get_by_tag.sql
+	tagHistoryGetByTag string
+
+	//go:embed sql/tag_history/get_version.sql
+	tagHistoryGetVersion string
+
+	//go:embed sql/tag_history/get_by_editor.sql
+	tagHistoryGetByEditor string
+)
+
+func newTagHistory(db *pgxpool.Pool) *TagHistory {
+	return &TagHistory{
+		db,
+	}
+}
+
+func (TagHistory) Schema() string {
+	return tagHistorySchema
+}
+
+// GetByTag returns every version of the tag, newest first
+func (h *TagHistory) GetByTag(ctx context.Context, guildId uint64, tagId string) ([]TagVersion, error) {
+	rows, err := h.Query(ctx, tagHistoryGetByTag, guildId, tagId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var versions []TagVersion
+	for rows.Next() {
+		version, err := scanTagVersion(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		version.TagId = tagId
+		versions = append(versions, version)
+	}
+
+	return versions, rows.Err()
+}
+
+func (h *TagHistory) GetVersion(ctx context.Context, guildId uint64, tagId string, version int) (TagVersion, bool, error) {
+	res, err := scanTagVersion(h.QueryRow(ctx, tagHistoryGetVersion, guildId, tagId, version))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return TagVersion{}, false, nil
+		}
+
+		return TagVersion{}, false, err
+	}
+
+	res.TagId = tagId
+	return res, true, nil
+}
+
+// GetByEditor returns the most recent changes made by the user to any of the guild's tags, newest first
+func (h *TagHistory) GetByEditor(ctx context.Context, guildId, userId uint64, limit int) ([]TagVersion, error) {
+	rows, err := h.Query(ctx, tagHistoryGetByEditor, guildId, userId, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var versions []TagVersion
+	for rows.Next() {
+		var version TagVersion
+		var embedRaw *string
+		if err := rows.Scan(
+			&version.TagId,
+			&version.Version,
+			&version.Action,
+			&version.Content,
+			&embedRaw,
+			&version.EditedBy,
+			&version.EditedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		if embedRaw != nil {
+			if err := json.UnmarshalFromString(*embedRaw, &version.Embed); err != nil {
+				return nil, err
+			}
+		}
+
+		versions = append(versions, version)
+	}
+
+	return versions, rows.Err()
+}
+
+func scanTagVersion(row pgx.Row) (TagVersion, error) {
+	var version TagVersion
+	var embedRaw *string
+	if err := row.Scan(
+		&version.Version,
+		&version.Action,
+		&version.Content,
+		&embedRaw,
+		&version.EditedBy,
+		&version.EditedAt,
+	); err != nil {
+		return TagVersion{}, err
+	}
+
+	if embedRaw != nil {
+		if err := json.UnmarshalFromString(*embedRaw, &version.Embed); err != nil {
+			return TagVersion{}, err
+		}
+	}
+
+	return version, nil
+}
@@ -0,0 +1,174 @@
+package database
+
+import (
+	"context"
+	_ "embed"
+
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// TagScopes restrict where a tag is visible. A tag without any scopes is visible everywhere. Otherwise, it is only
+// visible in tickets opened from one of its panels, and to members of one of its support teams.
+type TagScopes struct {
+	*pgxpool.Pool
+}
+
+type TagScope struct {
+	PanelIds []int `json:"panel_ids"`
+	TeamIds  []int `json:"team_ids"`
+}
+
+// TagViewer is the user that tags are being shown to
+type TagViewer struct {
+	PanelId *int // The panel that the current ticket was opened from, if any
+	UserId  uint64
+	RoleIds []uint64
+}
+
+var (
+	//go:embed sql/tag_scopes/schema.sql
+	tagScopesSchema string
+
+	//go:embed sql/tag_scopes/get.sql
+	tagScopesGet string
+
+	//go:embed sql/tag_scopes/get_by_guild.sql
+	tagScopesGetByGuild string
+
+	//go:embed sql/tag_scopes/delete.sql
+	tagScopesDelete string
+
+	//go:embed sql/tag_scopes/insert.sql
+	tagScopesInsert string
+
+	//go:embed sql/tag_scopes/get_starting_with.sql
+	tagScopesGetStartingWith string
+
+	//go:embed sql/tag_scopes/is_visible.sql
+	tagScopesIsVisible string
+)
+
+func newTagScopes(db *pgxpool.Pool) *TagScopes {
+	return &TagScopes{
+		db,
+	}
+}
+
+func (TagScopes) Schema() string {
+	return tagScopesSchema
+}
+
+func (s *TagScopes) Get(ctx context.Context, guildId uint64, tagId string) (TagScope, error) {
+	rows, err := s.Query(ctx, tagScopesGet, guildId, tagId)
+	if err != nil {
+		return TagScope{}, err
+	}
+
+	defer rows.Close()
+
+	var scope TagScope
+	for rows.Next() {
+		var panelId, teamId *int
+		if err := rows.Scan(&panelId, &teamId); err != nil {
+			return TagScope{}, err
+		}
+
+		if panelId != nil {
+			scope.PanelIds = append(scope.PanelIds, *panelId)
+		} else if teamId != nil {
+			scope.TeamIds = append(scope.TeamIds, *teamId)
+		}
+	}
+
+	return scope, rows.Err()
+}
+
+// GetByGuild returns a map[tag_id]scope, omitting tags without any scopes
+func (s *TagScopes) GetByGuild(ctx context.Context, guildId uint64) (map[string]TagScope, error) {
+	rows, err := s.Query(ctx, tagScopesGetByGuild, guildId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	scopes := make(map[string]TagScope)
+	for rows.Next() {
+		var tagId string
+		var panelId, teamId *int
+		if err := rows.Scan(&tagId, &panelId, &teamId); err != nil {
+			return nil, err
+		}
+
+		scope := scopes[tagId]
+		if panelId != nil {
+			scope.PanelIds = append(scope.PanelIds, *panelId)
+		} else if teamId != nil {
+			scope.TeamIds = append(scope.TeamIds, *teamId)
+		}
+
+		scopes[tagId] = scope
+	}
+
+	return scopes, rows.Err()
+}
+
+func (s *TagScopes) Set(ctx context.Context, guildId uint64, tagId string, scope TagScope) error {
+	tx, err := s.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	if err := s.SetWithTx(ctx, tx, guildId, tagId, scope); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// SetWithTx replaces the tag's scopes. An empty scope makes the tag visible everywhere. Returns ErrPanelNotFound or
+// ErrSupportTeamNotFound if a panel or team does not belong to the guild.
+func (s *TagScopes) SetWithTx(ctx context.Context, tx pgx.Tx, guildId uint64, tagId string, scope TagScope) error {
+	// Remove existing scopes
+	if _, err := tx.Exec(ctx, tagScopesDelete, guildId, tagId); err != nil {
+		return err
+	}
+
+	for _, panelId := range scope.PanelIds {
+		if err := insertTagScope(ctx, tx, guildId, tagId, &panelId, nil); err != nil {
+			return err
+		}
+	}
+
+	for _, teamId := range scope.TeamIds {
+		if err := insertTagScope(ctx, tx, guildId, tagId, nil, &teamId); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func insertTagScope(ctx context.Context, tx pgx.Tx, guildId uint64, tagId string, panelId, teamId *int) error {
+	var validPanel, validTeam bool
+	if err := tx.QueryRow(ctx, tagScopesInsert, guildId, tagId, panelId, teamId).Scan(&validPanel, &validTeam); err != nil {
+		return err
+	}
+
+	return scopeError(validPanel, validTeam)
+}
+
+// IsVisible returns true if the tag exists and is visible to the viewer. It does not resolve aliases.
+func (s *TagScopes) IsVisible(ctx context.Context, guildId uint64, tagId string, viewer TagViewer) (visible bool, err error) {
+	roleIds := &pgtype.Int8Array{}
+	if err := roleIds.Set(viewer.RoleIds); err != nil {
+		return false, err
+	}
+
+	err = s.QueryRow(ctx, tagScopesIsVisible, guildId, tagId, viewer.PanelId, viewer.UserId, roleIds).Scan(&visible)
+	return
+}
@@ -0,0 +1,153 @@
+package database
+
+import (
+	"context"
+	_ "embed"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// TagUsages logs each time a tag is sent, so that guilds can find which tags are used, and by whom
+type TagUsages struct {
+	*pgxpool.Pool
+}
+
+type TagUsageCount struct {
+	TagId      string     `json:"tag_id"`
+	Uses       int        `json:"uses"`
+	LastUsedAt *time.Time `json:"last_used_at"` // Nil if the tag has never been used
+}
+
+type TagUserUsage struct {
+	UserId     uint64    `json:"user_id,string"`
+	Uses       int       `json:"uses"`
+	TagsUsed   int       `json:"tags_used"` // Number of distinct tags
+	LastUsedAt time.Time `json:"last_used_at"`
+}
+
+var (
+	//go:embed sql/tag_usages/schema.sql
+	tagUsagesSchema string
+
+	//go:embed sql/tag_usages/record.sql
+	tagUsagesRecord string
+
+	//go:embed sql/tag_usages/get_most_used.sql
+	tagUsagesGetMostUsed string
+
+	//go:embed sql/tag_usages/get_unused.sql
+	tagUsagesGetUnused string
+
+	//go:embed sql/tag_usages/get_usage_by_user.sql
+	tagUsagesGetUsageByUser string
+
+	//go:embed sql/tag_usages/get_user_usage_by_tag.sql
+	tagUsagesGetUserUsageByTag string
+)
+
+func newTagUsages(db *pgxpool.Pool) *TagUsages {
+	return &TagUsages{
+		db,
+	}
+}
+
+func (TagUsages) Schema() string {
+	return tagUsagesSchema
+}
+
+// Record logs a use of the tag. tagId may be an alias, in which case the use is recorded against the tag that it
+// belongs to. ticketId is nil if the tag was not used in a ticket.
+func (u *TagUsages) Record(ctx context.Context, guildId uint64, tagId string, ticketId *int, userId uint64) (err error) {
+	_, err = u.Exec(ctx, tagUsagesRecord, guildId, tagId, ticketId, userId)
+	return
+}
+
+// GetMostUsed returns the guild's existing tags that have been used since the given time, most used first
+func (u *TagUsages) GetMostUsed(ctx context.Context, guildId uint64, since time.Time, limit int) ([]TagUsageCount, error) {
+	rows, err := u.Query(ctx, tagUsagesGetMostUsed, guildId, since, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var counts []TagUsageCount
+	for rows.Next() {
+		var count TagUsageCount
+		if err := rows.Scan(&count.TagId, &count.Uses, &count.LastUsedAt); err != nil {
+			return nil, err
+		}
+
+		counts = append(counts, count)
+	}
+
+	return counts, rows.Err()
+}
+
+// GetUnused returns the guild's tags that have not been used since the given time, with Uses always 0
+func (u *TagUsages) GetUnused(ctx context.Context, guildId uint64, since time.Time) ([]TagUsageCount, error) {
+	rows, err := u.Query(ctx, tagUsagesGetUnused, guildId, since)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var unused []TagUsageCount
+	for rows.Next() {
+		var count TagUsageCount
+		if err := rows.Scan(&count.TagId, &count.LastUsedAt); err != nil {
+			return nil, err
+		}
+
+		unused = append(unused, count)
+	}
+
+	return unused, rows.Err()
+}
+
+// GetUsageByUser returns the number of tags sent by each user since the given time, most active first. Tags that have
+// since been deleted are included.
+func (u *TagUsages) GetUsageByUser(ctx context.Context, guildId uint64, since time.Time) ([]TagUserUsage, error) {
+	rows, err := u.Query(ctx, tagUsagesGetUsageByUser, guildId, since)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var usage []TagUserUsage
+	for rows.Next() {
+		var user TagUserUsage
+		if err := rows.Scan(&user.UserId, &user.Uses, &user.TagsUsed, &user.LastUsedAt); err != nil {
+			return nil, err
+		}
+
+		usage = append(usage, user)
+	}
+
+	return usage, rows.Err()
+}
+
+// GetUserUsageByTag returns the number of times the user has sent each tag since the given time, most used first
+func (u *TagUsages) GetUserUsageByTag(ctx context.Context, guildId, userId uint64, since time.Time) ([]TagUsageCount, error) {
+	rows, err := u.Query(ctx, tagUsagesGetUserUsageByTag, guildId, userId, since)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var counts []TagUsageCount
+	for rows.Next() {
+		var count TagUsageCount
+		if err := rows.Scan(&count.TagId, &count.Uses, &count.LastUsedAt); err != nil {
+			return nil, err
+		}
+
+		counts = append(counts, count)
+	}
+
+	return counts, rows.Err()
+}
@@ -0,0 +1,178 @@
+package database
+
+import (
+	"context"
+	_ "embed"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// TemplatePlaceholderRefs stores the placeholders referenced by each template, so that the templates depending on an
+// integration can be found before it is removed. Refs are written whenever a tag, panel, embed or welcome message is
+// saved, whether or not the placeholders are known, and are deleted with their tag, panel or embed.
+type TemplatePlaceholderRefs struct {
+	*pgxpool.Pool
+}
+
+type TemplateSourceType string
+
+const (
+	TemplateSourceTypeTag            TemplateSourceType = "tag"
+	TemplateSourceTypePanel          TemplateSourceType = "panel"
+	TemplateSourceTypeEmbed          TemplateSourceType = "embed"
+	TemplateSourceTypeWelcomeMessage TemplateSourceType = "welcome_message"
+)
+
+// TemplateSource identifies a template. Only the ID for the source type is set.
+type TemplateSource struct {
+	Type    TemplateSourceType `json:"type"`
+	TagId   *string            `json:"tag_id"`
+	PanelId *int               `json:"panel_id"`
+	EmbedId *int               `json:"embed_id"`
+}
+
+type TemplatePlaceholderRef struct {
+	Source      TemplateSource `json:"source"`
+	Placeholder string         `json:"placeholder"`
+}
+
+var (
+	//go:embed sql/template_placeholder_refs/schema.sql
+	templatePlaceholderRefsSchema string
+
+	//go:embed sql/template_placeholder_refs/get.sql
+	templatePlaceholderRefsGet string
+
+	//go:embed sql/template_placeholder_refs/delete.sql
+	templatePlaceholderRefsDelete string
+
+	//go:embed sql/template_placeholder_refs/insert.sql
+	templatePlaceholderRefsInsert string
+
+	//go:embed sql/template_placeholder_refs/get_dependents.sql
+	templatePlaceholderRefsGetDependents string
+)
+
+func TagTemplateSource(tagId string) TemplateSource {
+	return TemplateSource{Type: TemplateSourceTypeTag, TagId: &tagId}
+}
+
+func PanelTemplateSource(panelId int) TemplateSource {
+	return TemplateSource{Type: TemplateSourceTypePanel, PanelId: &panelId}
+}
+
+func EmbedTemplateSource(embedId int) TemplateSource {
+	return TemplateSource{Type: TemplateSourceTypeEmbed, EmbedId: &embedId}
+}
+
+func WelcomeMessageTemplateSource() TemplateSource {
+	return TemplateSource{Type: TemplateSourceTypeWelcomeMessage}
+}
+
+func newTemplatePlaceholderRefs(db *pgxpool.Pool) *TemplatePlaceholderRefs {
+	return &TemplatePlaceholderRefs{
+		db,
+	}
+}
+
+func (TemplatePlaceholderRefs) Schema() string {
+	return templatePlaceholderRefsSchema
+}
+
+func (r *TemplatePlaceholderRefs) Get(ctx context.Context, guildId uint64, source TemplateSource) ([]string, error) {
+	rows, err := r.Query(ctx, templatePlaceholderRefsGet, guildId, source.Type, source.TagId, source.PanelId, source.EmbedId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var placeholders []string
+	for rows.Next() {
+		var placeholder string
+		if err := rows.Scan(&placeholder); err != nil {
+			return nil, err
+		}
+
+		placeholders = append(placeholders, placeholder)
+	}
+
+	return placeholders, rows.Err()
+}
+
+func (r *TemplatePlaceholderRefs) Set(ctx context.Context, guildId uint64, source TemplateSource, placeholders []string) error {
+	tx, err := r.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	if err := r.SetWithTx(ctx, tx, guildId, source, placeholders); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// SetWithTx replaces the placeholders stored for the source, without validating them
+func (r *TemplatePlaceholderRefs) SetWithTx(ctx context.Context, tx pgx.Tx, guildId uint64, source TemplateSource, placeholders []string) error {
+	return setTemplatePlaceholderRefs(ctx, tx, guildId, source, placeholders)
+}
+
+func setTemplatePlaceholderRefs(ctx context.Context, tx pgx.Tx, guildId uint64, source TemplateSource, placeholders []string) error {
+	// Remove existing refs
+	if _, err := tx.Exec(ctx, templatePlaceholderRefsDelete, guildId, source.Type, source.TagId, source.PanelId, source.EmbedId); err != nil {
+		return err
+	}
+
+	for _, placeholder := range placeholders {
+		if _, err := tx.Exec(ctx, templatePlaceholderRefsInsert,
+			guildId,
+			source.Type,
+			source.TagId,
+			source.PanelId,
+			source.EmbedId,
+			placeholder,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Delete is only needed for welcome messages: refs for other sources are deleted with their tag, panel or embed
+func (r *TemplatePlaceholderRefs) Delete(ctx context.Context, guildId uint64, source TemplateSource) (err error) {
+	_, err = r.Exec(ctx, templatePlaceholderRefsDelete, guildId, source.Type, source.TagId, source.PanelId, source.EmbedId)
+	return
+}
+
+// GetDependents returns the guild's templates that reference a placeholder provided by the integration
+func (r *TemplatePlaceholderRefs) GetDependents(ctx context.Context, guildId uint64, integrationId int) ([]TemplatePlaceholderRef, error) {
+	rows, err := r.Query(ctx, templatePlaceholderRefsGetDependents, guildId, integrationId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var refs []TemplatePlaceholderRef
+	for rows.Next() {
+		var ref TemplatePlaceholderRef
+		if err := rows.Scan(
+			&ref.Source.Type,
+			&ref.Source.TagId,
+			&ref.Source.PanelId,
+			&ref.Source.EmbedId,
+			&ref.Placeholder,
+		); err != nil {
+			return nil, err
+		}
+
+		refs = append(refs, ref)
+	}
+
+	return refs, rows.Err()
+}
@@ -0,0 +1,258 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// Templates are tag content, panel content, welcome messages and embed text containing placeholders in the form
+// %name%, which are substituted when the message is sent.
+
+// BuiltInPlaceholders are the placeholders provided by the bot itself, which must be kept in sync with the bot. Custom
+// integrations activated in a guild provide further placeholders.
+var BuiltInPlaceholders = []string{
+	"user",
+	"username",
+	"ticket_id",
+	"channel",
+	"server",
+	"open_tickets",
+	"total_tickets",
+	"user_open_tickets",
+	"ticket_limit",
+	"rating_count",
+	"average_rating",
+	"time_open",
+	"claimed_by",
+	"first_response_time_weekly",
+	"first_response_time_monthly",
+	"first_response_time_all_time",
+}
+
+var ErrUnknownPlaceholder = errors.New("unknown placeholder")
+
+// Placeholder names have the same maximum length as custom integration placeholder names. Names must start with a
+// letter, so that percent-encoded text such as my%20file%20name.png is not mistaken for a placeholder.
+var placeholderPattern = regexp.MustCompile(`%([a-zA-Z][a-zA-Z0-9_]{0,31})%`)
+
+// PlaceholderRegistry is the set of placeholders that can be used in a guild's templates
+type PlaceholderRegistry struct {
+	BuiltIn      map[string]struct{}
+	Integrations map[string][]int // Placeholder name -> IDs of the integrations providing it
+}
+
+// ParsePlaceholders returns the distinct placeholders in the templates, in order of first use
+func ParsePlaceholders(templates ...string) []string {
+	seen := make(map[string]struct{})
+
+	var placeholders []string
+	for _, template := range templates {
+		for _, match := range placeholderPattern.FindAllStringSubmatch(template, -1) {
+			name := match[1]
+			if _, ok := seen[name]; ok {
+				continue
+			}
+
+			seen[name] = struct{}{}
+			placeholders = append(placeholders, name)
+		}
+	}
+
+	return placeholders
+}
+
+// EmbedTemplates returns the text of each of the embed's fields that may contain placeholders. URLs are not included, as
+// percent-encoded characters such as %E2%9C%93 cannot be told apart from placeholders.
+func EmbedTemplates(embed *CustomEmbedWithFields) []string {
+	if embed == nil {
+		return nil
+	}
+
+	var templates []string
+	if embed.CustomEmbed != nil {
+		for _, value := range []*string{
+			embed.Title,
+			embed.Description,
+			embed.AuthorName,
+			embed.FooterText,
+		} {
+			if value != nil {
+				templates = append(templates, *value)
+			}
+		}
+	}
+
+	for _, field := range embed.Fields {
+		templates = append(templates, field.Name, field.Value)
+	}
+
+	return templates
+}
+
+// RenderTemplate substitutes the placeholders in the template that have a value. Placeholders without a value are left
+// as they are.
+func RenderTemplate(template string, values map[string]string) string {
+	return placeholderPattern.ReplaceAllStringFunc(template, func(match string) string {
+		if value, ok := values[match[1:len(match)-1]]; ok {
+			return value
+		}
+
+		return match
+	})
+}
+
+func (r PlaceholderRegistry) IsKnown(placeholder string) bool {
+	if _, ok := r.BuiltIn[placeholder]; ok {
+		return true
+	}
+
+	_, ok := r.Integrations[placeholder]
+	return ok
+}
+
+// Validate returns an error wrapping ErrUnknownPlaceholder, listing each unknown placeholder, if any of the
+// placeholders are not in the registry
+func (r PlaceholderRegistry) Validate(placeholders []string) error {
+	var unknown []string
+	for _, placeholder := range placeholders {
+		if !r.IsKnown(placeholder) {
+			unknown = append(unknown, placeholder)
+		}
+	}
+
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("%w: %s", ErrUnknownPlaceholder, strings.Join(unknown, ", "))
+	}
+
+	return nil
+}
+
+// GetPlaceholderRegistry returns the built-in placeholders, and the placeholders of the integrations activated in the
+// guild
+func (d *Database) GetPlaceholderRegistry(ctx context.Context, guildId uint64) (PlaceholderRegistry, error) {
+	integrationPlaceholders, err := d.CustomIntegrationPlaceholders.GetAllActivatedInGuild(ctx, guildId)
+	if err != nil {
+		return PlaceholderRegistry{}, err
+	}
+
+	return newPlaceholderRegistry(integrationPlaceholders), nil
+}
+
+func getPlaceholderRegistryWithTx(ctx context.Context, tx pgx.Tx, guildId uint64) (PlaceholderRegistry, error) {
+	query := `
+SELECT placeholders.integration_id, placeholders.name
+FROM custom_integration_placeholders AS placeholders
+INNER JOIN custom_integration_guilds guilds ON placeholders.integration_id = guilds.integration_id
+WHERE guilds.guild_id = $1;`
+
+	rows, err := tx.Query(ctx, query, guildId)
+	if err != nil {
+		return PlaceholderRegistry{}, err
+	}
+
+	defer rows.Close()
+
+	var integrationPlaceholders []CustomIntegrationPlaceholder
+	for rows.Next() {
+		var placeholder CustomIntegrationPlaceholder
+		if err := rows.Scan(&placeholder.IntegrationId, &placeholder.Name); err != nil {
+			return PlaceholderRegistry{}, err
+		}
+
+		integrationPlaceholders = append(integrationPlaceholders, placeholder)
+	}
+
+	if err := rows.Err(); err != nil {
+		return PlaceholderRegistry{}, err
+	}
+
+	return newPlaceholderRegistry(integrationPlaceholders), nil
+}
+
+func newPlaceholderRegistry(integrationPlaceholders []CustomIntegrationPlaceholder) PlaceholderRegistry {
+	registry := PlaceholderRegistry{
+		BuiltIn:      make(map[string]struct{}, len(BuiltInPlaceholders)),
+		Integrations: make(map[string][]int),
+	}
+
+	for _, placeholder := range BuiltInPlaceholders {
+		registry.BuiltIn[placeholder] = struct{}{}
+	}
+
+	for _, placeholder := range integrationPlaceholders {
+		registry.Integrations[placeholder.Name] = append(registry.Integrations[placeholder.Name], placeholder.IntegrationId)
+	}
+
+	return registry
+}
+
+// ValidateTemplates returns an error wrapping ErrUnknownPlaceholder if the templates use a placeholder that is neither
+// built in nor provided by an integration activated in the guild. Saving a tag, panel, embed or welcome message does not
+// validate its placeholders, so that imports, and edits to a template whose integration has since been deactivated, are
+// not rejected. Templates entered by a user should be validated before they are saved.
+func (d *Database) ValidateTemplates(ctx context.Context, guildId uint64, templates ...string) error {
+	placeholders := ParsePlaceholders(templates...)
+
+	// Built-in placeholders are known without querying integrations
+	if newPlaceholderRegistry(nil).Validate(placeholders) == nil {
+		return nil
+	}
+
+	registry, err := d.GetPlaceholderRegistry(ctx, guildId)
+	if err != nil {
+		return err
+	}
+
+	return registry.Validate(placeholders)
+}
+
+// SaveTemplatePlaceholders validates the placeholders in the templates as ValidateTemplates does, and replaces the
+// placeholders stored for the source. Returns the placeholders referenced. Tags, panels, embeds and welcome messages
+// already record their placeholders, without validating them, when they are saved.
+func (d *Database) SaveTemplatePlaceholders(ctx context.Context, guildId uint64, source TemplateSource, templates ...string) ([]string, error) {
+	var placeholders []string
+	err := d.WithTx(ctx, func(tx pgx.Tx) (err error) {
+		placeholders, err = d.SaveTemplatePlaceholdersWithTx(ctx, tx, guildId, source, templates...)
+		return
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return placeholders, nil
+}
+
+func (d *Database) SaveTemplatePlaceholdersWithTx(ctx context.Context, tx pgx.Tx, guildId uint64, source TemplateSource, templates ...string) ([]string, error) {
+	placeholders := ParsePlaceholders(templates...)
+
+	// Built-in placeholders are known without querying integrations
+	if newPlaceholderRegistry(nil).Validate(placeholders) != nil {
+		registry, err := getPlaceholderRegistryWithTx(ctx, tx, guildId)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := registry.Validate(placeholders); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := setTemplatePlaceholderRefs(ctx, tx, guildId, source, placeholders); err != nil {
+		return nil, err
+	}
+
+	return placeholders, nil
+}
+
+// recordTemplatePlaceholders replaces the placeholders stored for the source, including any that are unknown
+func recordTemplatePlaceholders(ctx context.Context, tx pgx.Tx, guildId uint64, source TemplateSource, templates ...string) error {
+	return setTemplatePlaceholderRefs(ctx, tx, guildId, source, ParsePlaceholders(templates...))
+}
@@ -0,0 +1,132 @@
+package database
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePlaceholders(t *testing.T) {
+	cases := []struct {
+		name      string
+		templates []string
+		expected  []string
+	}{
+		{
+			name:      "no placeholders",
+			templates: []string{"Hello there"},
+		},
+		{
+			name:      "in order of first use",
+			templates: []string{"%user% opened %ticket_id%", "%server%: %user%"},
+			expected:  []string{"user", "ticket_id", "server"},
+		},
+		{
+			name:      "adjacent placeholders",
+			templates: []string{"%user%%server%"},
+			expected:  []string{"user", "server"},
+		},
+		{
+			name:      "percent-encoded URL",
+			templates: []string{"https://example.com/my%20file%20name.png"},
+		},
+		{
+			name:      "percent-encoded URL with a placeholder",
+			templates: []string{"https://example.com/%user%/my%20file%2Fname.png"},
+			expected:  []string{"user"},
+		},
+		{
+			name:      "percentages",
+			templates: []string{"50% off, then 20% more"},
+		},
+		{
+			name:      "name starting with a digit",
+			templates: []string{"%1st_place%"},
+		},
+		{
+			name:      "name too long",
+			templates: []string{"%" + strings.Repeat("a", 33) + "%"},
+		},
+		{
+			name:      "name at the maximum length",
+			templates: []string{"%" + strings.Repeat("a", 32) + "%"},
+			expected:  []string{strings.Repeat("a", 32)},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			placeholders := ParsePlaceholders(c.templates...)
+			if !reflect.DeepEqual(placeholders, c.expected) {
+				t.Errorf("expected %v, got %v", c.expected, placeholders)
+			}
+		})
+	}
+}
+
+func TestRenderTemplate(t *testing.T) {
+	values := map[string]string{
+		"user":   "<@1>",
+		"server": "Example",
+	}
+
+	cases := []struct {
+		template string
+		expected string
+	}{
+		{"Welcome %user% to %server%!", "Welcome <@1> to Example!"},
+		{"%user%%user%", "<@1><@1>"},
+		{"%unknown% is left as it is", "%unknown% is left as it is"},
+		{"https://example.com/my%20file%20name.png", "https://example.com/my%20file%20name.png"},
+		{"50% off for %user%", "50% off for <@1>"},
+	}
+
+	for _, c := range cases {
+		if rendered := RenderTemplate(c.template, values); rendered != c.expected {
+			t.Errorf("rendering %q: expected %q, got %q", c.template, c.expected, rendered)
+		}
+	}
+}
+
+func TestEmbedTemplates(t *testing.T) {
+	title, imageUrl := "Ticket %ticket_id%", "https://example.com/%E2%9C%93%user%.png"
+	embed := &CustomEmbedWithFields{
+		CustomEmbed: &CustomEmbed{
+			Title:    &title,
+			ImageUrl: &imageUrl,
+		},
+		Fields: []EmbedField{
+			{Name: "Opened by", Value: "%user%"},
+		},
+	}
+
+	// URLs are not scanned
+	expected := []string{"ticket_id", "user"}
+	if placeholders := ParsePlaceholders(EmbedTemplates(embed)...); !reflect.DeepEqual(placeholders, expected) {
+		t.Errorf("expected %v, got %v", expected, placeholders)
+	}
+
+	if templates := EmbedTemplates(nil); templates != nil {
+		t.Errorf("expected no templates for a nil embed, got %v", templates)
+	}
+}
+
+func TestPlaceholderRegistryValidate(t *testing.T) {
+	registry := newPlaceholderRegistry([]CustomIntegrationPlaceholder{
+		{IntegrationId: 1, Name: "weather"},
+	})
+
+	if err := registry.Validate([]string{"user", "weather"}); err != nil {
+		t.Errorf("expected built-in and integration placeholders to be valid, got %v", err)
+	}
+
+	err := registry.Validate([]string{"user", "zeta", "alpha"})
+	if !errors.Is(err, ErrUnknownPlaceholder) {
+		t.Fatalf("expected ErrUnknownPlaceholder, got %v", err)
+	}
+
+	if !strings.HasSuffix(err.Error(), ": alpha, zeta") {
+		t.Errorf("expected the unknown placeholders to be listed in order, got %q", err.Error())
+	}
+}
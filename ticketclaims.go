@@ -2,16 +2,117 @@ package database
 
 import (
 	"context"
+	_ "embed"
+	"fmt"
 	"time"
 
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
+// TicketClaims holds the primary claimer of each ticket. Other staff can help with a claimed ticket as helpers. Each
+// change to a ticket's claimers is recorded as a claim event, from which the time each claimer held the ticket is
+// calculated.
 type TicketClaims struct {
 	*pgxpool.Pool
 }
 
+type ClaimEventType string
+
+const (
+	ClaimEventClaimed       ClaimEventType = "claimed"
+	ClaimEventUnclaimed     ClaimEventType = "unclaimed"
+	ClaimEventReassigned    ClaimEventType = "reassigned"
+	ClaimEventHelperAdded   ClaimEventType = "helper_added"
+	ClaimEventHelperRemoved ClaimEventType = "helper_removed"
+)
+
+type ClaimEvent struct {
+	Type           ClaimEventType `json:"type"`
+	UserId         uint64         `json:"user_id,string"`
+	PreviousUserId *uint64        `json:"previous_user_id,string"` // Only set for reassigned events
+	ActorId        *uint64        `json:"actor_id,string"`         // Who made the change, if known
+	CreatedAt      time.Time      `json:"created_at"`
+}
+
+type TicketClaimer struct {
+	UserId    uint64     `json:"user_id,string"`
+	IsPrimary bool       `json:"is_primary"`
+	ClaimedAt *time.Time `json:"claimed_at"` // Nil if claimed before claim events were recorded
+}
+
+// ClaimAttribution determines how tickets are attributed to claimers in statistics
+type ClaimAttribution string
+
+const (
+	// ClaimAttributionPrimary attributes each ticket to its current primary claimer
+	ClaimAttributionPrimary ClaimAttribution = "primary"
+	// ClaimAttributionAny attributes each ticket in full to everyone who has ever claimed or helped with it
+	ClaimAttributionAny ClaimAttribution = "any"
+	// ClaimAttributionTimeWeighted attributes a share of each ticket to each claimer, proportional to the time they held it
+	ClaimAttributionTimeWeighted ClaimAttribution = "time_weighted"
+)
+
+var (
+	//go:embed sql/ticket_claims/set.sql
+	ticketClaimsSet string
+
+	//go:embed sql/ticket_claims/delete.sql
+	ticketClaimsDelete string
+
+	//go:embed sql/ticket_claims/add_helper.sql
+	ticketClaimsAddHelper string
+
+	//go:embed sql/ticket_claims/remove_helper.sql
+	ticketClaimsRemoveHelper string
+
+	//go:embed sql/ticket_claims/get_claimers.sql
+	ticketClaimsGetClaimers string
+
+	//go:embed sql/ticket_claims/get_history.sql
+	ticketClaimsGetHistory string
+
+	//go:embed sql/ticket_claims/held.sql
+	ticketClaimsHeld string
+
+	// Formatted with ticketClaimsHeld
+	//
+	//go:embed sql/ticket_claims/get_time_held.sql
+	ticketClaimsGetTimeHeld string
+
+	//go:embed sql/ticket_claims/weights_primary.sql
+	ticketClaimsWeightsPrimary string
+
+	//go:embed sql/ticket_claims/weights_any.sql
+	ticketClaimsWeightsAny string
+
+	// Formatted with ticketClaimsHeld
+	//
+	//go:embed sql/ticket_claims/weights_time_weighted.sql
+	ticketClaimsWeightsTimeWeighted string
+
+	//go:embed sql/ticket_claims/guild_weights_primary.sql
+	ticketClaimsGuildWeightsPrimary string
+
+	//go:embed sql/ticket_claims/guild_weights_any.sql
+	ticketClaimsGuildWeightsAny string
+
+	// Formatted with ticketClaimsHeld
+	//
+	//go:embed sql/ticket_claims/guild_weights_time_weighted.sql
+	ticketClaimsGuildWeightsTimeWeighted string
+
+	// Formatted with the weights query for the attribution
+	//
+	//go:embed sql/ticket_claims/get_attributed_count.sql
+	ticketClaimsGetAttributedCount string
+
+	// Formatted with the weights query for the attribution
+	//
+	//go:embed sql/ticket_claims/get_attributed_average_rating.sql
+	ticketClaimsGetAttributedAverageRating string
+)
+
 func newTicketClaims(db *pgxpool.Pool) *TicketClaims {
 	return &TicketClaims{
 		db,
@@ -27,6 +128,32 @@ CREATE TABLE IF NOT EXISTS ticket_claims(
 	FOREIGN KEY("guild_id", "ticket_id") REFERENCES tickets("guild_id", "id"),
 	PRIMARY KEY("guild_id", "ticket_id")
 );
+
+CREATE TABLE IF NOT EXISTS ticket_claim_helpers(
+	"guild_id" int8 NOT NULL,
+	"ticket_id" int4 NOT NULL,
+	"user_id" int8 NOT NULL,
+	"added_at" timestamptz NOT NULL DEFAULT NOW(),
+	FOREIGN KEY("guild_id", "ticket_id") REFERENCES tickets("guild_id", "id") ON DELETE CASCADE,
+	PRIMARY KEY("guild_id", "ticket_id", "user_id")
+);
+
+CREATE TYPE claim_event_type AS ENUM ('claimed', 'unclaimed', 'reassigned', 'helper_added', 'helper_removed');
+
+CREATE TABLE IF NOT EXISTS ticket_claim_events(
+	"id" BIGSERIAL NOT NULL,
+	"guild_id" int8 NOT NULL,
+	"ticket_id" int4 NOT NULL,
+	"event_type" claim_event_type NOT NULL,
+	"user_id" int8 NOT NULL,
+	"previous_user_id" int8,
+	"actor_id" int8,
+	"created_at" timestamptz NOT NULL DEFAULT NOW(),
+	FOREIGN KEY("guild_id", "ticket_id") REFERENCES tickets("guild_id", "id") ON DELETE CASCADE,
+	PRIMARY KEY("id")
+);
+CREATE INDEX IF NOT EXISTS ticket_claim_events_guild_id_ticket_id ON ticket_claim_events("guild_id", "ticket_id");
+CREATE INDEX IF NOT EXISTS ticket_claim_events_guild_id_user_id ON ticket_claim_events("guild_id", "user_id");
 `
 }
 
@@ -39,6 +166,7 @@ func (c *TicketClaims) Get(ctx context.Context, guildId uint64, ticketId int) (u
 	return
 }
 
+// Deprecated: Use Database.ImportTicketData, which can be safely re-run if an import fails.
 func (c *TicketClaims) ImportBulk(ctx context.Context, guildId uint64, claims map[int]uint64) (err error) {
 	rows := make([][]interface{}, 0)
 
@@ -51,17 +179,128 @@ func (c *TicketClaims) ImportBulk(ctx context.Context, guildId uint64, claims ma
 }
 
 func (c *TicketClaims) Set(ctx context.Context, guildId uint64, ticketId int, userId uint64) (err error) {
-	query := `INSERT INTO ticket_claims("guild_id", "ticket_id", "user_id") VALUES($1, $2, $3) ON CONFLICT("guild_id", "ticket_id") DO UPDATE SET "user_id" = $3;`
-	_, err = c.Exec(ctx, query, guildId, ticketId, userId)
+	tx, err := c.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	if err := c.SetWithTx(ctx, tx, guildId, ticketId, userId); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// SetWithTx makes the user the primary claimer of the ticket, recording a claimed or reassigned event. If the user was a
+// helper, they are removed as a helper.
+func (c *TicketClaims) SetWithTx(ctx context.Context, tx pgx.Tx, guildId uint64, ticketId int, userId uint64) (err error) {
+	_, err = tx.Exec(ctx, ticketClaimsSet, guildId, ticketId, userId, nil)
 	return
 }
 
+// SetBy is the same as Set, but records who made the change in the ticket's claim history.
+func (c *TicketClaims) SetBy(ctx context.Context, guildId uint64, ticketId int, userId, actorId uint64) (err error) {
+	_, err = c.Exec(ctx, ticketClaimsSet, guildId, ticketId, userId, actorId)
+	return
+}
+
+// Delete unclaims the ticket, recording an unclaimed event. Helpers are kept.
 func (c *TicketClaims) Delete(ctx context.Context, guildId uint64, ticketId int) (err error) {
-	query := `DELETE FROM ticket_claims WHERE "guild_id"=$1 AND "ticket_id"=$2;`
-	_, err = c.Exec(ctx, query, guildId, ticketId)
+	_, err = c.Exec(ctx, ticketClaimsDelete, guildId, ticketId, nil)
+	return
+}
+
+// DeleteBy is the same as Delete, but records who made the change in the ticket's claim history.
+func (c *TicketClaims) DeleteBy(ctx context.Context, guildId uint64, ticketId int, actorId uint64) (err error) {
+	_, err = c.Exec(ctx, ticketClaimsDelete, guildId, ticketId, actorId)
+	return
+}
+
+// AddHelper adds the user as a helper on the ticket. Has no effect if the user is already a claimer of the ticket.
+// actorId may be nil.
+func (c *TicketClaims) AddHelper(ctx context.Context, guildId uint64, ticketId int, userId uint64, actorId *uint64) (err error) {
+	_, err = c.Exec(ctx, ticketClaimsAddHelper, guildId, ticketId, userId, actorId)
+	return
+}
+
+// RemoveHelper removes the user as a helper on the ticket. actorId may be nil.
+func (c *TicketClaims) RemoveHelper(ctx context.Context, guildId uint64, ticketId int, userId uint64, actorId *uint64) (err error) {
+	_, err = c.Exec(ctx, ticketClaimsRemoveHelper, guildId, ticketId, userId, actorId)
 	return
 }
 
+// GetClaimers returns the ticket's primary claimer, if any, followed by its helpers in the order they were added
+func (c *TicketClaims) GetClaimers(ctx context.Context, guildId uint64, ticketId int) ([]TicketClaimer, error) {
+	rows, err := c.Query(ctx, ticketClaimsGetClaimers, guildId, ticketId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var claimers []TicketClaimer
+	for rows.Next() {
+		var claimer TicketClaimer
+		if err := rows.Scan(&claimer.UserId, &claimer.IsPrimary, &claimer.ClaimedAt); err != nil {
+			return nil, err
+		}
+
+		claimers = append(claimers, claimer)
+	}
+
+	return claimers, rows.Err()
+}
+
+// GetHistory returns the ticket's claim events, oldest first
+func (c *TicketClaims) GetHistory(ctx context.Context, guildId uint64, ticketId int) ([]ClaimEvent, error) {
+	rows, err := c.Query(ctx, ticketClaimsGetHistory, guildId, ticketId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var events []ClaimEvent
+	for rows.Next() {
+		var event ClaimEvent
+		if err := rows.Scan(&event.Type, &event.UserId, &event.PreviousUserId, &event.ActorId, &event.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// GetTimeHeld returns how long each claimer, primary or helper, has held the ticket while it was open. Time held before
+// claim events were recorded is not included.
+func (c *TicketClaims) GetTimeHeld(ctx context.Context, guildId uint64, ticketId int) (map[uint64]time.Duration, error) {
+	query := fmt.Sprintf(ticketClaimsGetTimeHeld, ticketClaimsHeld)
+
+	rows, err := c.Query(ctx, query, guildId, ticketId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	held := make(map[uint64]time.Duration)
+	for rows.Next() {
+		var userId uint64
+		var seconds float64
+		if err := rows.Scan(&userId, &seconds); err != nil {
+			return nil, err
+		}
+
+		held[userId] = time.Duration(seconds * float64(time.Second))
+	}
+
+	return held, rows.Err()
+}
+
 // stats
 func (c *TicketClaims) GetClaimedSinceCount(ctx context.Context, guildId, userId uint64, interval time.Duration) (count int, e error) {
 	query := `
@@ -86,3 +325,43 @@ func (c *TicketClaims) GetClaimedCount(ctx context.Context, guildId, userId uint
 
 	return
 }
+
+// GetAttributedClaimedCount is the same as GetClaimedCount, but attributes tickets using the given attribution. The
+// count is fractional for ClaimAttributionTimeWeighted.
+func (c *TicketClaims) GetAttributedClaimedCount(ctx context.Context, guildId, userId uint64, attribution ClaimAttribution) (count float64, err error) {
+	weights, err := claimWeightsQuery(attribution)
+	if err != nil {
+		return 0, err
+	}
+
+	err = c.QueryRow(ctx, fmt.Sprintf(ticketClaimsGetAttributedCount, weights), guildId, userId).Scan(&count)
+	return
+}
+
+// Returns a query of (ticket_id, weight), for the tickets attributed to the user $2 in the guild $1
+func claimWeightsQuery(attribution ClaimAttribution) (string, error) {
+	switch attribution {
+	case ClaimAttributionPrimary:
+		return ticketClaimsWeightsPrimary, nil
+	case ClaimAttributionAny:
+		return ticketClaimsWeightsAny, nil
+	case ClaimAttributionTimeWeighted:
+		return fmt.Sprintf(ticketClaimsWeightsTimeWeighted, ticketClaimsHeld), nil
+	default:
+		return "", fmt.Errorf("invalid claim attribution: %s", attribution)
+	}
+}
+
+// Returns a query of (ticket_id, user_id, weight), for the tickets attributed to every user in the guild $1
+func guildClaimWeightsQuery(attribution ClaimAttribution) (string, error) {
+	switch attribution {
+	case ClaimAttributionPrimary:
+		return ticketClaimsGuildWeightsPrimary, nil
+	case ClaimAttributionAny:
+		return ticketClaimsGuildWeightsAny, nil
+	case ClaimAttributionTimeWeighted:
+		return fmt.Sprintf(ticketClaimsGuildWeightsTimeWeighted, ticketClaimsHeld), nil
+	default:
+		return "", fmt.Errorf("invalid claim attribution: %s", attribution)
+	}
+}
@@ -0,0 +1,375 @@
+package database
+
+import (
+	"context"
+	_ "embed"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	jsoniter "github.com/json-iterator/go"
+)
+
+type TicketExportFormat string
+
+const (
+	TicketExportFormatCSV    TicketExportFormat = "csv"
+	TicketExportFormatNDJSON TicketExportFormat = "ndjson"
+)
+
+type TicketExportColumn string
+
+const (
+	TicketExportColumnId                   TicketExportColumn = "id"
+	TicketExportColumnUserId               TicketExportColumn = "user_id"
+	TicketExportColumnChannelId            TicketExportColumn = "channel_id"
+	TicketExportColumnPanelId              TicketExportColumn = "panel_id"
+	TicketExportColumnStatus               TicketExportColumn = "status"
+	TicketExportColumnOpen                 TicketExportColumn = "open"
+	TicketExportColumnOpenTime             TicketExportColumn = "open_time"
+	TicketExportColumnCloseTime            TicketExportColumn = "close_time"
+	TicketExportColumnHasTranscript        TicketExportColumn = "has_transcript"
+	TicketExportColumnIsThread             TicketExportColumn = "is_thread"
+	TicketExportColumnCloseReason          TicketExportColumn = "close_reason"
+	TicketExportColumnClosedBy             TicketExportColumn = "closed_by"
+	TicketExportColumnAutoClosed           TicketExportColumn = "auto_closed"
+	TicketExportColumnRating               TicketExportColumn = "rating"
+	TicketExportColumnClaimedBy            TicketExportColumn = "claimed_by"
+	TicketExportColumnFirstResponseBy      TicketExportColumn = "first_response_by"
+	TicketExportColumnFirstResponseSeconds TicketExportColumn = "first_response_seconds"
+	TicketExportColumnExitSurvey           TicketExportColumn = "exit_survey"
+)
+
+// TicketExportColumns is the default column selection, in order
+var TicketExportColumns = []TicketExportColumn{
+	TicketExportColumnId,
+	TicketExportColumnUserId,
+	TicketExportColumnChannelId,
+	TicketExportColumnPanelId,
+	TicketExportColumnStatus,
+	TicketExportColumnOpen,
+	TicketExportColumnOpenTime,
+	TicketExportColumnCloseTime,
+	TicketExportColumnHasTranscript,
+	TicketExportColumnIsThread,
+	TicketExportColumnCloseReason,
+	TicketExportColumnClosedBy,
+	TicketExportColumnAutoClosed,
+	TicketExportColumnRating,
+	TicketExportColumnClaimedBy,
+	TicketExportColumnFirstResponseBy,
+	TicketExportColumnFirstResponseSeconds,
+	TicketExportColumnExitSurvey,
+}
+
+type TicketExportOptions struct {
+	Format     TicketExportFormat
+	Columns    []TicketExportColumn // Defaults to TicketExportColumns
+	Location   *time.Location       // Defaults to UTC
+	TimeLayout string               // Defaults to RFC 3339
+	BatchSize  int                  // Number of rows fetched from the cursor at a time, defaults to 1000
+}
+
+var ErrTicketExportGuildRequired = errors.New("ticket exports must be filtered by guild")
+
+// Formatted with the query built from TicketQueryOptions, and the order to sort by
+//
+//go:embed sql/ticket_export/export.sql
+var ticketExportQuery string
+
+// ticketExportRow is a ticket joined with its close metadata, rating, claim, first response and exit survey responses
+type ticketExportRow struct {
+	Id                 int
+	UserId             uint64
+	ChannelId          *uint64
+	PanelId            *int
+	Status             string
+	Open               bool
+	OpenTime           time.Time
+	CloseTime          *time.Time
+	HasTranscript      bool
+	IsThread           bool
+	CloseReason        *string
+	ClosedBy           *uint64
+	AutoClosed         bool
+	Rating             *int16
+	ClaimedBy          *uint64
+	FirstResponseBy    *uint64
+	FirstResponseTime  *time.Duration
+	ExitSurveyResponse *string // JSON object of question -> response
+}
+
+// Export streams the tickets matching the query options to w, using a server-side cursor so that only BatchSize rows
+// are held in memory at a time. The query options must be filtered by guild. Returns the number of tickets written.
+func (t *TicketTable) Export(ctx context.Context, w io.Writer, queryOptions TicketQueryOptions, options TicketExportOptions) (int, error) {
+	if queryOptions.GuildId == 0 {
+		return 0, ErrTicketExportGuildRequired
+	}
+
+	columns := options.Columns
+	if len(columns) == 0 {
+		columns = TicketExportColumns
+	}
+
+	for _, column := range columns {
+		if !isTicketExportColumn(column) {
+			return 0, fmt.Errorf("invalid ticket export column: %s", column)
+		}
+	}
+
+	location := options.Location
+	if location == nil {
+		location = time.UTC
+	}
+
+	timeLayout := options.TimeLayout
+	if timeLayout == "" {
+		timeLayout = time.RFC3339
+	}
+
+	batchSize := options.BatchSize
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+
+	var writeRow func(row ticketExportRow) error
+	var flush func() error
+
+	switch options.Format {
+	case TicketExportFormatCSV:
+		writer := csv.NewWriter(w)
+
+		header := make([]string, len(columns))
+		for i, column := range columns {
+			header[i] = string(column)
+		}
+
+		if err := writer.Write(header); err != nil {
+			return 0, err
+		}
+
+		record := make([]string, len(columns))
+		writeRow = func(row ticketExportRow) error {
+			for i, column := range columns {
+				record[i] = row.csvValue(column, location, timeLayout)
+			}
+
+			return writer.Write(record)
+		}
+
+		flush = func() error {
+			writer.Flush()
+			return writer.Error()
+		}
+	case TicketExportFormatNDJSON:
+		encoder := json.NewEncoder(w)
+
+		writeRow = func(row ticketExportRow) error {
+			object := make(map[string]interface{}, len(columns))
+			for _, column := range columns {
+				object[string(column)] = row.jsonValue(column, location, timeLayout)
+			}
+
+			return encoder.Encode(object)
+		}
+
+		flush = func() error {
+			return nil
+		}
+	default:
+		return 0, fmt.Errorf("invalid ticket export format: %s", options.Format)
+	}
+
+	baseQuery, args, err := queryOptions.BuildQuery()
+	if err != nil {
+		return 0, err
+	}
+
+	order := OrderTypeAscending
+	if queryOptions.Order == OrderTypeDescending {
+		order = OrderTypeDescending
+	}
+
+	query := fmt.Sprintf(ticketExportQuery, strings.TrimSuffix(strings.TrimSpace(baseQuery), ";"), order)
+
+	// Cursors only exist for the duration of the transaction
+	tx, err := t.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, "DECLARE ticket_export NO SCROLL CURSOR FOR "+query, args...); err != nil {
+		return 0, err
+	}
+
+	fetch := fmt.Sprintf("FETCH FORWARD %d FROM ticket_export;", batchSize)
+
+	var written int
+	for {
+		rows, err := tx.Query(ctx, fetch)
+		if err != nil {
+			return written, err
+		}
+
+		var fetched int
+		for rows.Next() {
+			var row ticketExportRow
+			if err := rows.Scan(
+				&row.Id,
+				&row.UserId,
+				&row.ChannelId,
+				&row.PanelId,
+				&row.Status,
+				&row.Open,
+				&row.OpenTime,
+				&row.CloseTime,
+				&row.HasTranscript,
+				&row.IsThread,
+				&row.CloseReason,
+				&row.ClosedBy,
+				&row.AutoClosed,
+				&row.Rating,
+				&row.ClaimedBy,
+				&row.FirstResponseBy,
+				&row.FirstResponseTime,
+				&row.ExitSurveyResponse,
+			); err != nil {
+				rows.Close()
+				return written, err
+			}
+
+			if err := writeRow(row); err != nil {
+				rows.Close()
+				return written, err
+			}
+
+			fetched++
+			written++
+		}
+
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return written, err
+		}
+
+		if err := flush(); err != nil {
+			return written, err
+		}
+
+		if fetched < batchSize {
+			break
+		}
+	}
+
+	return written, nil
+}
+
+func isTicketExportColumn(column TicketExportColumn) bool {
+	for _, valid := range TicketExportColumns {
+		if column == valid {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r ticketExportRow) csvValue(column TicketExportColumn, location *time.Location, timeLayout string) string {
+	switch value := r.jsonValue(column, location, timeLayout).(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case jsoniter.RawMessage:
+		return string(value)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// Snowflakes are written as strings, as they cannot be represented by a JSON number
+func (r ticketExportRow) jsonValue(column TicketExportColumn, location *time.Location, timeLayout string) interface{} {
+	snowflake := func(id *uint64) interface{} {
+		if id == nil {
+			return nil
+		}
+
+		return strconv.FormatUint(*id, 10)
+	}
+
+	formatTime := func(t *time.Time) interface{} {
+		if t == nil {
+			return nil
+		}
+
+		return t.In(location).Format(timeLayout)
+	}
+
+	switch column {
+	case TicketExportColumnId:
+		return r.Id
+	case TicketExportColumnUserId:
+		return snowflake(&r.UserId)
+	case TicketExportColumnChannelId:
+		return snowflake(r.ChannelId)
+	case TicketExportColumnPanelId:
+		if r.PanelId == nil {
+			return nil
+		}
+
+		return *r.PanelId
+	case TicketExportColumnStatus:
+		return r.Status
+	case TicketExportColumnOpen:
+		return r.Open
+	case TicketExportColumnOpenTime:
+		return formatTime(&r.OpenTime)
+	case TicketExportColumnCloseTime:
+		return formatTime(r.CloseTime)
+	case TicketExportColumnHasTranscript:
+		return r.HasTranscript
+	case TicketExportColumnIsThread:
+		return r.IsThread
+	case TicketExportColumnCloseReason:
+		if r.CloseReason == nil {
+			return nil
+		}
+
+		return *r.CloseReason
+	case TicketExportColumnClosedBy:
+		return snowflake(r.ClosedBy)
+	case TicketExportColumnAutoClosed:
+		return r.AutoClosed
+	case TicketExportColumnRating:
+		if r.Rating == nil {
+			return nil
+		}
+
+		return *r.Rating
+	case TicketExportColumnClaimedBy:
+		return snowflake(r.ClaimedBy)
+	case TicketExportColumnFirstResponseBy:
+		return snowflake(r.FirstResponseBy)
+	case TicketExportColumnFirstResponseSeconds:
+		if r.FirstResponseTime == nil {
+			return nil
+		}
+
+		return int64(r.FirstResponseTime.Seconds())
+	case TicketExportColumnExitSurvey:
+		if r.ExitSurveyResponse == nil {
+			return nil
+		}
+
+		return jsoniter.RawMessage(*r.ExitSurveyResponse)
+	default:
+		return nil
+	}
+}
@@ -58,6 +58,7 @@ WHERE "guild_id" = $1 AND "ticket_id" = $2;`
 	return
 }
 
+// Deprecated: Use Database.ImportTicketData, which can be safely re-run if an import fails.
 func (m *TicketLastMessageTable) ImportBulk(ctx context.Context, guildId uint64, lastMessages map[int]TicketLastMessage) (err error) {
 	rows := make([][]interface{}, 0)
 
@@ -53,6 +53,7 @@ func (m *TicketMembers) Get(ctx context.Context, guildId uint64, ticketId int) (
 	return
 }
 
+// Deprecated: Use Database.ImportTicketData, which can be safely re-run if an import fails.
 func (m *TicketMembers) ImportBulk(ctx context.Context, guildId uint64, ticketUsers map[int][]uint64) (err error) {
 	rows := make([][]interface{}, 0)
 
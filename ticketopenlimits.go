@@ -0,0 +1,286 @@
+package database
+
+import (
+	"context"
+	_ "embed"
+	"time"
+
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// TicketOpenLimits restricts how many tickets can be opened, scoped to the whole guild, a panel, the panels of a support
+// team, or the users with a role. Guilds without a guild-wide per-user max_open rule are still limited by TicketLimit.
+type TicketOpenLimits struct {
+	*pgxpool.Pool
+}
+
+type TicketOpenLimitType string
+
+const (
+	// TicketOpenLimitMaxOpen limits the number of tickets open at once
+	TicketOpenLimitMaxOpen TicketOpenLimitType = "max_open"
+	// TicketOpenLimitCooldown requires Period to pass between tickets being opened
+	TicketOpenLimitCooldown TicketOpenLimitType = "cooldown"
+	// TicketOpenLimitWindowCap limits the number of tickets opened within a rolling window of Period, e.g. a daily cap
+	TicketOpenLimitWindowCap TicketOpenLimitType = "window_cap"
+)
+
+// TicketOpenLimit applies to the whole guild if PanelId, TeamId and RoleId are all nil. If PerUser is false, tickets
+// opened by all users are counted together.
+type TicketOpenLimit struct {
+	Id       int                 `json:"id"` // 0 for the legacy limit from TicketLimit
+	GuildId  uint64              `json:"guild_id,string"`
+	PanelId  *int                `json:"panel_id"`
+	TeamId   *int                `json:"team_id"`
+	RoleId   *uint64             `json:"role_id,string"`
+	Type     TicketOpenLimitType `json:"type"`
+	MaxCount *int                `json:"max_count"` // Nil for cooldowns
+	Period   *time.Duration      `json:"period"`    // Nil for max_open
+	PerUser  bool                `json:"per_user"`
+}
+
+type OpenLimitEvaluation struct {
+	Allowed   bool             `json:"allowed"`
+	BlockedBy *TicketOpenLimit `json:"blocked_by"`
+	Count     int              `json:"count"` // Number of tickets counted towards the blocking rule
+	// When the user may try again. Nil if allowed, or if the user must wait for a ticket to be closed.
+	RetryAt *time.Time `json:"retry_at"`
+}
+
+type evaluatedOpenLimit struct {
+	limit   TicketOpenLimit
+	count   int
+	blocked bool
+	retryAt *time.Time
+}
+
+var (
+	//go:embed sql/ticket_open_limits/schema.sql
+	ticketOpenLimitsSchema string
+
+	//go:embed sql/ticket_open_limits/get_by_guild.sql
+	ticketOpenLimitsGetByGuild string
+
+	//go:embed sql/ticket_open_limits/create.sql
+	ticketOpenLimitsCreate string
+
+	//go:embed sql/ticket_open_limits/update.sql
+	ticketOpenLimitsUpdate string
+
+	//go:embed sql/ticket_open_limits/delete.sql
+	ticketOpenLimitsDelete string
+
+	//go:embed sql/ticket_open_limits/evaluate.sql
+	ticketOpenLimitsEvaluate string
+)
+
+func newTicketOpenLimits(db *pgxpool.Pool) *TicketOpenLimits {
+	return &TicketOpenLimits{
+		db,
+	}
+}
+
+func (TicketOpenLimits) Schema() string {
+	return ticketOpenLimitsSchema
+}
+
+func (l *TicketOpenLimits) GetByGuild(ctx context.Context, guildId uint64) ([]TicketOpenLimit, error) {
+	rows, err := l.Query(ctx, ticketOpenLimitsGetByGuild, guildId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var limits []TicketOpenLimit
+	for rows.Next() {
+		var limit TicketOpenLimit
+		if err := rows.Scan(
+			&limit.Id,
+			&limit.GuildId,
+			&limit.PanelId,
+			&limit.TeamId,
+			&limit.RoleId,
+			&limit.Type,
+			&limit.MaxCount,
+			&limit.Period,
+			&limit.PerUser,
+		); err != nil {
+			return nil, err
+		}
+
+		limits = append(limits, limit)
+	}
+
+	return limits, rows.Err()
+}
+
+// Create returns ErrPanelNotFound or ErrSupportTeamNotFound if the limit's panel or team does not belong to the guild.
+func (l *TicketOpenLimits) Create(ctx context.Context, limit TicketOpenLimit) (int, error) {
+	var validPanel, validTeam bool
+	var id *int
+	if err := l.QueryRow(ctx, ticketOpenLimitsCreate,
+		limit.GuildId,
+		limit.PanelId,
+		limit.TeamId,
+		limit.RoleId,
+		limit.Type,
+		limit.MaxCount,
+		limit.Period,
+		limit.PerUser,
+	).Scan(&validPanel, &validTeam, &id); err != nil {
+		return 0, err
+	}
+
+	if err := scopeError(validPanel, validTeam); err != nil {
+		return 0, err
+	}
+
+	return *id, nil
+}
+
+// Update returns ErrPanelNotFound or ErrSupportTeamNotFound if the limit's panel or team does not belong to the guild.
+func (l *TicketOpenLimits) Update(ctx context.Context, limit TicketOpenLimit) error {
+	var validPanel, validTeam bool
+	if err := l.QueryRow(ctx, ticketOpenLimitsUpdate,
+		limit.GuildId,
+		limit.Id,
+		limit.PanelId,
+		limit.TeamId,
+		limit.RoleId,
+		limit.Type,
+		limit.MaxCount,
+		limit.Period,
+		limit.PerUser,
+	).Scan(&validPanel, &validTeam); err != nil {
+		return err
+	}
+
+	return scopeError(validPanel, validTeam)
+}
+
+func (l *TicketOpenLimits) Delete(ctx context.Context, guildId uint64, id int) (err error) {
+	_, err = l.Exec(ctx, ticketOpenLimitsDelete, guildId, id)
+	return
+}
+
+// EvaluateOpenLimits checks whether the user may open a ticket from the panel, or without a panel if panelId is nil.
+// Every applicable rule must pass, except that if the user has roles with rules of a type, the most permissive of those
+// rules replaces the guild-wide rules of that type, so that a role can be used to grant a higher limit.
+//
+// If multiple rules block the open, the one that will block it for longest is returned.
+func (l *TicketOpenLimits) EvaluateOpenLimits(
+	ctx context.Context,
+	guildId uint64,
+	panelId *int,
+	userId uint64,
+	roleIds []uint64,
+) (OpenLimitEvaluation, error) {
+	roleIdArray := &pgtype.Int8Array{}
+	if err := roleIdArray.Set(roleIds); err != nil {
+		return OpenLimitEvaluation{}, err
+	}
+
+	rows, err := l.Query(ctx, ticketOpenLimitsEvaluate, guildId, panelId, userId, roleIdArray)
+	if err != nil {
+		return OpenLimitEvaluation{}, err
+	}
+
+	defer rows.Close()
+
+	var evaluated []evaluatedOpenLimit
+	for rows.Next() {
+		e := evaluatedOpenLimit{
+			limit: TicketOpenLimit{
+				GuildId: guildId,
+			},
+		}
+
+		if err := rows.Scan(
+			&e.limit.Id,
+			&e.limit.PanelId,
+			&e.limit.TeamId,
+			&e.limit.RoleId,
+			&e.limit.Type,
+			&e.limit.MaxCount,
+			&e.limit.Period,
+			&e.limit.PerUser,
+			&e.count,
+			&e.blocked,
+			&e.retryAt,
+		); err != nil {
+			return OpenLimitEvaluation{}, err
+		}
+
+		evaluated = append(evaluated, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return OpenLimitEvaluation{}, err
+	}
+
+	return resolveOpenLimits(evaluated), nil
+}
+
+func resolveOpenLimits(evaluated []evaluatedOpenLimit) OpenLimitEvaluation {
+	// Role rules of a type override the guild-wide rules of that type. If none of them pass, the user is only held to
+	// the most permissive, i.e. the one that blocks for the shortest time.
+	roleRules := make(map[TicketOpenLimitType]*evaluatedOpenLimit) // Nil if any role rule of the type passes
+	for i, e := range evaluated {
+		if e.limit.RoleId == nil {
+			continue
+		}
+
+		current, ok := roleRules[e.limit.Type]
+		if !e.blocked {
+			roleRules[e.limit.Type] = nil
+		} else if !ok || (current != nil && blocksLonger(*current, e)) {
+			roleRules[e.limit.Type] = &evaluated[i]
+		}
+	}
+
+	var blocking *evaluatedOpenLimit
+	for i, e := range evaluated {
+		if !e.blocked {
+			continue
+		}
+
+		if roleRule, ok := roleRules[e.limit.Type]; ok {
+			isGuildWide := e.limit.PanelId == nil && e.limit.TeamId == nil && e.limit.RoleId == nil
+			if isGuildWide || (e.limit.RoleId != nil && roleRule != &evaluated[i]) {
+				continue
+			}
+		}
+
+		if blocking == nil || blocksLonger(e, *blocking) {
+			blocking = &evaluated[i]
+		}
+	}
+
+	if blocking == nil {
+		return OpenLimitEvaluation{
+			Allowed: true,
+		}
+	}
+
+	return OpenLimitEvaluation{
+		Allowed:   false,
+		BlockedBy: &blocking.limit,
+		Count:     blocking.count,
+		RetryAt:   blocking.retryAt,
+	}
+}
+
+// A rule without a retry time blocks until a ticket is closed, and so is treated as blocking for longest
+func blocksLonger(a, b evaluatedOpenLimit) bool {
+	if a.retryAt == nil {
+		return b.retryAt != nil
+	}
+
+	if b.retryAt == nil {
+		return false
+	}
+
+	return a.retryAt.After(*b.retryAt)
+}
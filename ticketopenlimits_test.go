@@ -0,0 +1,117 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResolveOpenLimits(t *testing.T) {
+	now := time.Now()
+	soon, later := now.Add(time.Minute), now.Add(time.Hour)
+	roleId, otherRoleId := uint64(1), uint64(2)
+	panelId := 1
+
+	limit := func(id int, limitType TicketOpenLimitType, roleId *uint64, panelId *int) TicketOpenLimit {
+		return TicketOpenLimit{Id: id, Type: limitType, RoleId: roleId, PanelId: panelId}
+	}
+
+	cases := []struct {
+		name      string
+		evaluated []evaluatedOpenLimit
+		blockedBy int // 0 if allowed
+	}{
+		{
+			name: "no rules",
+		},
+		{
+			name: "passing guild rule",
+			evaluated: []evaluatedOpenLimit{
+				{limit: limit(1, TicketOpenLimitMaxOpen, nil, nil)},
+			},
+		},
+		{
+			name: "blocking guild rule",
+			evaluated: []evaluatedOpenLimit{
+				{limit: limit(1, TicketOpenLimitMaxOpen, nil, nil), blocked: true},
+			},
+			blockedBy: 1,
+		},
+		{
+			name: "passing role rule overrides blocking guild rule of the same type",
+			evaluated: []evaluatedOpenLimit{
+				{limit: limit(1, TicketOpenLimitMaxOpen, nil, nil), blocked: true},
+				{limit: limit(2, TicketOpenLimitMaxOpen, &roleId, nil)},
+			},
+		},
+		{
+			name: "role rule does not override guild rule of another type",
+			evaluated: []evaluatedOpenLimit{
+				{limit: limit(1, TicketOpenLimitCooldown, nil, nil), blocked: true, retryAt: &soon},
+				{limit: limit(2, TicketOpenLimitMaxOpen, &roleId, nil)},
+			},
+			blockedBy: 1,
+		},
+		{
+			name: "role rule does not override panel rule",
+			evaluated: []evaluatedOpenLimit{
+				{limit: limit(1, TicketOpenLimitMaxOpen, nil, &panelId), blocked: true},
+				{limit: limit(2, TicketOpenLimitMaxOpen, &roleId, nil)},
+			},
+			blockedBy: 1,
+		},
+		{
+			name: "any passing role rule of a type allows",
+			evaluated: []evaluatedOpenLimit{
+				{limit: limit(1, TicketOpenLimitCooldown, &roleId, nil), blocked: true, retryAt: &later},
+				{limit: limit(2, TicketOpenLimitCooldown, &otherRoleId, nil)},
+			},
+		},
+		{
+			name: "most permissive blocking role rule applies",
+			evaluated: []evaluatedOpenLimit{
+				{limit: limit(1, TicketOpenLimitCooldown, &roleId, nil), blocked: true, retryAt: &later},
+				{limit: limit(2, TicketOpenLimitCooldown, &otherRoleId, nil), blocked: true, retryAt: &soon},
+				{limit: limit(3, TicketOpenLimitCooldown, nil, nil), blocked: true, retryAt: &later},
+			},
+			blockedBy: 2,
+		},
+		{
+			name: "longest blocking rule is reported",
+			evaluated: []evaluatedOpenLimit{
+				{limit: limit(1, TicketOpenLimitCooldown, nil, nil), blocked: true, retryAt: &soon},
+				{limit: limit(2, TicketOpenLimitWindowCap, nil, nil), blocked: true, retryAt: &later},
+			},
+			blockedBy: 2,
+		},
+		{
+			name: "rule without a retry time blocks longest",
+			evaluated: []evaluatedOpenLimit{
+				{limit: limit(1, TicketOpenLimitCooldown, nil, nil), blocked: true, retryAt: &later},
+				{limit: limit(2, TicketOpenLimitMaxOpen, nil, &panelId), blocked: true},
+			},
+			blockedBy: 2,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			res := resolveOpenLimits(c.evaluated)
+
+			if c.blockedBy == 0 {
+				if !res.Allowed || res.BlockedBy != nil {
+					t.Errorf("expected to be allowed, got blocked by %+v", res.BlockedBy)
+				}
+
+				return
+			}
+
+			if res.Allowed || res.BlockedBy == nil {
+				t.Fatalf("expected to be blocked by rule %d, but was allowed", c.blockedBy)
+			}
+
+			if res.BlockedBy.Id != c.blockedBy {
+				t.Errorf("expected to be blocked by rule %d, got %d", c.blockedBy, res.BlockedBy.Id)
+			}
+		})
+	}
+}
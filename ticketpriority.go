@@ -0,0 +1,82 @@
+package database
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// TicketPriority is 0 for tickets without a row. Higher values are more urgent.
+type TicketPriority struct {
+	*pgxpool.Pool
+}
+
+var (
+	//go:embed sql/ticket_priority/schema.sql
+	ticketPrioritySchema string
+
+	//go:embed sql/ticket_priority/get.sql
+	ticketPriorityGet string
+
+	//go:embed sql/ticket_priority/set.sql
+	ticketPrioritySet string
+
+	//go:embed sql/ticket_priority/increase.sql
+	ticketPriorityIncrease string
+)
+
+func newTicketPriority(db *pgxpool.Pool) *TicketPriority {
+	return &TicketPriority{
+		db,
+	}
+}
+
+func (TicketPriority) Schema() string {
+	return ticketPrioritySchema
+}
+
+func (p *TicketPriority) Get(ctx context.Context, guildId uint64, ticketId int) (priority int, err error) {
+	if err := p.QueryRow(ctx, ticketPriorityGet, guildId, ticketId).Scan(&priority); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, nil
+		}
+
+		return 0, err
+	}
+
+	return priority, nil
+}
+
+func (p *TicketPriority) Set(ctx context.Context, guildId uint64, ticketId int, priority int) (err error) {
+	_, err = p.Exec(ctx, ticketPrioritySet, guildId, ticketId, priority)
+	return
+}
+
+// Increase adds to the ticket's priority, and returns the new priority
+func (p *TicketPriority) Increase(ctx context.Context, guildId uint64, ticketId int, by int) (int, error) {
+	tx, err := p.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	priority, err := p.IncreaseWithTx(ctx, tx, guildId, ticketId, by)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+
+	return priority, nil
+}
+
+func (p *TicketPriority) IncreaseWithTx(ctx context.Context, tx pgx.Tx, guildId uint64, ticketId int, by int) (priority int, err error) {
+	err = tx.QueryRow(ctx, ticketPriorityIncrease, guildId, ticketId, by).Scan(&priority)
+	return
+}
@@ -93,6 +93,7 @@ CREATE INDEX IF NOT EXISTS tickets_panel_id ON tickets("panel_id");
 `
 }
 
+// Deprecated: Use Database.ImportTicketData, which can be safely re-run if an import fails.
 func (t *TicketTable) BulkImport(ctx context.Context, guildId uint64, tickets []Ticket) (err error) {
 	rows := make([][]interface{}, len(tickets))
 
@@ -134,7 +135,31 @@ VALUES(
 )
 RETURNING "id";`
 
-	err = t.QueryRow(ctx, query, guildId, userId, isThread, panelId, model.TicketStatusOpen).Scan(&id)
+	tx, err := t.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	if err := tx.QueryRow(ctx, query, guildId, userId, isThread, panelId, model.TicketStatusOpen).Scan(&id); err != nil {
+		return 0, err
+	}
+
+	if err := recordTicketStatus(ctx, tx, guildId, id, model.TicketStatusOpen); err != nil {
+		return 0, err
+	}
+
+	if err := publishTicketEvent(ctx, tx, OutboxEventTicketOpened, TicketEventPayload{
+		GuildId:  guildId,
+		TicketId: id,
+		UserId:   userId,
+		PanelId:  panelId,
+	}); err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit(ctx)
 	return
 }
 
@@ -1052,15 +1077,81 @@ func (t *TicketTable) GetTotalTicketCount(ctx context.Context, guildId uint64) (
 }
 
 func (t *TicketTable) Close(ctx context.Context, ticketId int, guildId uint64) (err error) {
-	query := `UPDATE tickets SET "open"=false, "close_time"=NOW(), "status"='CLOSED' WHERE "id"=$1 AND "guild_id"=$2;`
-	_, err = t.Exec(ctx, query, ticketId, guildId)
-	return
+	query := `
+UPDATE tickets SET "open"=false, "close_time"=NOW(), "status"='CLOSED'
+FROM (SELECT "guild_id", "id", "open" FROM tickets WHERE "id"=$1 AND "guild_id"=$2 FOR UPDATE) previous
+WHERE tickets."guild_id" = previous."guild_id" AND tickets."id" = previous."id"
+RETURNING tickets."guild_id", tickets."id", tickets."user_id", tickets."panel_id", tickets."open" <> previous."open";`
+
+	closed := model.TicketStatusClosed
+	return t.updateAndPublish(ctx, OutboxEventTicketClosed, &closed, query, ticketId, guildId)
 }
 
 func (t *TicketTable) CloseByChannel(ctx context.Context, channelId uint64) (err error) {
-	query := `UPDATE tickets SET "open" = false, "close_time" = NOW(), "status" = 'CLOSED' WHERE "channel_id" = $1;`
-	_, err = t.Exec(ctx, query, channelId)
-	return
+	query := `
+UPDATE tickets SET "open" = false, "close_time" = NOW(), "status" = 'CLOSED'
+FROM (SELECT "guild_id", "id", "open" FROM tickets WHERE "channel_id" = $1 FOR UPDATE) previous
+WHERE tickets."guild_id" = previous."guild_id" AND tickets."id" = previous."id"
+RETURNING tickets."guild_id", tickets."id", tickets."user_id", tickets."panel_id", tickets."open" <> previous."open";`
+
+	closed := model.TicketStatusClosed
+	return t.updateAndPublish(ctx, OutboxEventTicketClosed, &closed, query, channelId)
+}
+
+// updateAndPublish runs an UPDATE ... RETURNING guild_id, id, user_id, panel_id, changed statement, and records an
+// outbox event, and the new status if it is not nil, in the same transaction for each ticket whose open state actually
+// changed, so that closing an already closed ticket does not emit a second event.
+func (t *TicketTable) updateAndPublish(
+	ctx context.Context,
+	eventType OutboxEventType,
+	status *model.TicketStatus,
+	query string,
+	args ...interface{},
+) error {
+	tx, err := t.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	var payloads []TicketEventPayload
+	for rows.Next() {
+		var payload TicketEventPayload
+		var changed bool
+		if err := rows.Scan(&payload.GuildId, &payload.TicketId, &payload.UserId, &payload.PanelId, &changed); err != nil {
+			rows.Close()
+			return err
+		}
+
+		if changed {
+			payloads = append(payloads, payload)
+		}
+	}
+
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, payload := range payloads {
+		if err := publishTicketEvent(ctx, tx, eventType, payload); err != nil {
+			return err
+		}
+
+		if status != nil {
+			if err := recordTicketStatus(ctx, tx, payload.GuildId, payload.TicketId, *status); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit(ctx)
 }
 
 func (t *TicketTable) SetHasTranscript(ctx context.Context, guildId uint64, ticketId int, hasTranscript bool) (err error) {
@@ -1076,9 +1167,14 @@ func (t *TicketTable) SetPanelId(ctx context.Context, guildId uint64, ticketId,
 }
 
 func (t *TicketTable) SetOpen(ctx context.Context, guildId uint64, ticketId int) (err error) {
-	query := `UPDATE tickets SET "open" = TRUE, "close_time" = NULL WHERE "guild_id" = $1 AND "id" = $2;`
-	_, err = t.Exec(ctx, query, guildId, ticketId)
-	return
+	query := `
+UPDATE tickets SET "open" = TRUE, "close_time" = NULL
+FROM (SELECT "guild_id", "id", "open" FROM tickets WHERE "guild_id" = $1 AND "id" = $2 FOR UPDATE) previous
+WHERE tickets."guild_id" = previous."guild_id" AND tickets."id" = previous."id"
+RETURNING tickets."guild_id", tickets."id", tickets."user_id", tickets."panel_id", tickets."open" <> previous."open";`
+
+	// The status is managed separately through SetStatus, which also records the status history
+	return t.updateAndPublish(ctx, OutboxEventTicketReopened, nil, query, guildId, ticketId)
 }
 
 func (t *TicketTable) SetJoinMessageId(ctx context.Context, guildId uint64, ticketId int, joinMessageId *uint64) (err error) {
@@ -1097,6 +1193,23 @@ func (t *TicketTable) SetNotesThreadId(ctx context.Context, guildId uint64, tick
 func (t *TicketTable) SetStatus(ctx context.Context, guildId uint64, ticketId int, status model.TicketStatus) error {
 	query := `UPDATE tickets SET "status" = $3 WHERE "guild_id" = $1 AND "id" = $2;`
 
-	_, err := t.Exec(ctx, query, guildId, ticketId, status)
-	return err
+	tx, err := t.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	res, err := tx.Exec(ctx, query, guildId, ticketId, status)
+	if err != nil {
+		return err
+	}
+
+	if res.RowsAffected() > 0 {
+		if err := recordTicketStatus(ctx, tx, guildId, ticketId, status); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
 }
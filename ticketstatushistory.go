@@ -0,0 +1,165 @@
+package database
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/jadevelopmentgrp/Tickets-Utilities/model"
+)
+
+// TicketStatusHistory records each status a ticket passes through, including closes and reopens. It is written to by
+// TicketTable, and should not need to be written to directly.
+type TicketStatusHistory struct {
+	*pgxpool.Pool
+}
+
+type TicketStatusChange struct {
+	Status    model.TicketStatus `json:"status"`
+	ChangedAt time.Time          `json:"changed_at"`
+}
+
+type ResolutionMetricsGroup uint8
+
+const (
+	ResolutionMetricsByGuild ResolutionMetricsGroup = iota
+	ResolutionMetricsByPanel
+	ResolutionMetricsByTeam
+	ResolutionMetricsByCloser
+)
+
+// ResolutionMetrics is computed over closed tickets. Durations only count the time that a ticket was open or pending,
+// so time spent closed before being reopened is excluded. The time spent in each status is only available for tickets
+// opened after status history started being recorded, and is nil if there are no such tickets in the group.
+type ResolutionMetrics struct {
+	// Panel ID, team ID or the user ID of the closer, depending on the grouping. Nil when grouped by guild, for tickets
+	// that were not opened from a panel or whose panel has no teams, and for automatically closed tickets.
+	Key                     *uint64        `json:"key,string"`
+	TicketCount             int            `json:"ticket_count"`
+	AutoClosedCount         int            `json:"auto_closed_count"`
+	AverageTimeToClose      *time.Duration `json:"average_time_to_close"`
+	MedianTimeToClose       *time.Duration `json:"median_time_to_close"`
+	AverageTimeToCloseStaff *time.Duration `json:"average_time_to_close_staff"` // Excludes automatic closes
+	Reopens                 int            `json:"reopens"`
+	ReopenedTicketCount     int            `json:"reopened_ticket_count"`
+	AverageTimeOpen         *time.Duration `json:"average_time_open"`
+	AverageTimePending      *time.Duration `json:"average_time_pending"`
+}
+
+var (
+	//go:embed sql/ticket_status_history/schema.sql
+	ticketStatusHistorySchema string
+
+	//go:embed sql/ticket_status_history/record.sql
+	ticketStatusHistoryRecord string
+
+	//go:embed sql/ticket_status_history/get_by_ticket.sql
+	ticketStatusHistoryGetByTicket string
+
+	//go:embed sql/ticket_status_history/get_resolution_metrics.sql
+	ticketStatusHistoryGetResolutionMetrics string
+)
+
+var resolutionMetricsGroups = map[ResolutionMetricsGroup]struct {
+	key  string
+	join string
+}{
+	ResolutionMetricsByGuild:  {key: "NULL::int8"},
+	ResolutionMetricsByPanel:  {key: "resolved.panel_id::int8"},
+	ResolutionMetricsByTeam:   {key: "panel_teams.team_id::int8", join: "LEFT JOIN panel_teams ON panel_teams.panel_id = resolved.panel_id"},
+	ResolutionMetricsByCloser: {key: "CASE WHEN resolved.auto_closed THEN NULL ELSE resolved.closed_by END"},
+}
+
+func newTicketStatusHistory(db *pgxpool.Pool) *TicketStatusHistory {
+	return &TicketStatusHistory{
+		db,
+	}
+}
+
+func (TicketStatusHistory) Schema() string {
+	return ticketStatusHistorySchema
+}
+
+func (h *TicketStatusHistory) GetByTicket(ctx context.Context, guildId uint64, ticketId int) ([]TicketStatusChange, error) {
+	rows, err := h.Query(ctx, ticketStatusHistoryGetByTicket, guildId, ticketId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var changes []TicketStatusChange
+	for rows.Next() {
+		var change TicketStatusChange
+		if err := rows.Scan(&change.Status, &change.ChangedAt); err != nil {
+			return nil, err
+		}
+
+		changes = append(changes, change)
+	}
+
+	return changes, rows.Err()
+}
+
+// GetResolutionMetrics returns metrics for tickets closed within the interval, or for all time if the interval is
+// zero. Tickets from panels with multiple teams are counted once for each team.
+func (h *TicketStatusHistory) GetResolutionMetrics(
+	ctx context.Context,
+	guildId uint64,
+	interval time.Duration,
+	groupBy ResolutionMetricsGroup,
+) ([]ResolutionMetrics, error) {
+	group, ok := resolutionMetricsGroups[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("invalid resolution metrics group: %d", groupBy)
+	}
+
+	parsedInterval := pgtype.Interval{Status: pgtype.Null}
+	if interval > 0 {
+		var err error
+		if parsedInterval, err = toInterval(interval); err != nil {
+			return nil, err
+		}
+	}
+
+	query := fmt.Sprintf(ticketStatusHistoryGetResolutionMetrics, group.key, group.join)
+
+	rows, err := h.Query(ctx, query, guildId, parsedInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var metrics []ResolutionMetrics
+	for rows.Next() {
+		var m ResolutionMetrics
+		if err := rows.Scan(
+			&m.Key,
+			&m.TicketCount,
+			&m.AutoClosedCount,
+			&m.AverageTimeToClose,
+			&m.MedianTimeToClose,
+			&m.AverageTimeToCloseStaff,
+			&m.Reopens,
+			&m.ReopenedTicketCount,
+			&m.AverageTimeOpen,
+			&m.AverageTimePending,
+		); err != nil {
+			return nil, err
+		}
+
+		metrics = append(metrics, m)
+	}
+
+	return metrics, rows.Err()
+}
+
+func recordTicketStatus(ctx context.Context, tx pgx.Tx, guildId uint64, ticketId int, status model.TicketStatus) error {
+	_, err := tx.Exec(ctx, ticketStatusHistoryRecord, guildId, ticketId, status)
+	return err
+}
@@ -0,0 +1,223 @@
+package database
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// TicketSubscriptions lets staff follow tickets without claiming them, either individually, or all tickets opened from a
+// panel or from the panels assigned to a support team.
+type TicketSubscriptions struct {
+	*pgxpool.Pool
+}
+
+type SubscriptionEvent string
+
+const (
+	SubscriptionEventMessage      SubscriptionEvent = "message"
+	SubscriptionEventStatusChange SubscriptionEvent = "status_change"
+	SubscriptionEventClose        SubscriptionEvent = "close"
+)
+
+// TicketSubscriber is a user to notify of an event on a ticket. If HasAccess is false, the user can only access the
+// ticket through one of RoleIds, so should only be notified if CanAccess reports that they hold one of them.
+type TicketSubscriber struct {
+	UserId    uint64   `json:"user_id,string"`
+	HasAccess bool     `json:"has_access"` // Whether the user has access through their own permissions or team membership
+	RoleIds   []uint64 `json:"role_ids"`   // Roles that grant access, only set if HasAccess is false
+}
+
+// TicketSubscription targets exactly one of TicketId, PanelId and TeamId. A ticket subscription with every event
+// disabled mutes a ticket that the user would otherwise be notified about through a panel or team subscription.
+type TicketSubscription struct {
+	Id                 int    `json:"id"`
+	GuildId            uint64 `json:"guild_id,string"`
+	UserId             uint64 `json:"user_id,string"`
+	TicketId           *int   `json:"ticket_id"`
+	PanelId            *int   `json:"panel_id"`
+	TeamId             *int   `json:"team_id"`
+	NotifyMessage      bool   `json:"notify_message"`
+	NotifyStatusChange bool   `json:"notify_status_change"`
+	NotifyClose        bool   `json:"notify_close"`
+}
+
+var subscriptionEventColumns = map[SubscriptionEvent]string{
+	SubscriptionEventMessage:      "notify_message",
+	SubscriptionEventStatusChange: "notify_status_change",
+	SubscriptionEventClose:        "notify_close",
+}
+
+var (
+	//go:embed sql/ticket_subscriptions/schema.sql
+	ticketSubscriptionsSchema string
+
+	//go:embed sql/ticket_subscriptions/set.sql
+	ticketSubscriptionsSet string
+
+	//go:embed sql/ticket_subscriptions/delete.sql
+	ticketSubscriptionsDelete string
+
+	//go:embed sql/ticket_subscriptions/get_by_user.sql
+	ticketSubscriptionsGetByUser string
+
+	//go:embed sql/ticket_subscriptions/get_by_ticket.sql
+	ticketSubscriptionsGetByTicket string
+
+	// Formatted with the preference column for the event
+	//
+	//go:embed sql/ticket_subscriptions/get_subscribers.sql
+	ticketSubscriptionsGetSubscribers string
+)
+
+func newTicketSubscriptions(db *pgxpool.Pool) *TicketSubscriptions {
+	return &TicketSubscriptions{
+		db,
+	}
+}
+
+func (TicketSubscriptions) Schema() string {
+	return ticketSubscriptionsSchema
+}
+
+// Set creates the subscription, or updates the preferences of the user's existing subscription to the same target, and
+// returns its ID.
+func (s *TicketSubscriptions) Set(ctx context.Context, subscription TicketSubscription) (id int, err error) {
+	err = s.QueryRow(ctx, ticketSubscriptionsSet,
+		subscription.GuildId,
+		subscription.UserId,
+		subscription.TicketId,
+		subscription.PanelId,
+		subscription.TeamId,
+		subscription.NotifyMessage,
+		subscription.NotifyStatusChange,
+		subscription.NotifyClose,
+	).Scan(&id)
+
+	return
+}
+
+func (s *TicketSubscriptions) Delete(ctx context.Context, guildId, userId uint64, id int) (err error) {
+	_, err = s.Exec(ctx, ticketSubscriptionsDelete, guildId, userId, id)
+	return
+}
+
+func (s *TicketSubscriptions) GetByUser(ctx context.Context, guildId, userId uint64) ([]TicketSubscription, error) {
+	rows, err := s.Query(ctx, ticketSubscriptionsGetByUser, guildId, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var subscriptions []TicketSubscription
+	for rows.Next() {
+		subscription := TicketSubscription{
+			GuildId: guildId,
+			UserId:  userId,
+		}
+
+		if err := rows.Scan(
+			&subscription.Id,
+			&subscription.TicketId,
+			&subscription.PanelId,
+			&subscription.TeamId,
+			&subscription.NotifyMessage,
+			&subscription.NotifyStatusChange,
+			&subscription.NotifyClose,
+		); err != nil {
+			return nil, err
+		}
+
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	return subscriptions, rows.Err()
+}
+
+// GetByTicket returns the subscriptions to the ticket itself, not including panel or team subscriptions
+func (s *TicketSubscriptions) GetByTicket(ctx context.Context, guildId uint64, ticketId int) ([]TicketSubscription, error) {
+	rows, err := s.Query(ctx, ticketSubscriptionsGetByTicket, guildId, ticketId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var subscriptions []TicketSubscription
+	for rows.Next() {
+		subscription := TicketSubscription{
+			GuildId:  guildId,
+			TicketId: ptr(ticketId),
+		}
+
+		if err := rows.Scan(
+			&subscription.Id,
+			&subscription.UserId,
+			&subscription.NotifyMessage,
+			&subscription.NotifyStatusChange,
+			&subscription.NotifyClose,
+		); err != nil {
+			return nil, err
+		}
+
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	return subscriptions, rows.Err()
+}
+
+// GetSubscribers returns the users to notify of an event on the ticket. Users who have access to the ticket according
+// to Permissions or SupportTeamMembersTable are returned with HasAccess set. Other users are returned with the roles from
+// RolePermissions and SupportTeamRolesTable that would grant them access, as member roles are not stored, or omitted if
+// no roles grant access. actorId is the user who caused the event, who is not notified, and may be nil.
+func (s *TicketSubscriptions) GetSubscribers(
+	ctx context.Context,
+	guildId uint64,
+	ticketId int,
+	event SubscriptionEvent,
+	actorId *uint64,
+) ([]TicketSubscriber, error) {
+	column, ok := subscriptionEventColumns[event]
+	if !ok {
+		return nil, fmt.Errorf("invalid subscription event: %s", event)
+	}
+
+	rows, err := s.Query(ctx, fmt.Sprintf(ticketSubscriptionsGetSubscribers, column), guildId, ticketId, actorId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var subscribers []TicketSubscriber
+	for rows.Next() {
+		var subscriber TicketSubscriber
+		if err := rows.Scan(&subscriber.UserId, &subscriber.HasAccess, &subscriber.RoleIds); err != nil {
+			return nil, err
+		}
+
+		subscribers = append(subscribers, subscriber)
+	}
+
+	return subscribers, rows.Err()
+}
+
+// CanAccess reports whether the subscriber has access to the ticket, given the roles that they hold.
+func (s TicketSubscriber) CanAccess(memberRoleIds []uint64) bool {
+	if s.HasAccess {
+		return true
+	}
+
+	for _, roleId := range memberRoleIds {
+		for _, accessRoleId := range s.RoleIds {
+			if roleId == accessRoleId {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -36,3 +36,16 @@ func slice[T any](v ...T) []T {
 func ptr[T any](v T) *T {
 	return &v
 }
+
+// scopeError returns the error for a panel or support team that does not belong to the guild it was scoped to
+func scopeError(validPanel, validTeam bool) error {
+	if !validPanel {
+		return ErrPanelNotFound
+	}
+
+	if !validTeam {
+		return ErrSupportTeamNotFound
+	}
+
+	return nil
+}
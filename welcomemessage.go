@@ -36,8 +36,23 @@ func (w *WelcomeMessages) Get(ctx context.Context, guildId uint64) (welcomeMessa
 	return
 }
 
-func (w *WelcomeMessages) Set(ctx context.Context, guildId uint64, welcomeMessage string) (err error) {
+func (w *WelcomeMessages) Set(ctx context.Context, guildId uint64, welcomeMessage string) error {
 	query := `INSERT INTO welcome_messages("guild_id", "welcome_message") VALUES($1, $2) ON CONFLICT("guild_id") DO UPDATE SET "welcome_message" = $2;`
-	_, err = w.Exec(ctx, query, guildId, welcomeMessage)
-	return
+
+	tx, err := w.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, query, guildId, welcomeMessage); err != nil {
+		return err
+	}
+
+	if err := recordTemplatePlaceholders(ctx, tx, guildId, WelcomeMessageTemplateSource(), welcomeMessage); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
 }